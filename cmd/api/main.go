@@ -2,56 +2,254 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/pobradovic08/route-beacon/internal/auth"
+	"github.com/pobradovic08/route-beacon/internal/config"
 	"github.com/pobradovic08/route-beacon/internal/handler"
+	"github.com/pobradovic08/route-beacon/internal/logging"
+	"github.com/pobradovic08/route-beacon/internal/metrics"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/probe"
+	"github.com/pobradovic08/route-beacon/internal/ratelimit"
+	"github.com/pobradovic08/route-beacon/internal/ribcache"
+	"github.com/pobradovic08/route-beacon/internal/routercmd"
 	"github.com/pobradovic08/route-beacon/internal/store"
+	"github.com/pobradovic08/route-beacon/internal/telemetry"
 )
 
 func main() {
-	databaseURL := os.Getenv("DATABASE_URL")
-	listenAddr := os.Getenv("LISTEN_ADDR")
-	if listenAddr == "" {
-		listenAddr = ":8080"
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
 	}
 
+	cfg, opts, err := config.Load("api", os.Args[1:], os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config: %v\n", err)
+		os.Exit(2)
+	}
+	if opts.PrintConfig {
+		out, err := cfg.Redacted().YAML()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "config: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(out)
+		return
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logging: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	db, err := store.NewDB(ctx, databaseURL)
+	shutdownTracing, err := telemetry.SetupTracing(ctx, cfg.Tracing.Exporter)
 	if err != nil {
-		log.Fatalf("database: %v", err)
+		fatal("tracing setup failed", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// In demo mode the storage is loaded from fixtures and there is no
+	// database; the endpoints querying it directly are not registered.
+	var (
+		db *store.DB
+		st handler.Store
+	)
+	if cfg.Demo.Fixtures != "" {
+		mem, err := store.LoadMemory(cfg.Demo.Fixtures)
+		if err != nil {
+			fatal("loading demo fixtures failed", err)
+		}
+		slog.Warn("demo mode: serving fixtures without a database", slog.String("fixtures", cfg.Demo.Fixtures))
+		st = mem
+	} else {
+		db, err = store.NewDB(ctx, store.PoolConfig{
+			URL:              cfg.Database.URL,
+			MaxConns:         int32(cfg.Database.MaxConns),
+			MinConns:         int32(cfg.Database.MinConns),
+			MaxConnLifetime:  time.Duration(cfg.Database.MaxConnLifetime),
+			MaxConnIdleTime:  time.Duration(cfg.Database.MaxConnIdleTime),
+			StatementTimeout: time.Duration(cfg.Database.StatementTimeout),
+		})
+		if err != nil {
+			fatal("database connection failed", err)
+		}
+		defer db.Close()
+		if err := checkSchema(ctx, db, cfg.Database.AutoMigrate); err != nil {
+			fatal("database migration failed", err)
+		}
+		st = db
 	}
-	defer db.Close()
 
 	startTime := time.Now()
 
+	// Live route lookups are served from the in-memory RIB cache when
+	// enabled, and from the store otherwise.
+	var routes handler.RouteLookup = st
+	var ribCache *ribcache.Cache
+	if cfg.RIBCache.Enabled {
+		ribCache = ribcache.New(db, ribcache.Options{
+			PollInterval:   time.Duration(cfg.RIBCache.PollInterval),
+			ReloadInterval: time.Duration(cfg.RIBCache.ReloadInterval),
+			MaxStaleness:   time.Duration(cfg.RIBCache.MaxStaleness),
+		})
+		go ribCache.Run(ctx)
+		routes = ribCache
+	}
+
 	mux := http.NewServeMux()
+	limits := cfg.Limits
+	features := cfg.Features
+	if db == nil {
+		// Of the optional endpoint groups, only history works on fixtures.
+		features = config.FeaturesConfig{History: features.History}
+	}
+
+	// Each endpoint requires a scope and draws from the rate limit budget
+	// of its cost class.
+	var lookupLimiter, expensiveLimiter, probeLimiter, cliLimiter, authFailureLimiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		lookupLimiter = ratelimit.New(cfg.RateLimit.LookupPerMinute, cfg.RateLimit.LookupBurst)
+		expensiveLimiter = ratelimit.New(cfg.RateLimit.ExpensivePerMinute, cfg.RateLimit.ExpensiveBurst)
+		probeLimiter = ratelimit.New(cfg.RateLimit.ProbePerMinute, cfg.RateLimit.ProbeBurst)
+		cliLimiter = ratelimit.New(cfg.RateLimit.CLIPerMinute, cfg.RateLimit.CLIBurst)
+		authFailureLimiter = ratelimit.New(cfg.RateLimit.AuthFailurePerMinute, cfg.RateLimit.AuthFailureBurst)
+	}
+	lookup := func(h http.HandlerFunc) http.HandlerFunc {
+		return handler.RequireScope(auth.ScopeLookup, handler.RateLimit("lookup", lookupLimiter, h))
+	}
+	history := func(h http.HandlerFunc) http.HandlerFunc {
+		return handler.RequireScope(auth.ScopeHistory, handler.RateLimit("expensive", expensiveLimiter, h))
+	}
+	export := func(h http.HandlerFunc) http.HandlerFunc {
+		return handler.RequireScope(auth.ScopeExport, handler.RateLimit("expensive", expensiveLimiter, h))
+	}
+	probeScope := func(h http.HandlerFunc) http.HandlerFunc {
+		return handler.RequireScope(auth.ScopeProbe, handler.RateLimit("probe", probeLimiter, h))
+	}
+	cliScope := func(h http.HandlerFunc) http.HandlerFunc {
+		return handler.RequireScope(auth.ScopeCLI, handler.RateLimit("cli", cliLimiter, h))
+	}
 
 	// Health
-	mux.HandleFunc("GET /api/v1/health", handler.HandleGetHealth(db, startTime))
+	mux.HandleFunc("GET /api/v1/health", handler.HandleGetHealth(st, startTime))
+
+	// Prometheus metrics
+	if cfg.Metrics.Enabled && db != nil {
+		collector := metrics.NewCollector(db, time.Duration(cfg.Metrics.Interval))
+		collector.MustRegister(telemetry.Collectors()...)
+		if ribCache != nil {
+			collector.MustRegister(ribcache.Collectors()...)
+		}
+		go collector.Run(ctx)
+		mux.HandleFunc("GET /metrics", handler.RequireScope(auth.ScopeAdmin, collector.Handler().ServeHTTP))
+	}
 
 	// Routers
-	mux.HandleFunc("GET /api/v1/routers", handler.HandleListRouters(db))
-	mux.HandleFunc("GET /api/v1/routers/{routerId}", handler.HandleGetRouter(db))
+	mux.HandleFunc("GET /api/v1/routers", lookup(handler.HandleListRouters(st)))
+	mux.HandleFunc("GET /api/v1/routers/{routerId}", lookup(handler.HandleGetRouter(st)))
+
+	// RIB listing
+	if db != nil {
+		mux.HandleFunc("GET /api/v1/routers/{routerId}/routes", lookup(handler.HandleListRoutes(db, limits)))
+	}
 
 	// Route lookup
-	mux.HandleFunc("GET /api/v1/routers/{routerId}/routes/lookup", handler.HandleLookupRoutes(db))
+	mux.HandleFunc("GET /api/v1/routers/{routerId}/routes/lookup", lookup(handler.HandleLookupRoutes(st, routes, limits)))
 
 	// Route history
-	mux.HandleFunc("GET /api/v1/routers/{routerId}/routes/history", handler.HandleGetRouteHistory(db))
+	if features.History {
+		mux.HandleFunc("GET /api/v1/routers/{routerId}/routes/history", history(handler.HandleGetRouteHistory(st, limits)))
+		mux.HandleFunc("GET /api/v1/routes/history", history(handler.HandleGetMultiRouterHistory(st, limits)))
+	}
+
+	// Prefix timeline
+	if features.Timeline {
+		mux.HandleFunc("GET /api/v1/routers/{routerId}/routes/timeline", history(handler.HandleGetPrefixTimeline(db, limits)))
+	}
+
+	// BGPlay export
+	if features.BGPlay {
+		mux.HandleFunc("GET /api/v1/routes/bgplay", export(handler.HandleGetBGPlay(db, limits)))
+	}
 
-	// Apply middleware stack: Logger → Recover → CORS → JSON → mux
-	h := handler.Logger(handler.Recover(handler.CORS(handler.JSON(mux))))
+	// Route flap statistics
+	if features.Flaps {
+		mux.HandleFunc("GET /api/v1/routers/{routerId}/routes/flaps", history(handler.HandleGetFlapStats(db, limits)))
+	}
+
+	// Update churn
+	if features.Churn {
+		mux.HandleFunc("GET /api/v1/routers/{routerId}/routes/churn", history(handler.HandleGetChurn(db, limits)))
+	}
+
+	// RIB diff
+	if features.Diff {
+		mux.HandleFunc("GET /api/v1/routers/{routerId}/routes/diff", history(handler.HandleGetRIBDiff(db, limits)))
+	}
+
+	// RIB comparison between routers
+	if features.Compare {
+		mux.HandleFunc("GET /api/v1/routers/{routerId}/routes/compare/{otherRouterId}", history(handler.HandleCompareRouters(db, limits)))
+	}
+
+	// Ping and traceroute through probe agents
+	if cfg.Probe.Enabled {
+		probes := probe.NewRegistry(cfg.Probe.MaxConcurrent)
+		for _, a := range cfg.Probe.Agents {
+			probes.Register(&probe.HTTPAgent{URL: a.URL, Token: a.Token}, a.Routers...)
+		}
+		timeout := time.Duration(cfg.Probe.Timeout)
+		mux.HandleFunc("GET /api/v1/routers/{routerId}/ping", probeScope(handler.HandleProbe(st, probes, model.ProbePing, timeout)))
+		mux.HandleFunc("GET /api/v1/routers/{routerId}/traceroute", probeScope(handler.HandleProbe(st, probes, model.ProbeTraceroute, timeout)))
+		slog.Info("probes enabled", slog.Int("agents", len(cfg.Probe.Agents)))
+	}
+
+	// Router show commands over SSH
+	if cfg.RouterCLI.Enabled {
+		proxy, audit, err := newRouterCommandProxy(cfg.RouterCLI)
+		if err != nil {
+			fatal("router command setup failed", err)
+		}
+		mux.HandleFunc("GET /api/v1/routers/{routerId}/cli", cliScope(handler.HandleRouterCommand(st, proxy, audit)))
+		slog.Info("router commands enabled", slog.Int("routers", len(cfg.RouterCLI.Routers)))
+	}
+
+	authenticator, err := newAuthenticator(cfg.Auth, db)
+	if err != nil {
+		fatal("authentication setup failed", err)
+	}
+
+	// Failed authentications are charged to the client IP's own budget of
+	// failures, so that guessing API keys cannot hammer the api_keys table.
+	// Apply middleware stack: RequestID → RealIP → Logger → Recover → CORS → JSON → Authenticate → Instrument → mux
+	h := handler.RequestID(handler.RealIP(cfg.Server.TrustedProxyPrefixes())(handler.Logger(handler.Recover(
+		handler.CORS(cfg.CORS.AllowedOrigins)(handler.JSON(handler.Authenticate(authenticator, authFailureLimiter)(handler.Instrument(mux))))))))
 
 	srv := &http.Server{
-		Addr:    listenAddr,
-		Handler: h,
+		Addr:              cfg.Server.ListenAddr,
+		Handler:           h,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
 	}
 
 	// Graceful shutdown
@@ -59,14 +257,91 @@ func main() {
 		sigCh := make(chan os.Signal, 1)
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 		<-sigCh
-		log.Println("shutting down...")
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		slog.Info("shutting down")
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
 		defer shutdownCancel()
 		srv.Shutdown(shutdownCtx)
 	}()
 
-	log.Printf("listening on %s", listenAddr)
+	slog.Info("listening", slog.String("addr", cfg.Server.ListenAddr))
 	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-		log.Fatalf("server: %v", err)
+		fatal("server failed", err)
+	}
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
+}
+
+// newAuthenticator builds the request authenticator from the auth settings.
+func newAuthenticator(cfg config.AuthConfig, db *store.DB) (*auth.Authenticator, error) {
+	if !cfg.Enabled {
+		return auth.AllowAll(), nil
+	}
+
+	opts := auth.Options{AnonymousScopes: cfg.AnonymousScopes}
+	for _, k := range cfg.APIKeys {
+		opts.APIKeys = append(opts.APIKeys, auth.APIKey{Name: k.Name, Hash: k.SHA256, Scopes: k.Scopes})
+	}
+	if cfg.APIKeyTable {
+		opts.Lookup = auth.CachedLookup(func(ctx context.Context, hash string) (auth.Principal, bool, error) {
+			key, err := db.LookupAPIKey(ctx, hash)
+			if err != nil || key == nil {
+				return auth.Principal{}, false, err
+			}
+			return auth.Principal{Subject: key.Name, Method: auth.MethodAPIKey, Scopes: key.Scopes}, true, nil
+		}, time.Duration(cfg.APIKeyCacheTTL))
+	}
+	if cfg.JWT.JWKSFile != "" {
+		verifier, err := auth.LoadJWKS(cfg.JWT.JWKSFile, auth.JWTOptions{
+			Issuer:   cfg.JWT.Issuer,
+			Audience: cfg.JWT.Audience,
+			Leeway:   time.Duration(cfg.JWT.Leeway),
+		})
+		if err != nil {
+			return nil, err
+		}
+		opts.Verifier = verifier
+	}
+	slog.Info("authentication enabled",
+		slog.Int("api_keys", len(opts.APIKeys)),
+		slog.Bool("api_key_table", cfg.APIKeyTable),
+		slog.Bool("jwt", opts.Verifier != nil),
+		slog.Any("anonymous_scopes", cfg.AnonymousScopes))
+	return auth.New(opts), nil
+}
+
+// newRouterCommandProxy builds the router command proxy and its audit
+// logger from the router_cli settings.
+func newRouterCommandProxy(cfg config.RouterCLIConfig) (*routercmd.Proxy, *slog.Logger, error) {
+	proxy := routercmd.New(routercmd.Options{
+		Timeout:       time.Duration(cfg.Timeout),
+		CacheTTL:      time.Duration(cfg.CacheTTL),
+		MaxConcurrent: cfg.MaxConcurrent,
+	})
+	for _, r := range cfg.Routers {
+		runner, err := routercmd.NewSSH(routercmd.SSHOptions{
+			Address:        r.Address,
+			User:           r.Username,
+			Password:       r.Password,
+			PrivateKeyFile: r.PrivateKeyFile,
+			HostKey:        r.HostKey,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("router %s: %w", r.RouterID, err)
+		}
+		proxy.Register(r.RouterID, r.Platform, runner)
+	}
+
+	audit := slog.Default()
+	if cfg.AuditLog != "" {
+		f, err := os.OpenFile(cfg.AuditLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, nil, err
+		}
+		audit = slog.New(slog.NewJSONHandler(f, nil))
 	}
+	return proxy, audit, nil
 }
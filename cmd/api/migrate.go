@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
+	"github.com/pobradovic08/route-beacon/migrations"
+)
+
+const migrateUsage = "usage: api migrate up|down|status [flags]"
+
+// runMigrate implements "api migrate": up applies the pending migrations,
+// down reverts the latest one and status lists them all. The database is
+// taken from the usual configuration sources. It returns the exit code.
+func runMigrate(args []string) int {
+	if len(args) == 0 || (args[0] != "up" && args[0] != "down" && args[0] != "status") {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	action := args[0]
+	cfg, _, err := config.Load("api migrate "+action, args[1:], os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config: %v\n", err)
+		return 2
+	}
+
+	ms, err := store.LoadMigrations(migrations.FS)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Migrations may build indexes on large tables, so the statement
+	// timeout of the API does not apply.
+	db, err := store.NewDB(ctx, store.PoolConfig{URL: cfg.Database.URL, MaxConns: 1})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	switch action {
+	case "up":
+		done, err := db.MigrateUp(ctx, ms)
+		for _, m := range done {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		m, err := db.MigrateDown(ctx, ms)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+			return 1
+		}
+		if m == nil {
+			fmt.Println("no migration is applied")
+		} else {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+	case "status":
+		statuses, err := db.MigrationStatus(ctx, ms)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = model.FormatTime(*s.AppliedAt)
+			}
+			if s.Unknown {
+				applied += " (no migration file)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		w.Flush()
+	}
+	return 0
+}
+
+// checkSchema applies the pending migrations when autoMigrate is set, and
+// otherwise warns about them: queries against a schema that is behind
+// fail at request time.
+func checkSchema(ctx context.Context, db *store.DB, autoMigrate bool) error {
+	ms, err := store.LoadMigrations(migrations.FS)
+	if err != nil {
+		return err
+	}
+	if autoMigrate {
+		done, err := db.MigrateUp(ctx, ms)
+		for _, m := range done {
+			slog.Info("applied migration", slog.Int("version", m.Version), slog.String("name", m.Name))
+		}
+		return err
+	}
+
+	statuses, err := db.MigrationStatus(ctx, ms)
+	if err != nil {
+		// The schema may be managed by a role the API cannot inspect it as.
+		slog.Warn("checking schema version failed", slog.Any("error", err))
+		return nil
+	}
+	var pending []string
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		slog.Warn("database schema has pending migrations; run \"api migrate up\"", slog.Any("pending", pending))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Command probe-agent runs ping and traceroute on behalf of the route-beacon
+// API. It is deployed next to the routers it probes for and listed in the
+// probe.agents setting of the API.
+//
+// Usage:
+//
+//	PROBE_AGENT_TOKEN=... probe-agent [--listen :9180] [--fake]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/logging"
+	"github.com/pobradovic08/route-beacon/internal/probe"
+)
+
+func main() {
+	fs := flag.NewFlagSet("probe-agent", flag.ContinueOnError)
+	listen := fs.String("listen", envOr("PROBE_AGENT_LISTEN", ":9180"), "listen address (env PROBE_AGENT_LISTEN)")
+	tokenFile := fs.String("token-file", os.Getenv("PROBE_AGENT_TOKEN_FILE"), "file holding the token the API authenticates with (env PROBE_AGENT_TOKEN_FILE; or set PROBE_AGENT_TOKEN)")
+	ping := fs.String("ping", "ping", "ping command")
+	traceroute := fs.String("traceroute", "traceroute", "traceroute command")
+	fake := fs.Bool("fake", false, "return scripted results instead of running commands")
+	fakeDelay := fs.Duration("fake-delay", 500*time.Millisecond, "delay between scripted output lines")
+	logLevel := fs.String("log-level", envOr("LOG_LEVEL", "info"), "log level (env LOG_LEVEL)")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+
+	logger, err := logging.New(os.Stderr, "json", *logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logging: %v\n", err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
+	token := os.Getenv("PROBE_AGENT_TOKEN")
+	if *tokenFile != "" {
+		data, err := os.ReadFile(*tokenFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "token: %v\n", err)
+			os.Exit(2)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "token: set PROBE_AGENT_TOKEN or --token-file")
+		os.Exit(2)
+	}
+
+	var agent probe.Agent = &probe.Exec{Ping: *ping, Traceroute: *traceroute}
+	if *fake {
+		slog.Warn("fake mode: returning scripted results")
+		agent = &probe.Fake{Delay: *fakeDelay}
+	}
+
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           logRequests(probe.Handler(agent, token)),
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("listening", slog.String("addr", *listen))
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		slog.Error("server failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+// logRequests logs every probe request once it has been served.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		slog.Info("request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.Duration("duration", time.Since(start)))
+	})
+}
+
+func envOr(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/client"
+)
+
+var routersCmd = &command{
+	name:  "routers",
+	args:  "[ROUTER]",
+	nargs: [2]int{0, 1},
+	run: func(ctx context.Context, c *client.Client, o *options, args []string) error {
+		if len(args) == 1 {
+			router, err := c.GetRouter(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return renderRouter(os.Stdout, o.output, router)
+		}
+		routers, err := c.ListRouters(ctx)
+		if err != nil {
+			return err
+		}
+		return renderRouters(os.Stdout, o.output, routers)
+	},
+}
+
+var lookupQuery struct {
+	match string
+	at    timeFlag
+}
+
+var lookupCmd = &command{
+	name:  "lookup",
+	args:  "ROUTER PREFIX|ADDRESS",
+	nargs: [2]int{2, 2},
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&lookupQuery.match, "match", "", "match type: exact, longest or subnets (default exact for prefixes, longest for addresses)")
+		fs.Var(&lookupQuery.at, "at", "look up the routing table as it was at this time")
+	},
+	run: func(ctx context.Context, c *client.Client, o *options, args []string) error {
+		resp, err := c.Lookup(ctx, args[0], client.LookupQuery{
+			Prefix:    args[1],
+			MatchType: lookupQuery.match,
+			At:        lookupQuery.at.t,
+		})
+		if err != nil {
+			return err
+		}
+		return renderLookup(os.Stdout, o.output, resp)
+	},
+}
+
+var historyQuery struct {
+	routers    listFlag
+	scope      string
+	from, to   timeFlag
+	resolution string
+	changes    bool
+	limit      int
+	all        bool
+}
+
+var historyCmd = &command{
+	name:  "history",
+	args:  "PREFIX",
+	nargs: [2]int{1, 1},
+	flags: func(fs *flag.FlagSet) {
+		fs.Var(&historyQuery.routers, "routers", "comma-separated router IDs (default all routers)")
+		fs.StringVar(&historyQuery.scope, "scope", "", "exact or subnets (default exact)")
+		fs.Var(&historyQuery.from, "from", "start of the time range, or a duration before now (default 24h ago)")
+		fs.Var(&historyQuery.to, "to", "end of the time range (default now)")
+		fs.StringVar(&historyQuery.resolution, "resolution", "", "raw, intervals, hour or day (default raw)")
+		fs.BoolVar(&historyQuery.changes, "changes", false, "show attribute changes between consecutive events")
+		fs.IntVar(&historyQuery.limit, "limit", 0, "events per page")
+		fs.BoolVar(&historyQuery.all, "all", false, "fetch all pages")
+	},
+	run: func(ctx context.Context, c *client.Client, o *options, args []string) error {
+		q := client.HistoryQuery{
+			Prefix:     args[0],
+			Routers:    historyQuery.routers,
+			Scope:      historyQuery.scope,
+			From:       historyQuery.from.t,
+			To:         historyQuery.to.t,
+			Resolution: historyQuery.resolution,
+			Limit:      historyQuery.limit,
+		}
+		if historyQuery.changes {
+			q.View = "changes"
+		}
+		resp, err := c.History(ctx, q)
+		if err != nil {
+			return err
+		}
+		// Later pages are requested with the time range the API resolved
+		// for the first one, so that the default range does not shift.
+		if historyQuery.all && resp.NextCursor != nil {
+			if q.From, err = time.Parse(time.RFC3339Nano, resp.From); err != nil {
+				return err
+			}
+			if q.To, err = time.Parse(time.RFC3339Nano, resp.To); err != nil {
+				return err
+			}
+		}
+		for historyQuery.all && resp.NextCursor != nil {
+			q.Cursor = *resp.NextCursor
+			page, err := c.History(ctx, q)
+			if err != nil {
+				return err
+			}
+			resp.Events = append(resp.Events, page.Events...)
+			resp.NextCursor = page.NextCursor
+		}
+		return renderHistory(os.Stdout, o.output, resp)
+	},
+}
+
+var compareQuery struct {
+	table    string
+	afi      int
+	ignore   listFlag
+	statuses listFlag
+	limit    int
+	offset   int
+}
+
+var compareCmd = &command{
+	name:  "compare",
+	args:  "ROUTER OTHER_ROUTER",
+	nargs: [2]int{2, 2},
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&compareQuery.table, "table", "", "compare only this table")
+		fs.IntVar(&compareQuery.afi, "afi", 0, "compare only this address family: 4 or 6")
+		fs.Var(&compareQuery.ignore, "ignore", "comma-separated attributes to ignore")
+		fs.Var(&compareQuery.statuses, "status", "comma-separated statuses to show: only_a, only_b, different")
+		fs.IntVar(&compareQuery.limit, "limit", 0, "entries per page")
+		fs.IntVar(&compareQuery.offset, "offset", 0, "entries to skip")
+	},
+	run: func(ctx context.Context, c *client.Client, o *options, args []string) error {
+		resp, err := c.Compare(ctx, args[0], args[1], client.CompareQuery{
+			Table:    compareQuery.table,
+			AFI:      compareQuery.afi,
+			Ignore:   compareQuery.ignore,
+			Statuses: compareQuery.statuses,
+			Limit:    compareQuery.limit,
+			Offset:   compareQuery.offset,
+		})
+		if err != nil {
+			return err
+		}
+		return renderCompare(os.Stdout, o.output, resp)
+	},
+}
+
+var exportQuery struct {
+	routers  listFlag
+	scope    string
+	from, to timeFlag
+	out      string
+}
+
+var exportCmd = &command{
+	name:  "export",
+	args:  "PREFIX",
+	nargs: [2]int{1, 1},
+	flags: func(fs *flag.FlagSet) {
+		fs.Var(&exportQuery.routers, "routers", "comma-separated router IDs (default all routers)")
+		fs.StringVar(&exportQuery.scope, "scope", "", "exact or subnets (default exact)")
+		fs.Var(&exportQuery.from, "from", "start of the time range, or a duration before now (default 24h ago)")
+		fs.Var(&exportQuery.to, "to", "end of the time range (default now)")
+		fs.StringVar(&exportQuery.out, "out", "", "write to this file instead of standard output")
+	},
+	// The export is BGPlay JSON whatever the output format.
+	run: func(ctx context.Context, c *client.Client, o *options, args []string) error {
+		resp, err := c.BGPlay(ctx, client.BGPlayQuery{
+			Prefix:  args[0],
+			Routers: exportQuery.routers,
+			Scope:   exportQuery.scope,
+			From:    exportQuery.from.t,
+			To:      exportQuery.to.t,
+		})
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+		if exportQuery.out == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return os.WriteFile(exportQuery.out, data, 0o644)
+	},
+}
@@ -0,0 +1,285 @@
+// Command rbctl is a command-line client for the route-beacon API.
+//
+// Usage:
+//
+//	rbctl [flags] <command> [flags] [arguments]
+//
+// The API URL, key and default output format are read from a YAML file
+// (--config, RBCTL_CONFIG or $XDG_CONFIG_HOME/rbctl/config.yaml), the
+// environment (RBCTL_URL, RBCTL_API_KEY, RBCTL_OUTPUT) and flags, in
+// increasing order of precedence.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pobradovic08/route-beacon/internal/client"
+)
+
+const usage = `usage: rbctl [flags] <command> [flags] [arguments]
+
+Commands:
+  routers [ROUTER]               list routers, or show one with its RIB statistics
+  lookup ROUTER PREFIX|ADDRESS   look up routes on a router
+  history PREFIX                 show the announcements and withdrawals of a prefix
+  compare ROUTER OTHER_ROUTER    compare the routing tables of two routers
+  export PREFIX                  export the path evolution of a prefix for BGPlay
+
+Run "rbctl <command> -h" for the flags of a command.
+`
+
+// outputFormats are the accepted values of -o.
+var outputFormats = []string{"table", "json", "text"}
+
+// fileConfig is the content of the configuration file.
+type fileConfig struct {
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+	Output string `yaml:"output"`
+}
+
+// options are the settings shared by all commands.
+type options struct {
+	config  string
+	url     string
+	apiKey  string
+	output  string
+	timeout time.Duration
+}
+
+// register adds the shared flags to fs, keeping values set by an earlier
+// flag set as defaults.
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.config, "config", o.config, "configuration file (env RBCTL_CONFIG)")
+	fs.StringVar(&o.url, "url", o.url, "API base URL (env RBCTL_URL)")
+	fs.StringVar(&o.apiKey, "api-key", o.apiKey, "API key (env RBCTL_API_KEY)")
+	fs.StringVar(&o.output, "o", o.output, "output format: table, json or text (env RBCTL_OUTPUT)")
+	fs.DurationVar(&o.timeout, "timeout", o.timeout, "request timeout")
+}
+
+// resolve fills the settings not given as flags from the environment and
+// the configuration file.
+func (o *options) resolve(lookupEnv func(string) (string, bool)) error {
+	explicit := o.config != ""
+	if !explicit {
+		if v, ok := lookupEnv("RBCTL_CONFIG"); ok {
+			o.config, explicit = v, true
+		} else if dir, err := os.UserConfigDir(); err == nil {
+			o.config = filepath.Join(dir, "rbctl", "config.yaml")
+		}
+	}
+
+	var fc fileConfig
+	if o.config != "" {
+		data, err := os.ReadFile(o.config)
+		switch {
+		case err == nil:
+			dec := yaml.NewDecoder(bytes.NewReader(data))
+			dec.KnownFields(true)
+			if err := dec.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("parse %s: %w", o.config, err)
+			}
+		case explicit || !errors.Is(err, os.ErrNotExist):
+			return fmt.Errorf("read config: %w", err)
+		}
+	}
+
+	for _, s := range []struct {
+		value *string
+		env   string
+		file  string
+		def   string
+	}{
+		{&o.url, "RBCTL_URL", fc.URL, "http://localhost:8080"},
+		{&o.apiKey, "RBCTL_API_KEY", fc.APIKey, ""},
+		{&o.output, "RBCTL_OUTPUT", fc.Output, "table"},
+	} {
+		if *s.value != "" {
+			continue
+		}
+		if v, ok := lookupEnv(s.env); ok {
+			*s.value = v
+		} else if s.file != "" {
+			*s.value = s.file
+		} else {
+			*s.value = s.def
+		}
+	}
+	if !slices.Contains(outputFormats, o.output) {
+		return fmt.Errorf("unknown output format %q; must be table, json or text", o.output)
+	}
+	return nil
+}
+
+// command is a subcommand. Its run function receives the positional
+// arguments after flag parsing.
+type command struct {
+	name  string
+	args  string
+	nargs [2]int // minimum and maximum number of arguments
+	flags func(fs *flag.FlagSet)
+	run   func(ctx context.Context, c *client.Client, o *options, args []string) error
+}
+
+var commands = []*command{routersCmd, lookupCmd, historyCmd, compareCmd, exportCmd}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.LookupEnv, os.Stderr))
+}
+
+// run executes the command line and returns the exit code.
+func run(args []string, lookupEnv func(string) (string, bool), stderr io.Writer) int {
+	o := &options{timeout: 30 * time.Second}
+	global := flag.NewFlagSet("rbctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() {
+		fmt.Fprint(stderr, usage, "\nFlags:\n")
+		global.PrintDefaults()
+	}
+	o.register(global)
+	if err := global.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return 2
+	}
+
+	name := global.Arg(0)
+	i := slices.IndexFunc(commands, func(c *command) bool { return c.name == name })
+	if i < 0 {
+		fmt.Fprintf(stderr, "rbctl: unknown command %q\n\n%s", name, usage)
+		return 2
+	}
+	cmd := commands[i]
+
+	fs := flag.NewFlagSet("rbctl "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: rbctl %s [flags] %s\n\nFlags:\n", cmd.name, cmd.args)
+		fs.PrintDefaults()
+	}
+	o.register(fs)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	rest, err := parseInterspersed(fs, global.Args()[1:])
+	if err != nil {
+		return exitCode(err)
+	}
+	if len(rest) < cmd.nargs[0] || len(rest) > cmd.nargs[1] {
+		fs.Usage()
+		return 2
+	}
+	if err := o.resolve(lookupEnv); err != nil {
+		fmt.Fprintf(stderr, "rbctl: %v\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	c := client.New(o.url, o.apiKey, o.timeout)
+	if err := cmd.run(ctx, c, o, rest); err != nil {
+		fmt.Fprintf(stderr, "rbctl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parseInterspersed parses flags that may follow positional arguments, as
+// in "rbctl lookup r1 10.0.0.1 -o text", and returns the positional ones.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// exitCode maps a flag parsing error to the exit code.
+func exitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	return 2
+}
+
+// timeFlag is a time flag accepting RFC 3339 timestamps and durations
+// relative to now, such as "6h" for six hours ago or "7d" for a week ago.
+type timeFlag struct {
+	t   time.Time
+	now func() time.Time
+}
+
+// parseAgo parses a duration with an optional leading minus sign and, in
+// addition to the time.ParseDuration units, whole days.
+func parseAgo(s string) (time.Duration, error) {
+	s = strings.TrimPrefix(s, "-")
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, errors.New("invalid number of days")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func (f *timeFlag) String() string {
+	if f.t.IsZero() {
+		return ""
+	}
+	return f.t.Format(time.RFC3339)
+}
+
+func (f *timeFlag) Set(s string) error {
+	if d, err := parseAgo(s); err == nil {
+		now := time.Now
+		if f.now != nil {
+			now = f.now
+		}
+		f.t = now().Add(-d)
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return errors.New("must be an RFC 3339 timestamp or a duration such as 6h or 7d")
+	}
+	f.t = t
+	return nil
+}
+
+// listFlag is a comma-separated list flag that may also be repeated.
+type listFlag []string
+
+func (f *listFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *listFlag) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f = append(*f, v)
+		}
+	}
+	return nil
+}
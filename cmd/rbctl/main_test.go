@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("url: https://file\napi_key: file-key\noutput: text\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &options{config: path, url: "https://flag"}
+	if err := o.resolve(env(map[string]string{"RBCTL_API_KEY": "env-key"})); err != nil {
+		t.Fatal(err)
+	}
+	if o.url != "https://flag" || o.apiKey != "env-key" || o.output != "text" {
+		t.Errorf("unexpected options %+v", o)
+	}
+
+	o = &options{}
+	if err := o.resolve(env(map[string]string{"RBCTL_CONFIG": filepath.Join(t.TempDir(), "missing.yaml")})); err == nil {
+		t.Error("expected an error for a missing explicit configuration file")
+	}
+	o = &options{output: "xml", config: path}
+	if err := o.resolve(env(nil)); err == nil || !strings.Contains(err.Error(), "output format") {
+		t.Errorf("expected an output format error, got %v", err)
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	match := fs.String("match", "", "")
+	args, err := parseInterspersed(fs, []string{"r1", "--match", "exact", "10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(args, []string{"r1", "10.0.0.0/8"}) || *match != "exact" {
+		t.Errorf("unexpected result %v, match %q", args, *match)
+	}
+}
+
+func TestTimeFlag(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"6h", now.Add(-6 * time.Hour)},
+		{"-90m", now.Add(-90 * time.Minute)},
+		{"7d", now.Add(-7 * 24 * time.Hour)},
+		{"2026-01-01T00:00:00Z", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		f := timeFlag{now: func() time.Time { return now }}
+		if err := f.Set(tt.in); err != nil || !f.t.Equal(tt.want) {
+			t.Errorf("%s: got %v, %v", tt.in, f.t, err)
+		}
+	}
+	f := timeFlag{}
+	if err := f.Set("yesterday"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestRenderLookupTable(t *testing.T) {
+	nh, lp := "192.0.2.1", 100
+	resp := &model.RouteLookupResponse{
+		Prefix: "10.0.0.0/8",
+		Routes: []model.Route{{
+			Prefix:      "10.0.0.0/8",
+			NextHop:     &nh,
+			ASPath:      []any{65001.0, []any{64500.0, 64501.0}},
+			LocalPref:   &lp,
+			Communities: []model.Community{{Type: "standard", Value: "65001:1"}},
+		}},
+	}
+	var b strings.Builder
+	if err := renderLookup(&b, "table", resp); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "PREFIX") {
+		t.Fatalf("unexpected table:\n%s", b.String())
+	}
+	if fields := strings.Fields(lines[1]); !slices.Equal(fields, []string{"10.0.0.0/8", "0", "192.0.2.1", "65001", "{64500,64501}", "-", "100", "-", "65001:1"}) {
+		t.Errorf("unexpected row %q", fields)
+	}
+}
+
+func TestChange(t *testing.T) {
+	tests := []struct {
+		c    model.AttributeChange
+		want string
+	}{
+		{model.AttributeChange{Attribute: "local_pref", Old: 100.0, New: 200.0}, "local_pref: 100 -> 200"},
+		{model.AttributeChange{Attribute: "med", Old: 50.0}, "med: 50 -> -"},
+		{model.AttributeChange{Attribute: "communities", Added: []string{"65001:2"}, Removed: []string{"65001:1"}}, "communities: +65001:2 -65001:1"},
+	}
+	for _, tt := range tests {
+		if got := change(tt.c); got != tt.want {
+			t.Errorf("expected %q, got %q", tt.want, got)
+		}
+	}
+}
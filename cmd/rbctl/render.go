@@ -0,0 +1,380 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// Every render function writes v as indented JSON, as a table of one row
+// per item, or as text in the style of a router CLI.
+
+func renderJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func newTable(w io.Writer, header ...string) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	return tw
+}
+
+func row(tw *tabwriter.Writer, cols ...string) {
+	fmt.Fprintln(tw, strings.Join(cols, "\t"))
+}
+
+func renderRouters(w io.Writer, format string, routers []model.Router) error {
+	switch format {
+	case "json":
+		return renderJSON(w, routers)
+	case "text":
+		for _, r := range routers {
+			writeRouterText(w, r)
+			fmt.Fprintln(w)
+		}
+		return nil
+	}
+	tw := newTable(w, "ID", "NAME", "ASN", "STATUS", "EOR", "LOCATION", "LAST SEEN")
+	for _, r := range routers {
+		row(tw, r.ID, r.DisplayName, optInt64(r.ASNumber), r.Status, yesNo(r.EORReceived), optString(r.Location), r.LastSeen)
+	}
+	return tw.Flush()
+}
+
+func renderRouter(w io.Writer, format string, r *model.RouterDetail) error {
+	switch format {
+	case "json":
+		return renderJSON(w, r)
+	case "text":
+		writeRouterText(w, r.Router)
+		fmt.Fprintf(w, "  %d routes, %d prefixes (%d IPv4, %d IPv6), %d next hops\n",
+			r.RouteCount, r.UniquePrefixes, r.IPv4Routes, r.IPv6Routes, r.PeerCount)
+		if r.AvgASPathLen != nil {
+			fmt.Fprintf(w, "  Average AS path length %.1f\n", *r.AvgASPathLen)
+		}
+		if r.SessionStart != nil {
+			fmt.Fprintf(w, "  Session up since %s\n", *r.SessionStart)
+		}
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, kv := range [][2]string{
+		{"ID", r.ID},
+		{"Name", r.DisplayName},
+		{"Router IP", optString(r.RouterIP)},
+		{"Hostname", optString(r.Hostname)},
+		{"ASN", optInt64(r.ASNumber)},
+		{"Description", optString(r.Description)},
+		{"Location", optString(r.Location)},
+		{"Status", r.Status},
+		{"EOR received", yesNo(r.EORReceived)},
+		{"Session start", optString(r.SessionStart)},
+		{"First seen", r.FirstSeen},
+		{"Last seen", r.LastSeen},
+		{"Routes", strconv.FormatInt(r.RouteCount, 10)},
+		{"Prefixes", strconv.FormatInt(r.UniquePrefixes, 10)},
+		{"IPv4 routes", strconv.FormatInt(r.IPv4Routes, 10)},
+		{"IPv6 routes", strconv.FormatInt(r.IPv6Routes, 10)},
+		{"Next hops", strconv.FormatInt(r.PeerCount, 10)},
+	} {
+		row(tw, kv[0]+":", kv[1])
+	}
+	return tw.Flush()
+}
+
+func writeRouterText(w io.Writer, r model.Router) {
+	fmt.Fprintf(w, "Router %s", r.ID)
+	if r.DisplayName != r.ID {
+		fmt.Fprintf(w, " (%s)", r.DisplayName)
+	}
+	if r.ASNumber != nil {
+		fmt.Fprintf(w, ", AS %d", *r.ASNumber)
+	}
+	fmt.Fprintf(w, ", status %s\n", r.Status)
+	if r.RouterIP != nil {
+		fmt.Fprintf(w, "  Address %s\n", *r.RouterIP)
+	}
+	if r.Description != nil {
+		fmt.Fprintf(w, "  Description: %s\n", *r.Description)
+	}
+	if r.Location != nil {
+		fmt.Fprintf(w, "  Location: %s\n", *r.Location)
+	}
+	eor := "not received"
+	if r.EORReceived {
+		eor = "received"
+	}
+	fmt.Fprintf(w, "  First seen %s, last seen %s, End-of-RIB %s\n", r.FirstSeen, r.LastSeen, eor)
+}
+
+func renderLookup(w io.Writer, format string, resp *model.RouteLookupResponse) error {
+	switch format {
+	case "json":
+		return renderJSON(w, resp)
+	case "text":
+		_, err := io.WriteString(w, resp.PlainText)
+		return err
+	}
+	tw := newTable(w, "PREFIX", "PATH", "NEXT HOP", "AS PATH", "ORIGIN", "LOCAL PREF", "MED", "COMMUNITIES")
+	for _, r := range resp.Routes {
+		row(tw, r.Prefix, strconv.FormatInt(r.PathID, 10), optString(r.NextHop), asPath(r.ASPath),
+			optString(r.Origin), optInt(r.LocalPref), optInt(r.MED), communities(r.Communities))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if resp.Meta.Truncated {
+		fmt.Fprintln(w, "(truncated)")
+	}
+	return nil
+}
+
+func renderHistory(w io.Writer, format string, resp *model.RouteHistoryResponse) error {
+	if format == "json" {
+		return renderJSON(w, resp)
+	}
+	switch resp.Resolution {
+	case "intervals":
+		tw := newTable(w, "ROUTER", "PREFIX", "PATH", "START", "END", "NEXT HOP", "AS PATH")
+		for _, iv := range resp.Intervals {
+			end := iv.End
+			if iv.Ongoing {
+				end = "-"
+			}
+			row(tw, iv.RouterID, iv.Prefix, optInt64(iv.PathID), iv.Start, end, optString(iv.NextHop), asPath(iv.ASPath))
+		}
+		return finishHistory(w, tw, resp)
+	case "hour", "day":
+		tw := newTable(w, "TIME", "ROUTER", "PREFIX", "ANNOUNCEMENTS", "WITHDRAWALS", "PATHS", "LAST ACTION")
+		for _, b := range resp.Buckets {
+			row(tw, b.Time, b.RouterID, b.Prefix, strconv.FormatInt(b.Announcements, 10),
+				strconv.FormatInt(b.Withdrawals, 10), strconv.FormatInt(b.Paths, 10), b.LastAction)
+		}
+		return finishHistory(w, tw, resp)
+	}
+
+	if format == "text" {
+		for _, e := range resp.Events {
+			fmt.Fprintf(w, "%s %s: %s", e.Timestamp, e.RouterID, e.Prefix)
+			if e.PathID != nil {
+				fmt.Fprintf(w, " path %d", *e.PathID)
+			}
+			if e.Action == "withdraw" {
+				fmt.Fprint(w, " withdrawn")
+			} else {
+				fmt.Fprintf(w, " announced via %s, AS path %s", optString(e.NextHop), asPath(e.ASPath))
+			}
+			if e.ChangeType != nil {
+				fmt.Fprintf(w, " (%s)", *e.ChangeType)
+			}
+			fmt.Fprintln(w)
+			for _, c := range e.Changes {
+				fmt.Fprintf(w, "  %s\n", change(c))
+			}
+		}
+		if resp.NextCursor != nil {
+			fmt.Fprintln(w, "(more events; use --all)")
+		}
+		return nil
+	}
+
+	header := []string{"TIME", "ROUTER", "ACTION", "PREFIX", "PATH", "NEXT HOP", "AS PATH", "LOCAL PREF", "MED"}
+	changes := resp.View == "changes"
+	if changes {
+		header = append(header, "CHANGE", "CHANGES")
+	}
+	tw := newTable(w, header...)
+	for _, e := range resp.Events {
+		cols := []string{e.Timestamp, e.RouterID, e.Action, e.Prefix, optInt64(e.PathID),
+			optString(e.NextHop), asPath(e.ASPath), optInt(e.LocalPref), optInt(e.MED)}
+		if changes {
+			var cs []string
+			for _, c := range e.Changes {
+				cs = append(cs, change(c))
+			}
+			cols = append(cols, optString(e.ChangeType), strings.Join(cs, "; "))
+		}
+		row(tw, cols...)
+	}
+	return finishHistory(w, tw, resp)
+}
+
+func finishHistory(w io.Writer, tw *tabwriter.Writer, resp *model.RouteHistoryResponse) error {
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if resp.NextCursor != nil {
+		fmt.Fprintln(w, "(more events; use --all)")
+	}
+	if resp.Truncated {
+		fmt.Fprintln(w, "(truncated)")
+	}
+	return nil
+}
+
+func renderCompare(w io.Writer, format string, resp *model.RouterCompareResponse) error {
+	switch format {
+	case "json":
+		return renderJSON(w, resp)
+	case "text":
+		a, b := resp.RouterA.ID, resp.RouterB.ID
+		s := resp.Summary
+		fmt.Fprintf(w, "Comparing %s with %s\n", routerName(resp.RouterA), routerName(resp.RouterB))
+		fmt.Fprintf(w, "  %d only on %s, %d only on %s, %d different, %d identical\n\n",
+			s.OnlyA, a, s.OnlyB, b, s.Different, s.Identical)
+		for _, e := range resp.Entries {
+			fmt.Fprintf(w, "%s (%s): ", e.Prefix, e.Table)
+			switch e.Status {
+			case "only_a":
+				fmt.Fprintf(w, "only on %s\n  %s\n", a, routeSummary(e.A))
+			case "only_b":
+				fmt.Fprintf(w, "only on %s\n  %s\n", b, routeSummary(e.B))
+			default:
+				fmt.Fprintln(w, "different")
+				for _, c := range e.Changes {
+					fmt.Fprintf(w, "  %s\n", change(c))
+				}
+			}
+		}
+		if resp.HasMore {
+			fmt.Fprintln(w, "(more entries; use --offset)")
+		}
+		return nil
+	}
+	tw := newTable(w, "PREFIX", "TABLE", "STATUS", "DETAILS")
+	for _, e := range resp.Entries {
+		var details string
+		switch e.Status {
+		case "only_a":
+			details = routeSummary(e.A)
+		case "only_b":
+			details = routeSummary(e.B)
+		default:
+			var cs []string
+			for _, c := range e.Changes {
+				cs = append(cs, change(c))
+			}
+			details = strings.Join(cs, "; ")
+		}
+		row(tw, e.Prefix, e.Table, e.Status, details)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	s := resp.Summary
+	fmt.Fprintf(w, "\nonly_a %d, only_b %d, different %d, identical %d\n", s.OnlyA, s.OnlyB, s.Different, s.Identical)
+	if resp.HasMore {
+		fmt.Fprintln(w, "(more entries; use --offset)")
+	}
+	return nil
+}
+
+func routerName(r model.RouterSummary) string {
+	if r.DisplayName == r.ID {
+		return r.ID
+	}
+	return r.ID + " (" + r.DisplayName + ")"
+}
+
+// routeSummary is a one-line rendering of the main attributes of a route.
+func routeSummary(r *model.Route) string {
+	if r == nil {
+		return ""
+	}
+	return "via " + optString(r.NextHop) + ", AS path " + asPath(r.ASPath)
+}
+
+// change renders an attribute change as "attribute: old -> new", or with
+// the added and removed members of list attributes.
+func change(c model.AttributeChange) string {
+	if len(c.Added) > 0 || len(c.Removed) > 0 {
+		var parts []string
+		for _, v := range c.Added {
+			parts = append(parts, "+"+v)
+		}
+		for _, v := range c.Removed {
+			parts = append(parts, "-"+v)
+		}
+		return c.Attribute + ": " + strings.Join(parts, " ")
+	}
+	return c.Attribute + ": " + attrValue(c.Old) + " -> " + attrValue(c.New)
+}
+
+func attrValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case []any:
+		return asPath(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// asPath renders an AS path, with AS sets in braces.
+func asPath(path []any) string {
+	if len(path) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(path))
+	for i, seg := range path {
+		if set, ok := seg.([]any); ok {
+			nums := make([]string, len(set))
+			for j, n := range set {
+				nums[j] = attrValue(n)
+			}
+			parts[i] = "{" + strings.Join(nums, ",") + "}"
+			continue
+		}
+		parts[i] = attrValue(seg)
+	}
+	return strings.Join(parts, " ")
+}
+
+func communities(cs []model.Community) string {
+	if len(cs) == 0 {
+		return "-"
+	}
+	vals := make([]string, len(cs))
+	for i, c := range cs {
+		vals[i] = c.Value
+	}
+	return strings.Join(vals, " ")
+}
+
+func optString(s *string) string {
+	if s == nil {
+		return "-"
+	}
+	return *s
+}
+
+func optInt(n *int) string {
+	if n == nil {
+		return "-"
+	}
+	return strconv.Itoa(*n)
+}
+
+func optInt64(n *int64) string {
+	if n == nil {
+		return "-"
+	}
+	return strconv.FormatInt(*n, 10)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,251 @@
+// Package auth authenticates API callers by static API key or JWT bearer
+// token and resolves the scopes they were granted.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scopes gate groups of endpoints. ScopeAdmin implies every other scope.
+const (
+	ScopeLookup  = "lookup"  // router listing, RIB listing and route lookup
+	ScopeHistory = "history" // history, timeline and analytics
+	ScopeExport  = "export"  // bulk exports such as BGPlay
+	ScopeProbe   = "probe"   // ping and traceroute
+	ScopeCLI     = "cli"     // router show commands over SSH
+	ScopeAdmin   = "admin"   // operational endpoints such as /metrics
+)
+
+// Scopes lists every known scope.
+var Scopes = []string{ScopeLookup, ScopeHistory, ScopeExport, ScopeProbe, ScopeCLI, ScopeAdmin}
+
+// Authentication methods reported in Principal.Method.
+const (
+	MethodAnonymous = "anonymous"
+	MethodAPIKey    = "api_key"
+	MethodJWT       = "jwt"
+)
+
+// APIKeyHeader carries an API key as an alternative to a bearer token.
+const APIKeyHeader = "X-API-Key"
+
+// ErrInvalidCredentials is returned for unknown API keys, malformed
+// Authorization headers and tokens failing verification.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal is an authenticated (or anonymous) caller.
+type Principal struct {
+	Subject string // API key name or JWT subject; empty when anonymous
+	Method  string
+	Scopes  []string
+}
+
+// Anonymous reports whether the caller presented no credentials.
+func (p Principal) Anonymous() bool {
+	return p.Method == MethodAnonymous
+}
+
+// HasScope reports whether the caller was granted scope, directly or via
+// ScopeAdmin.
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal carried by ctx.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// HashKey returns the hex-encoded SHA-256 digest of an API key, the form in
+// which keys are stored in configuration and the api_keys table.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKey is a statically configured API key.
+type APIKey struct {
+	Name   string
+	Hash   string // HashKey of the key
+	Scopes []string
+}
+
+// KeyLookup resolves an API key hash to its principal. ok is false for
+// unknown, expired or revoked keys.
+type KeyLookup func(ctx context.Context, hash string) (p Principal, ok bool, err error)
+
+// Options configures an Authenticator.
+type Options struct {
+	// APIKeys are checked before Lookup.
+	APIKeys []APIKey
+	// Lookup, if set, resolves keys missing from APIKeys.
+	Lookup KeyLookup
+	// Verifier, if set, accepts JWT bearer tokens.
+	Verifier *JWTVerifier
+	// AnonymousScopes are granted to requests without credentials.
+	AnonymousScopes []string
+}
+
+// Authenticator resolves the principal of a request.
+type Authenticator struct {
+	keys      map[string]Principal
+	lookup    KeyLookup
+	verifier  *JWTVerifier
+	anonymous Principal
+	allowAll  bool
+}
+
+// New returns an Authenticator enforcing opts.
+func New(opts Options) *Authenticator {
+	a := &Authenticator{
+		keys:      make(map[string]Principal, len(opts.APIKeys)),
+		lookup:    opts.Lookup,
+		verifier:  opts.Verifier,
+		anonymous: Principal{Method: MethodAnonymous, Scopes: opts.AnonymousScopes},
+	}
+	for _, k := range opts.APIKeys {
+		a.keys[strings.ToLower(k.Hash)] = Principal{Subject: k.Name, Method: MethodAPIKey, Scopes: k.Scopes}
+	}
+	return a
+}
+
+// AllowAll returns an Authenticator that ignores credentials and grants
+// every scope, for deployments with authentication disabled.
+func AllowAll() *Authenticator {
+	return &Authenticator{
+		anonymous: Principal{Method: MethodAnonymous, Scopes: Scopes},
+		allowAll:  true,
+	}
+}
+
+// Authenticate resolves the principal of r from an "Authorization: Bearer"
+// header (a JWT, or an API key when the token is not a JWT) or an X-API-Key
+// header. Requests without credentials get the anonymous principal.
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	if a.allowAll {
+		return a.anonymous, nil
+	}
+
+	if h := r.Header.Get("Authorization"); h != "" {
+		scheme, token, ok := strings.Cut(h, " ")
+		token = strings.TrimSpace(token)
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return Principal{}, ErrInvalidCredentials
+		}
+		if strings.Count(token, ".") == 2 {
+			if a.verifier == nil {
+				return Principal{}, ErrInvalidCredentials
+			}
+			return a.verifier.Verify(token)
+		}
+		return a.authenticateKey(r.Context(), token)
+	}
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.authenticateKey(r.Context(), key)
+	}
+	return a.anonymous, nil
+}
+
+// HasCredentials reports whether r carries an Authorization or X-API-Key
+// header.
+func HasCredentials(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || r.Header.Get(APIKeyHeader) != ""
+}
+
+func (a *Authenticator) authenticateKey(ctx context.Context, key string) (Principal, error) {
+	hash := HashKey(key)
+	if p, ok := a.keys[hash]; ok {
+		return p, nil
+	}
+	if a.lookup != nil {
+		p, ok, err := a.lookup(ctx, hash)
+		if err != nil {
+			return Principal{}, err
+		}
+		if ok {
+			return p, nil
+		}
+	}
+	return Principal{}, ErrInvalidCredentials
+}
+
+// maxCachedKeys bounds each of the CachedLookup caches of known and
+// unknown keys.
+const maxCachedKeys = 4096
+
+// CachedLookup wraps lookup so that each hash is resolved at most once per
+// ttl. Unknown keys are cached too, so repeated attempts with a bad key do
+// not reach the database. They are kept apart from known keys, so that a
+// stream of unknown keys only evicts other unknown keys. Errors are not
+// cached.
+func CachedLookup(lookup KeyLookup, ttl time.Duration) KeyLookup {
+	type entry struct {
+		p       Principal
+		expires time.Time
+	}
+	var (
+		mu      sync.Mutex
+		known   = map[string]entry{}
+		unknown = map[string]time.Time{}
+	)
+	return func(ctx context.Context, hash string) (Principal, bool, error) {
+		now := time.Now()
+		mu.Lock()
+		e, hit := known[hash]
+		expires, miss := unknown[hash]
+		mu.Unlock()
+		if hit && now.Before(e.expires) {
+			return e.p, true, nil
+		}
+		if miss && now.Before(expires) {
+			return Principal{}, false, nil
+		}
+
+		p, ok, err := lookup(ctx, hash)
+		if err != nil {
+			return Principal{}, false, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if ok {
+			makeRoom(known, now, func(e entry) time.Time { return e.expires })
+			known[hash] = entry{p: p, expires: now.Add(ttl)}
+		} else {
+			makeRoom(unknown, now, func(t time.Time) time.Time { return t })
+			unknown[hash] = now.Add(ttl)
+		}
+		return p, ok, nil
+	}
+}
+
+// makeRoom drops the expired entries of a full cache, and all of them when
+// none has expired.
+func makeRoom[V any](cache map[string]V, now time.Time, expires func(V) time.Time) {
+	if len(cache) < maxCachedKeys {
+		return
+	}
+	for h, v := range cache {
+		if !now.Before(expires(v)) {
+			delete(cache, h)
+		}
+	}
+	if len(cache) >= maxCachedKeys {
+		clear(cache)
+	}
+}
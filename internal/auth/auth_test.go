@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHashKey(t *testing.T) {
+	// printf %s secret | sha256sum
+	want := "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"
+	if got := HashKey("secret"); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	p := Principal{Scopes: []string{ScopeLookup}}
+	if !p.HasScope(ScopeLookup) || p.HasScope(ScopeHistory) {
+		t.Errorf("unexpected scopes for %v", p.Scopes)
+	}
+	admin := Principal{Scopes: []string{ScopeAdmin}}
+	for _, s := range Scopes {
+		if !admin.HasScope(s) {
+			t.Errorf("admin lacks %q", s)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	lookups := 0
+	a := New(Options{
+		APIKeys: []APIKey{{Name: "noc", Hash: HashKey("static-key"), Scopes: []string{ScopeHistory}}},
+		Lookup: func(ctx context.Context, hash string) (Principal, bool, error) {
+			lookups++
+			if hash == HashKey("table-key") {
+				return Principal{Subject: "partner", Method: MethodAPIKey, Scopes: []string{ScopeExport}}, true, nil
+			}
+			return Principal{}, false, nil
+		},
+		AnonymousScopes: []string{ScopeLookup},
+	})
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		subject string
+		method  string
+		wantErr bool
+	}{
+		{"anonymous", "", "", "", MethodAnonymous, false},
+		{"static key header", "X-API-Key", "static-key", "noc", MethodAPIKey, false},
+		{"static key bearer", "Authorization", "Bearer static-key", "noc", MethodAPIKey, false},
+		{"table key", "X-API-Key", "table-key", "partner", MethodAPIKey, false},
+		{"unknown key", "X-API-Key", "nope", "", "", true},
+		{"basic auth", "Authorization", "Basic dXNlcjpwYXNz", "", "", true},
+		{"empty bearer", "Authorization", "Bearer ", "", "", true},
+		{"jwt without verifier", "Authorization", "Bearer a.b.c", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/routers", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			p, err := a.Authenticate(req)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Subject != tt.subject || p.Method != tt.method {
+				t.Errorf("expected %s/%s, got %s/%s", tt.subject, tt.method, p.Subject, p.Method)
+			}
+		})
+	}
+	if lookups != 2 {
+		t.Errorf("expected 2 table lookups, got %d", lookups)
+	}
+}
+
+func TestAuthenticateLookupError(t *testing.T) {
+	boom := errors.New("db down")
+	a := New(Options{Lookup: func(ctx context.Context, hash string) (Principal, bool, error) {
+		return Principal{}, false, boom
+	}})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(APIKeyHeader, "key")
+	if _, err := a.Authenticate(req); !errors.Is(err, boom) {
+		t.Fatalf("expected lookup error, got %v", err)
+	}
+}
+
+func TestAllowAll(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(APIKeyHeader, "anything")
+	p, err := AllowAll().Authenticate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Anonymous() || !slices.Equal(p.Scopes, Scopes) {
+		t.Errorf("expected anonymous with every scope, got %+v", p)
+	}
+}
+
+func TestCachedLookup(t *testing.T) {
+	calls := 0
+	lookup := CachedLookup(func(ctx context.Context, hash string) (Principal, bool, error) {
+		calls++
+		return Principal{Subject: hash}, hash == "known", nil
+	}, time.Hour)
+
+	for range 3 {
+		if _, ok, _ := lookup(context.Background(), "known"); !ok {
+			t.Fatal("expected known key")
+		}
+		if _, ok, _ := lookup(context.Background(), "unknown"); ok {
+			t.Fatal("expected unknown key")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 underlying lookups, got %d", calls)
+	}
+}
+
+func TestCachedLookupKeepsKnownKeysUnderUnknownFlood(t *testing.T) {
+	calls := 0
+	lookup := CachedLookup(func(ctx context.Context, hash string) (Principal, bool, error) {
+		calls++
+		return Principal{Subject: hash}, hash == "known", nil
+	}, time.Hour)
+
+	lookup(context.Background(), "known")
+	for i := range 2 * maxCachedKeys {
+		lookup(context.Background(), fmt.Sprintf("unknown-%d", i))
+	}
+	calls = 0
+	if _, ok, _ := lookup(context.Background(), "known"); !ok || calls != 0 {
+		t.Errorf("expected the known key to stay cached, got ok %v after %d lookups", ok, calls)
+	}
+}
@@ -0,0 +1,363 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JWTOptions configures claim validation of a JWTVerifier.
+type JWTOptions struct {
+	// Issuer, if set, must equal the iss claim.
+	Issuer string
+	// Audience, if set, must appear in the aud claim.
+	Audience string
+	// Leeway tolerates clock skew when checking exp and nbf.
+	Leeway time.Duration
+}
+
+// JWTVerifier validates JWT bearer tokens against the keys of a JWKS
+// document. RS256/384/512, ES256/384 and EdDSA are supported; tokens must
+// carry an exp claim. Scopes are read from the space-separated "scope"
+// claim or the "scp" array.
+type JWTVerifier struct {
+	keys []jwk
+	opts JWTOptions
+	now  func() time.Time
+}
+
+// jwk is a parsed JSON Web Key.
+type jwk struct {
+	kid string
+	alg string // optional restriction from the key's "alg" member
+	key crypto.PublicKey
+}
+
+// LoadJWKS reads a JWKS file and returns a verifier for its keys.
+func LoadJWKS(path string, opts JWTOptions) (*JWTVerifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read JWKS: %w", err)
+	}
+	return NewJWTVerifier(data, opts)
+}
+
+// NewJWTVerifier parses a JWKS document. Keys of unsupported types and keys
+// not meant for signatures are skipped; at least one usable key is required.
+func NewJWTVerifier(jwks []byte, opts JWTOptions) (*JWTVerifier, error) {
+	var doc struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(jwks, &doc); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	v := &JWTVerifier{opts: opts, now: time.Now}
+	for i, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = rsaKey(k.N, k.E)
+		case "EC":
+			key, err = ecKey(k.Crv, k.X, k.Y)
+		case "OKP":
+			key, err = okpKey(k.Crv, k.X)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %d (%q): %w", i, k.Kid, err)
+		}
+		v.keys = append(v.keys, jwk{kid: k.Kid, alg: k.Alg, key: key})
+	}
+	if len(v.keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+	return v, nil
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func rsaKey(n, e string) (crypto.PublicKey, error) {
+	nb, err := decodeSegment(n)
+	if err != nil || len(nb) == 0 {
+		return nil, errors.New("invalid RSA modulus")
+	}
+	eb, err := decodeSegment(e)
+	if err != nil || len(eb) == 0 || len(eb) > 4 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	exp := 0
+	for _, b := range eb {
+		exp = exp<<8 | int(b)
+	}
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: exp}
+	if key.N.BitLen() < 2048 {
+		return nil, errors.New("RSA key shorter than 2048 bits")
+	}
+	return key, nil
+}
+
+func ecKey(crv, x, y string) (crypto.PublicKey, error) {
+	var curve elliptic.Curve
+	switch crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+	xb, err := decodeSegment(x)
+	if err != nil {
+		return nil, errors.New("invalid EC x coordinate")
+	}
+	yb, err := decodeSegment(y)
+	if err != nil {
+		return nil, errors.New("invalid EC y coordinate")
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	if len(xb) != size || len(yb) != size {
+		return nil, errors.New("invalid EC coordinate length")
+	}
+	// Validate the point through the uncompressed encoding parser, which
+	// rejects points not on the curve.
+	point := append([]byte{4}, append(xb, yb...)...)
+	key, err := ecdsa.ParseUncompressedPublicKey(curve, point)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EC point: %w", err)
+	}
+	return key, nil
+}
+
+func okpKey(crv, x string) (crypto.PublicKey, error) {
+	if crv != "Ed25519" {
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+	xb, err := decodeSegment(x)
+	if err != nil || len(xb) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 key")
+	}
+	return ed25519.PublicKey(xb), nil
+}
+
+// claims are the registered and scope claims of a token.
+type claims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *json.Number    `json:"exp"`
+	NotBefore *json.Number    `json:"nbf"`
+	Scope     string          `json:"scope"`
+	Scp       json.RawMessage `json:"scp"`
+}
+
+// Verify checks the signature and claims of a compact JWS and returns its
+// principal. Every failure is reported as ErrInvalidCredentials wrapping
+// the reason.
+func (v *JWTVerifier) Verify(token string) (Principal, error) {
+	p, err := v.verify(token)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	return p, nil
+}
+
+func (v *JWTVerifier) verify(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, errors.New("malformed token")
+	}
+	headerJSON, err := decodeSegment(parts[0])
+	if err != nil {
+		return Principal{}, errors.New("malformed header")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return Principal{}, errors.New("malformed header")
+	}
+	sig, err := decodeSegment(parts[2])
+	if err != nil {
+		return Principal{}, errors.New("malformed signature")
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	if !v.verifySignature(header.Alg, header.Kid, signed, sig) {
+		return Principal{}, errors.New("signature verification failed")
+	}
+
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return Principal{}, errors.New("malformed payload")
+	}
+	var c claims
+	dec := json.NewDecoder(strings.NewReader(string(payload)))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return Principal{}, errors.New("malformed claims")
+	}
+	if err := v.validateClaims(c); err != nil {
+		return Principal{}, err
+	}
+	scopes, err := c.scopes()
+	if err != nil {
+		return Principal{}, err
+	}
+	return Principal{Subject: c.Subject, Method: MethodJWT, Scopes: scopes}, nil
+}
+
+// verifySignature tries the keys matching kid (every key when the token has
+// none) whose type fits alg.
+func (v *JWTVerifier) verifySignature(alg, kid string, signed, sig []byte) bool {
+	for _, k := range v.keys {
+		if kid != "" && k.kid != kid {
+			continue
+		}
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		if verifyWith(k.key, alg, signed, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+func verifyWith(key crypto.PublicKey, alg string, signed, sig []byte) bool {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	case "EdDSA":
+		k, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(k, signed, sig)
+	default:
+		return false
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") && rsa.VerifyPKCS1v15(k, hash, digest, sig) == nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(sig) != 2*size ||
+			(alg == "ES256") != (k.Curve == elliptic.P256()) {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	}
+	return false
+}
+
+func (v *JWTVerifier) validateClaims(c claims) error {
+	now := v.now()
+	if c.ExpiresAt == nil {
+		return errors.New("missing exp claim")
+	}
+	exp, err := numericDate(*c.ExpiresAt)
+	if err != nil {
+		return errors.New("invalid exp claim")
+	}
+	if !now.Before(exp.Add(v.opts.Leeway)) {
+		return errors.New("token expired")
+	}
+	if c.NotBefore != nil {
+		nbf, err := numericDate(*c.NotBefore)
+		if err != nil {
+			return errors.New("invalid nbf claim")
+		}
+		if now.Add(v.opts.Leeway).Before(nbf) {
+			return errors.New("token not yet valid")
+		}
+	}
+	if v.opts.Issuer != "" && c.Issuer != v.opts.Issuer {
+		return errors.New("unexpected issuer")
+	}
+	if v.opts.Audience != "" {
+		aud, err := stringOrList(c.Audience)
+		if err != nil || !slices.Contains(aud, v.opts.Audience) {
+			return errors.New("unexpected audience")
+		}
+	}
+	return nil
+}
+
+func (c claims) scopes() ([]string, error) {
+	if c.Scope != "" {
+		return strings.Fields(c.Scope), nil
+	}
+	scp, err := stringOrList(c.Scp)
+	if err != nil {
+		return nil, errors.New("invalid scp claim")
+	}
+	if len(scp) == 1 {
+		return strings.Fields(scp[0]), nil
+	}
+	return scp, nil
+}
+
+// numericDate converts a JWT NumericDate (seconds since the epoch, possibly
+// fractional) to a time.
+func numericDate(n json.Number) (time.Time, error) {
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(f*float64(time.Second))), nil
+}
+
+// stringOrList decodes a claim that is either a string or an array of
+// strings. An absent claim yields nil.
+func stringOrList(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []string{s}, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
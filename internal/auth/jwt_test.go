@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+	ed  ed25519.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKeys{rsa: rsaKey, ec: ecKey, ed: edKey}
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func (k testKeys) jwks() []byte {
+	ecPub := k.ec.PublicKey
+	x, y := make([]byte, 32), make([]byte, 32)
+	ecPub.X.FillBytes(x)
+	ecPub.Y.FillBytes(y)
+	doc := map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa1", "use": "sig", "alg": "RS256",
+			"n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes())},
+		{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(x), "y": b64(y)},
+		{"kty": "OKP", "kid": "ed1", "crv": "Ed25519", "x": b64(k.ed.Public().(ed25519.PublicKey))},
+		{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}}
+	data, _ := json.Marshal(doc)
+	return data
+}
+
+func (k testKeys) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	var err error
+	switch alg {
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case "EdDSA":
+		sig = ed25519.Sign(k.ed, []byte(signed))
+	case "none":
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + b64(sig)
+}
+
+func newVerifier(t *testing.T, k testKeys, opts JWTOptions) *JWTVerifier {
+	t.Helper()
+	v, err := NewJWTVerifier(k.jwks(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.now = func() time.Time { return now }
+	return v
+}
+
+func TestNewJWTVerifierSkipsUnusableKeys(t *testing.T) {
+	v := newVerifier(t, newTestKeys(t), JWTOptions{})
+	var kids []string
+	for _, k := range v.keys {
+		kids = append(kids, k.kid)
+	}
+	if !slices.Equal(kids, []string{"rsa1", "ec1", "ed1"}) {
+		t.Fatalf("expected rsa1, ec1, ed1, got %v", kids)
+	}
+
+	if _, err := NewJWTVerifier([]byte(`{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`), JWTOptions{}); err == nil {
+		t.Error("expected error for JWKS without usable keys")
+	}
+	if _, err := NewJWTVerifier([]byte(`{"keys":[{"kty":"EC","crv":"P-256","x":"AAAA","y":"AAAA"}]}`), JWTOptions{}); err == nil {
+		t.Error("expected error for invalid EC key")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	k := newTestKeys(t)
+	v := newVerifier(t, k, JWTOptions{Issuer: "https://idp.example.com", Audience: "route-beacon", Leeway: time.Minute})
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"sub":   "alice",
+			"iss":   "https://idp.example.com",
+			"aud":   []string{"route-beacon", "other"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": "lookup history",
+		}
+	}
+	with := func(key string, value any) map[string]any {
+		c := valid()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		scopes []string
+		errMsg string
+	}{
+		{"RS256", k.sign(t, "RS256", "rsa1", valid()), []string{"lookup", "history"}, ""},
+		{"ES256", k.sign(t, "ES256", "ec1", valid()), []string{"lookup", "history"}, ""},
+		{"EdDSA without kid", k.sign(t, "EdDSA", "", valid()), []string{"lookup", "history"}, ""},
+		{"scp array", k.sign(t, "RS256", "rsa1", with("scope", nil)), nil, ""},
+		{"string audience", k.sign(t, "RS256", "rsa1", with("aud", "route-beacon")), []string{"lookup", "history"}, ""},
+		{"expired within leeway", k.sign(t, "RS256", "rsa1", with("exp", now.Add(-30*time.Second).Unix())), []string{"lookup", "history"}, ""},
+		{"expired", k.sign(t, "RS256", "rsa1", with("exp", now.Add(-2*time.Minute).Unix())), nil, "token expired"},
+		{"missing exp", k.sign(t, "RS256", "rsa1", with("exp", nil)), nil, "missing exp"},
+		{"not yet valid", k.sign(t, "RS256", "rsa1", with("nbf", now.Add(time.Hour).Unix())), nil, "not yet valid"},
+		{"wrong issuer", k.sign(t, "RS256", "rsa1", with("iss", "https://evil.example.com")), nil, "issuer"},
+		{"wrong audience", k.sign(t, "RS256", "rsa1", with("aud", "other")), nil, "audience"},
+		{"unknown kid", k.sign(t, "RS256", "rsa9", valid()), nil, "signature"},
+		{"alg mismatch", k.sign(t, "ES256", "rsa1", valid()), nil, "signature"},
+		{"alg none", k.sign(t, "none", "", valid()), nil, "signature"},
+		{"malformed", "a.b.c", nil, "malformed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(tt.token)
+			if tt.errMsg != "" {
+				if !errors.Is(err, ErrInvalidCredentials) || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Subject != "alice" || p.Method != MethodJWT {
+				t.Errorf("unexpected principal %+v", p)
+			}
+			if tt.scopes != nil && !slices.Equal(p.Scopes, tt.scopes) {
+				t.Errorf("expected scopes %v, got %v", tt.scopes, p.Scopes)
+			}
+		})
+	}
+}
+
+func TestVerifyScpClaim(t *testing.T) {
+	k := newTestKeys(t)
+	v := newVerifier(t, k, JWTOptions{})
+	token := k.sign(t, "RS256", "rsa1", map[string]any{
+		"sub": "svc", "exp": now.Add(time.Hour).Unix(), "scp": []string{"export", "admin"},
+	})
+	p, err := v.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p.Scopes, []string{"export", "admin"}) {
+		t.Errorf("expected scp scopes, got %v", p.Scopes)
+	}
+}
+
+func TestVerifyTamperedPayload(t *testing.T) {
+	k := newTestKeys(t)
+	v := newVerifier(t, k, JWTOptions{})
+	token := k.sign(t, "RS256", "rsa1", map[string]any{"sub": "bob", "exp": now.Add(time.Hour).Unix(), "scope": "lookup"})
+	parts := strings.Split(token, ".")
+	forged, _ := json.Marshal(map[string]any{"sub": "bob", "exp": now.Add(time.Hour).Unix(), "scope": "admin"})
+	parts[1] = b64(forged)
+	if _, err := v.Verify(strings.Join(parts, ".")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected rejection of tampered token, got %v", err)
+	}
+}
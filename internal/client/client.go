@@ -0,0 +1,234 @@
+// Package client is a Go client for the route-beacon HTTP API.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/auth"
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// Client calls the API at BaseURL, e.g. "https://lg.example.net".
+type Client struct {
+	BaseURL string
+	// APIKey is sent in the X-API-Key header when set.
+	APIKey     string
+	HTTPClient *http.Client
+	UserAgent  string
+}
+
+// New returns a client for baseURL with the given request timeout.
+func New(baseURL, apiKey string, timeout time.Duration) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: timeout},
+		UserAgent:  "rbctl",
+	}
+}
+
+// Error is a problem response returned by the API.
+type Error struct {
+	model.ProblemDetail
+}
+
+func (e *Error) Error() string {
+	msg := e.Title
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	for _, p := range e.InvalidParams {
+		msg += "\n  " + p.Name + ": " + p.Reason
+	}
+	return msg
+}
+
+// ListRouters returns all routers.
+func (c *Client) ListRouters(ctx context.Context) ([]model.Router, error) {
+	var resp model.RouterListResponse
+	if err := c.get(ctx, "/api/v1/routers", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// GetRouter returns a router with its routing table statistics.
+func (c *Client) GetRouter(ctx context.Context, routerID string) (*model.RouterDetail, error) {
+	var resp struct {
+		Data *model.RouterDetail `json:"data"`
+	}
+	if err := c.get(ctx, "/api/v1/routers/"+url.PathEscape(routerID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// LookupQuery selects the routes of a lookup. An empty MatchType lets the
+// API choose from the form of Prefix.
+type LookupQuery struct {
+	Prefix    string
+	MatchType string
+	At        time.Time
+}
+
+// Lookup looks up routes on a router.
+func (c *Client) Lookup(ctx context.Context, routerID string, q LookupQuery) (*model.RouteLookupResponse, error) {
+	params := url.Values{"prefix": {q.Prefix}}
+	setString(params, "match_type", q.MatchType)
+	setTime(params, "at", q.At)
+	var resp model.RouteLookupResponse
+	if err := c.get(ctx, "/api/v1/routers/"+url.PathEscape(routerID)+"/routes/lookup", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// HistoryQuery selects route history across routers; no routers means all
+// of them. Zero values keep the API defaults.
+type HistoryQuery struct {
+	Prefix     string
+	Routers    []string
+	Scope      string
+	From, To   time.Time
+	Resolution string
+	View       string
+	Limit      int
+	Cursor     string
+}
+
+// History returns one page of route history.
+func (c *Client) History(ctx context.Context, q HistoryQuery) (*model.RouteHistoryResponse, error) {
+	params := url.Values{"prefix": {q.Prefix}}
+	setString(params, "routers", strings.Join(q.Routers, ","))
+	setString(params, "scope", q.Scope)
+	setTime(params, "from", q.From)
+	setTime(params, "to", q.To)
+	setString(params, "resolution", q.Resolution)
+	setString(params, "view", q.View)
+	setInt(params, "limit", q.Limit)
+	setString(params, "cursor", q.Cursor)
+	var resp model.RouteHistoryResponse
+	if err := c.get(ctx, "/api/v1/routes/history", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CompareQuery filters a comparison between two routers. Zero values keep
+// the API defaults.
+type CompareQuery struct {
+	Table    string
+	AFI      int
+	Ignore   []string
+	Statuses []string
+	Limit    int
+	Offset   int
+}
+
+// Compare compares the routing tables of two routers.
+func (c *Client) Compare(ctx context.Context, routerA, routerB string, q CompareQuery) (*model.RouterCompareResponse, error) {
+	params := url.Values{}
+	setString(params, "table", q.Table)
+	setInt(params, "afi", q.AFI)
+	setString(params, "ignore", strings.Join(q.Ignore, ","))
+	setString(params, "status", strings.Join(q.Statuses, ","))
+	setInt(params, "limit", q.Limit)
+	setInt(params, "offset", q.Offset)
+	var resp model.RouterCompareResponse
+	path := "/api/v1/routers/" + url.PathEscape(routerA) + "/routes/compare/" + url.PathEscape(routerB)
+	if err := c.get(ctx, path, params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// BGPlayQuery selects the prefix history to export.
+type BGPlayQuery struct {
+	Prefix   string
+	Routers  []string
+	Scope    string
+	From, To time.Time
+}
+
+// BGPlay returns the BGPlay export of a prefix.
+func (c *Client) BGPlay(ctx context.Context, q BGPlayQuery) (*model.BGPlayResponse, error) {
+	params := url.Values{"prefix": {q.Prefix}}
+	setString(params, "routers", strings.Join(q.Routers, ","))
+	setString(params, "scope", q.Scope)
+	setTime(params, "from", q.From)
+	setTime(params, "to", q.To)
+	var resp model.BGPlayResponse
+	if err := c.get(ctx, "/api/v1/routes/bgplay", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// get sends a GET request and decodes the JSON response into v. Problem
+// responses are returned as *Error.
+func (c *Client) get(ctx context.Context, path string, params url.Values, v any) error {
+	u := c.BaseURL + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set(auth.APIKeyHeader, c.APIKey)
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		apiErr := &Error{}
+		if json.Unmarshal(body, &apiErr.ProblemDetail) != nil || apiErr.Status == 0 {
+			apiErr.ProblemDetail = model.NewProblem(resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		return apiErr
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func setString(params url.Values, name, v string) {
+	if v != "" {
+		params.Set(name, v)
+	}
+}
+
+func setInt(params url.Values, name string, v int) {
+	if v != 0 {
+		params.Set(name, strconv.Itoa(v))
+	}
+}
+
+func setTime(params url.Values, name string, t time.Time) {
+	if !t.IsZero() {
+		params.Set(name, t.UTC().Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+func TestHistorySendsQuery(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		json.NewEncoder(w).Encode(model.RouteHistoryResponse{Prefix: "10.0.0.0/8"})
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", "secret", time.Second)
+	resp, err := c.History(context.Background(), HistoryQuery{
+		Prefix:  "10.0.0.0/8",
+		Routers: []string{"r1", "r2"},
+		From:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600)),
+		Limit:   50,
+	})
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if resp.Prefix != "10.0.0.0/8" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if got.URL.Path != "/api/v1/routes/history" {
+		t.Errorf("unexpected path %s", got.URL.Path)
+	}
+	want := "from=2025-12-31T23%3A00%3A00Z&limit=50&prefix=10.0.0.0%2F8&routers=r1%2Cr2"
+	if got.URL.RawQuery != want {
+		t.Errorf("expected query %s, got %s", want, got.URL.RawQuery)
+	}
+	if got.Header.Get("X-API-Key") != "secret" {
+		t.Errorf("API key not sent")
+	}
+}
+
+func TestProblemResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		model.WriteProblemWithParams(w, http.StatusUnprocessableEntity, "Request validation failed.",
+			[]model.InvalidParam{{Name: "prefix", Reason: "Not a valid IPv4 or IPv6 prefix."}})
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL, "", time.Second).Lookup(context.Background(), "r1", LookupQuery{Prefix: "bogus"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnprocessableEntity || len(apiErr.InvalidParams) != 1 {
+		t.Fatalf("expected a problem error, got %v", err)
+	}
+	want := "Unprocessable Entity: Request validation failed.\n  prefix: Not a valid IPv4 or IPv6 prefix."
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestNonProblemError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL, "", time.Second).ListRouters(context.Background())
+	if err == nil || err.Error() != "Bad Gateway: upstream unavailable" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,316 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	cfg := Default()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default configuration invalid: %v", err)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, opts, err := Load("api", nil, env(nil), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.PrintConfig {
+		t.Error("expected PrintConfig false")
+	}
+	if cfg.Server.ListenAddr != ":8080" {
+		t.Errorf("expected :8080, got %q", cfg.Server.ListenAddr)
+	}
+	if cfg.Limits != DefaultLimits() {
+		t.Errorf("expected default limits, got %+v", cfg.Limits)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, `
+server:
+  listen_addr: ":9000"
+log:
+  level: debug
+  format: text
+metrics:
+  interval: 1m
+cors:
+  allowed_origins: ["https://a.example.com"]
+`)
+	cfg, _, err := Load("api",
+		[]string{"--config", path, "--log-level", "error"},
+		env(map[string]string{"LOG_LEVEL": "warn", "LOG_FORMAT": "json", "FEATURE_BGPLAY": "false"}),
+		io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.ListenAddr != ":9000" {
+		t.Errorf("file: expected :9000, got %q", cfg.Server.ListenAddr)
+	}
+	if cfg.Metrics.Interval != Duration(time.Minute) {
+		t.Errorf("file: expected 1m interval, got %v", time.Duration(cfg.Metrics.Interval))
+	}
+	if got := cfg.CORS.AllowedOrigins; len(got) != 1 || got[0] != "https://a.example.com" {
+		t.Errorf("file: expected one origin, got %v", got)
+	}
+	if cfg.Log.Format != "json" {
+		t.Errorf("env over file: expected json, got %q", cfg.Log.Format)
+	}
+	if cfg.Features.BGPlay {
+		t.Error("env: expected bgplay disabled")
+	}
+	if cfg.Log.Level != "error" {
+		t.Errorf("flag over env: expected error, got %q", cfg.Log.Level)
+	}
+}
+
+func TestLoadConfigFileFromEnv(t *testing.T) {
+	path := writeFile(t, "limits:\n  max_page_size: 250\n")
+	cfg, _, err := Load("api", []string{"--limits.max_diff_routes=5"}, env(map[string]string{"CONFIG_FILE": path}), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Limits.MaxPageSize != 250 {
+		t.Errorf("expected page size 250, got %d", cfg.Limits.MaxPageSize)
+	}
+	if cfg.Limits.MaxDiffRoutes != 5 {
+		t.Errorf("expected diff routes 5, got %d", cfg.Limits.MaxDiffRoutes)
+	}
+}
+
+func TestLoadRejectsUnknownKey(t *testing.T) {
+	path := writeFile(t, "server:\n  listen_adr: \":9000\"\n")
+	_, _, err := Load("api", []string{"--config", path}, env(nil), io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "listen_adr") {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestLoadInvalidValues(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"bad duration env", nil, map[string]string{"METRICS_INTERVAL": "soon"}, "METRICS_INTERVAL"},
+		{"bad int flag", []string{"--database.max_conns", "many"}, nil, "database.max_conns"},
+		{"bad log level", []string{"--log-level", "verbose"}, nil, "log.level"},
+		{"bad exporter", nil, map[string]string{"OTEL_TRACES_EXPORTER": "jaeger"}, "tracing.exporter"},
+		{"zero page size", []string{"--limits.max_page_size=0"}, nil, "limits.max_page_size"},
+		{"min over max conns", []string{"--database.min_conns=20"}, nil, "database.min_conns"},
+		{"extra argument", []string{"serve"}, nil, "unexpected arguments"},
+		{"bad trusted proxy", nil, map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8,proxy.local"}, "server.trusted_proxies"},
+		{"zero rate", []string{"--rate_limit.lookup_per_minute=0"}, nil, "rate_limit.lookup_per_minute"},
+		{"zero cli burst", nil, map[string]string{"RATE_LIMIT_CLI_BURST": "0"}, "rate_limit.cli_burst"},
+		{"unknown anonymous scope", nil, map[string]string{"AUTH_ANONYMOUS_SCOPES": "lookup,everything"}, "auth.anonymous_scopes"},
+		{"stale rib cache", []string{"--rib_cache.max_staleness=1s"}, nil, "rib_cache.max_staleness"},
+		{"demo with rib cache", []string{"--demo", "demo.yaml", "--rib_cache.enabled=true"}, nil, "rib_cache.enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Load("api", tt.args, env(tt.env), io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	path := writeFile(t, `
+auth:
+  enabled: true
+  api_keys:
+    - name: noc
+      sha256: 2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b
+      scopes: [lookup, history]
+`)
+	cfg, _, err := Load("api", []string{"--config", path}, env(nil), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Auth.Enabled || len(cfg.Auth.APIKeys) != 1 || cfg.Auth.APIKeys[0].Name != "noc" {
+		t.Fatalf("unexpected auth config %+v", cfg.Auth)
+	}
+
+	bad := writeFile(t, `
+auth:
+  api_keys:
+    - name: noc
+      sha256: not-a-hash
+      scopes: [root]
+`)
+	_, _, err = Load("api", []string{"--config", bad}, env(nil), io.Discard)
+	for _, want := range []string{"auth.api_keys[0].sha256", `unknown scope "root"`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error mentioning %q, got %v", want, err)
+		}
+	}
+}
+
+func TestLoadProbeAgents(t *testing.T) {
+	path := writeFile(t, `
+probe:
+  enabled: true
+  agents:
+    - name: fra1
+      url: http://probe-fra1:9180
+      token: s3cret
+      routers: [r1, r2]
+`)
+	cfg, _, err := Load("api", []string{"--config", path}, env(nil), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Probe.Enabled || len(cfg.Probe.Agents) != 1 || len(cfg.Probe.Agents[0].Routers) != 2 {
+		t.Fatalf("unexpected probe config %+v", cfg.Probe)
+	}
+
+	bad := writeFile(t, `
+probe:
+  agents:
+    - name: fra1
+      url: probe-fra1:9180
+      routers: [r1]
+    - name: fra2
+      url: http://probe-fra2:9180
+      token: s3cret
+      routers: [r1]
+`)
+	_, _, err = Load("api", []string{"--config", bad}, env(nil), io.Discard)
+	for _, want := range []string{"probe.agents[0].url", "probe.agents[0].token", `router "r1" already has an agent`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error mentioning %q, got %v", want, err)
+		}
+	}
+}
+
+func TestLoadRouterCLI(t *testing.T) {
+	path := writeFile(t, `
+router_cli:
+  enabled: true
+  routers:
+    - router_id: r1
+      address: 192.0.2.1:22
+      platform: iosxr
+      username: looking-glass
+      password: s3cret
+      host_key: ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIE2b8uK3hAaQ0Xv5x+TzR0jV2b3Ye5vR6YQ0Y1m6Hm8f
+`)
+	cfg, _, err := Load("api", []string{"--config", path}, env(nil), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.RouterCLI.Enabled || len(cfg.RouterCLI.Routers) != 1 || cfg.RouterCLI.Routers[0].Platform != "iosxr" {
+		t.Fatalf("unexpected router_cli config %+v", cfg.RouterCLI)
+	}
+
+	bad := writeFile(t, `
+router_cli:
+  routers:
+    - router_id: r1
+      address: 192.0.2.1
+      platform: ios
+      username: looking-glass
+    - router_id: r1
+      address: 192.0.2.2:22
+      platform: junos
+      username: looking-glass
+      private_key_file: /etc/route-beacon/id_ed25519
+`)
+	_, _, err = Load("api", []string{"--config", bad}, env(nil), io.Discard)
+	for _, want := range []string{
+		"router_cli.routers[0].address", "router_cli.routers[0].platform",
+		"router_cli.routers[0] needs a password", "router_cli.routers[0].host_key",
+		`router_cli.routers[1].router_id "r1" is not unique`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error mentioning %q, got %v", want, err)
+		}
+	}
+}
+
+func TestTrustedProxyPrefixes(t *testing.T) {
+	cfg, _, err := Load("api", nil, env(map[string]string{"TRUSTED_PROXIES": "10.1.2.3/8, 192.0.2.1,2001:db8::/32"}), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range cfg.Server.TrustedProxyPrefixes() {
+		got = append(got, p.String())
+	}
+	want := []string{"10.0.0.0/8", "192.0.2.1/32", "2001:db8::/32"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	cfg := Default()
+	cfg.Server.ListenAddr = ""
+	cfg.Log.Format = "xml"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"server.listen_addr", "log.format"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.Database.URL = "postgresql://rib:secret@db:5432/rib"
+	cfg.Probe.Agents = []ProbeAgentConfig{{Name: "fra1", URL: "http://probe-fra1:9180", Token: "secret", Routers: []string{"r1"}}}
+	cfg.RouterCLI.Routers = []RouterCLITarget{{RouterID: "r1", Address: "192.0.2.1:22", Username: "lg", Password: "secret"}}
+	out, err := cfg.Redacted().YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "secret") {
+		t.Fatalf("password leaked:\n%s", out)
+	}
+	if !strings.Contains(string(out), "rib:xxxxx@db:5432") {
+		t.Errorf("expected masked URL in:\n%s", out)
+	}
+	if cfg.Database.URL != "postgresql://rib:secret@db:5432/rib" || cfg.Probe.Agents[0].Token != "secret" ||
+		cfg.RouterCLI.Routers[0].Password != "secret" {
+		t.Error("Redacted modified the original")
+	}
+}
+
+func TestPrintConfigFlag(t *testing.T) {
+	_, opts, err := Load("api", []string{"--print-config"}, env(nil), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.PrintConfig {
+		t.Error("expected PrintConfig")
+	}
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Options are the command-line settings that are not part of Config.
+type Options struct {
+	// PrintConfig requests printing the effective configuration and
+	// exiting.
+	PrintConfig bool
+}
+
+// flagAliases are short names for the most common flags.
+var flagAliases = map[string]string{
+	"listen":       "server.listen_addr",
+	"database-url": "database.url",
+	"log-level":    "log.level",
+	"log-format":   "log.format",
+	"demo":         "demo.fixtures",
+}
+
+// Load builds the configuration from defaults, the YAML file named by
+// --config (or CONFIG_FILE), environment variables and command-line flags,
+// in increasing order of precedence, and validates it. Every setting has a
+// flag named after its YAML path, such as --server.listen_addr. lookupEnv
+// is typically os.LookupEnv.
+func Load(name string, args []string, lookupEnv func(string) (string, bool), output io.Writer) (Config, Options, error) {
+	cfg := Default()
+	var opts Options
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	configFile, _ := lookupEnv("CONFIG_FILE")
+	fs.StringVar(&configFile, "config", configFile, "path to a YAML configuration file (env CONFIG_FILE)")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the effective configuration and exit")
+
+	// Flags are recorded during parsing and applied after the file and the
+	// environment so that they take precedence.
+	var pending []func() error
+	fields := leafFields(reflect.ValueOf(&cfg).Elem(), "")
+	byPath := map[string]leafField{}
+	for _, f := range fields {
+		byPath[f.path] = f
+		usage := "set " + f.path
+		if f.env != "" {
+			usage += " (env " + f.env + ")"
+		}
+		fs.Var(&fieldFlag{name: f.path, field: f.value, pending: &pending}, f.path, usage)
+	}
+	for alias, path := range flagAliases {
+		fs.Var(&fieldFlag{name: alias, field: byPath[path].value, pending: &pending}, alias, "alias for --"+path)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, opts, err
+	}
+	if fs.NArg() > 0 {
+		return cfg, opts, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if configFile != "" {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			return cfg, opts, fmt.Errorf("read config: %w", err)
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return cfg, opts, fmt.Errorf("parse %s: %w", configFile, err)
+		}
+	}
+
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if v, ok := lookupEnv(f.env); ok {
+			if err := setField(f.value, v); err != nil {
+				return cfg, opts, fmt.Errorf("%s: %w", f.env, err)
+			}
+		}
+	}
+
+	for _, apply := range pending {
+		if err := apply(); err != nil {
+			return cfg, opts, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, opts, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, opts, nil
+}
+
+// leafField is a settable configuration value with its YAML path and
+// environment variable.
+type leafField struct {
+	path  string
+	env   string
+	value reflect.Value
+}
+
+// leafFields lists the non-struct fields of v, depth first. Lists of
+// structs are skipped.
+func leafFields(v reflect.Value, prefix string) []leafField {
+	var fields []leafField
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		path := prefix + name
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			fields = append(fields, leafFields(fv, path+".")...)
+			continue
+		}
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct {
+			continue // lists of structs are set in the file only
+		}
+		fields = append(fields, leafField{path: path, env: sf.Tag.Get("env"), value: fv})
+	}
+	return fields
+}
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// setField parses s into a leaf field. Lists are comma-separated.
+func setField(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// fieldFlag is a flag.Value that defers setting a configuration field.
+type fieldFlag struct {
+	name    string
+	field   reflect.Value
+	pending *[]func() error
+}
+
+func (f *fieldFlag) String() string {
+	if !f.field.IsValid() {
+		return ""
+	}
+	if f.field.Type() == durationType {
+		return time.Duration(f.field.Int()).String()
+	}
+	return fmt.Sprint(f.field.Interface())
+}
+
+func (f *fieldFlag) Set(s string) error {
+	name, field := f.name, f.field
+	*f.pending = append(*f.pending, func() error {
+		if err := setField(field, s); err != nil {
+			return fmt.Errorf("--%s: %w", name, err)
+		}
+		return nil
+	})
+	return nil
+}
+
+func (f *fieldFlag) IsBoolFlag() bool {
+	return f.field.IsValid() && f.field.Kind() == reflect.Bool
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/pobradovic08/route-beacon/internal/auth"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/ratelimit"
+	"github.com/pobradovic08/route-beacon/internal/telemetry"
+)
+
+// Authenticate resolves the caller of every request with a and stores the
+// principal in the request context. Invalid credentials are rejected with
+// 401; requests without credentials continue as anonymous.
+//
+// Each failed authentication takes a token from the client IP's bucket in
+// failures, which must not be shared with other rate limit classes. While
+// that bucket is empty, requests carrying credentials get 429 before the
+// credentials are looked up. A nil limiter disables this.
+func Authenticate(a *auth.Authenticator, failures *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if failures != nil && auth.HasCredentials(r) {
+				if wait := failures.Wait(clientKey(r)); wait > 0 {
+					telemetry.RateLimitedRequests.WithLabelValues("auth").Inc()
+					seconds := max(1, int(math.Ceil(wait.Seconds())))
+					w.Header().Set("Retry-After", strconv.Itoa(seconds))
+					model.WriteProblem(w, http.StatusTooManyRequests,
+						fmt.Sprintf("Too many failed authentications. Retry in %d seconds.", seconds))
+					return
+				}
+			}
+			p, err := a.Authenticate(r)
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				if failures != nil {
+					failures.Allow(clientKey(r))
+				}
+				slog.DebugContext(r.Context(), "authentication failed",
+					slog.String("request_id", telemetry.RequestID(r.Context())),
+					slog.Any("error", err))
+				w.Header().Set("WWW-Authenticate", `Bearer realm="route-beacon", error="invalid_token"`)
+				model.WriteProblem(w, http.StatusUnauthorized, "Invalid API key or bearer token.")
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(r.Context(), "authentication error",
+					slog.String("request_id", telemetry.RequestID(r.Context())),
+					slog.Any("error", err))
+				model.WriteProblem(w, http.StatusInternalServerError, "Failed to verify credentials.")
+				return
+			}
+			if info := telemetry.Info(r.Context()); info != nil {
+				info.Subject = p.Subject
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+		})
+	}
+}
+
+// RequireScope wraps next so that it only serves callers granted scope.
+// Anonymous callers get 401 so that clients know to authenticate;
+// authenticated callers lacking the scope get 403.
+func RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := auth.PrincipalFrom(r.Context())
+		if ok && p.HasScope(scope) {
+			next(w, r)
+			return
+		}
+		if !ok || p.Anonymous() {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="route-beacon"`)
+			model.WriteProblem(w, http.StatusUnauthorized, "Authentication required.")
+			return
+		}
+		model.WriteProblem(w, http.StatusForbidden, "Missing required scope '"+scope+"'.")
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/auth"
+	"github.com/pobradovic08/route-beacon/internal/ratelimit"
+)
+
+func newAuthTestHandler() http.Handler {
+	a := auth.New(auth.Options{
+		APIKeys: []auth.APIKey{
+			{Name: "noc", Hash: auth.HashKey("noc-key"), Scopes: []string{auth.ScopeLookup, auth.ScopeHistory}},
+			{Name: "ops", Hash: auth.HashKey("ops-key"), Scopes: []string{auth.ScopeAdmin}},
+		},
+		AnonymousScopes: []string{auth.ScopeLookup},
+	})
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	mux.HandleFunc("GET /lookup", RequireScope(auth.ScopeLookup, ok))
+	mux.HandleFunc("GET /history", RequireScope(auth.ScopeHistory, ok))
+	mux.HandleFunc("GET /export", RequireScope(auth.ScopeExport, ok))
+	return Authenticate(a, nil)(mux)
+}
+
+func TestAuthenticateAndRequireScope(t *testing.T) {
+	h := newAuthTestHandler()
+	tests := []struct {
+		name   string
+		path   string
+		key    string
+		status int
+	}{
+		{"anonymous lookup", "/lookup", "", http.StatusOK},
+		{"anonymous history", "/history", "", http.StatusUnauthorized},
+		{"key with scope", "/history", "noc-key", http.StatusOK},
+		{"key without scope", "/export", "noc-key", http.StatusForbidden},
+		{"admin implies export", "/export", "ops-key", http.StatusOK},
+		{"invalid key", "/lookup", "wrong", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if w.Code == http.StatusOK {
+				return
+			}
+			var resp problemResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode problem: %v", err)
+			}
+			if resp.Status != tt.status {
+				t.Errorf("expected problem status %d, got %d", tt.status, resp.Status)
+			}
+			hasChallenge := w.Header().Get("WWW-Authenticate") != ""
+			if hasChallenge != (tt.status == http.StatusUnauthorized) {
+				t.Errorf("unexpected WWW-Authenticate %q for %d", w.Header().Get("WWW-Authenticate"), w.Code)
+			}
+		})
+	}
+}
+
+func TestAuthenticateChargesFailures(t *testing.T) {
+	lookups := 0
+	a := auth.New(auth.Options{
+		APIKeys: []auth.APIKey{{Name: "noc", Hash: auth.HashKey("noc-key"), Scopes: []string{auth.ScopeLookup}}},
+		Lookup: func(ctx context.Context, hash string) (auth.Principal, bool, error) {
+			lookups++
+			return auth.Principal{}, false, nil
+		},
+	})
+	h := Authenticate(a, ratelimit.New(1, 2))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	do := func(remote, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := range 2 {
+		if w := do("198.51.100.7:1000", fmt.Sprintf("guess-%d", i)); w.Code != http.StatusUnauthorized {
+			t.Fatalf("expected 401, got %d", w.Code)
+		}
+	}
+	for _, key := range []string{"guess-2", "noc-key"} {
+		w := do("198.51.100.7:1000", key)
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+			t.Fatalf("expected 429 with Retry-After for %s, got %d", key, w.Code)
+		}
+	}
+	if lookups != 2 {
+		t.Errorf("expected credentials to go unchecked once the budget is spent, got %d lookups", lookups)
+	}
+	if w := do("198.51.100.7:1000", ""); w.Code != http.StatusOK {
+		t.Errorf("expected requests without credentials to pass, got %d", w.Code)
+	}
+	if w := do("198.51.100.8:1000", "noc-key"); w.Code != http.StatusOK {
+		t.Errorf("expected other clients to be unaffected, got %d", w.Code)
+	}
+}
+
+func TestRequireScopeWithoutPrincipal(t *testing.T) {
+	h := RequireScope(auth.ScopeLookup, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not run")
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+// HandleGetBGPlay handles GET /api/v1/routes/bgplay, which returns the path
+// evolution of a prefix across routers in the BGPlay data format.
+func HandleGetBGPlay(db *store.DB, limits config.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := store.HistoryQuery{Prefix: r.URL.Query().Get("prefix")}
+		if q.Prefix == "" {
+			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "prefix", Reason: "prefix query parameter is required."}})
+			return
+		}
+
+		// Validate prefix is CIDR
+		if _, _, err := net.ParseCIDR(q.Prefix); err != nil {
+			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "prefix", Reason: "Not a valid IPv4 or IPv6 prefix."}})
+			return
+		}
+
+		var ok bool
+		if q.Subnets, ok = parseScope(w, r); !ok {
+			return
+		}
+		if q.From, q.To, ok = parseTimeRange(w, r, time.Duration(limits.MaxAnalyticsRange)); !ok {
+			return
+		}
+		q.Since = q.From.Add(-time.Duration(limits.MaxHistoryRange))
+		if q.RouterIDs, ok = parseRouterList(w, r, db, limits.MaxHistoryRouters); !ok {
+			return
+		}
+
+		resp, err := db.GetBGPlay(r.Context(), q)
+		if errors.Is(err, store.ErrTooManyEvents) {
+			model.WriteProblemWithParams(w, http.StatusBadRequest,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "from", Reason: "Too many events in range; narrow the time range."}})
+			return
+		}
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query route history.")
+			return
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+)
+
+func TestBGPlayRejectsInvalidPrefix(t *testing.T) {
+	handler := HandleGetBGPlay(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET", "/api/v1/routes/bgplay?prefix=not-a-prefix", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+}
+
+func TestBGPlayRejectsInvalidScope(t *testing.T) {
+	handler := HandleGetBGPlay(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET", "/api/v1/routes/bgplay?prefix=10.0.0.0/8&scope=supernets", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) == 0 || prob.InvalidParams[0].Name != "scope" {
+		t.Fatalf("expected invalid param 'scope', got %+v", prob.InvalidParams)
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/auth"
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+const (
+	// liveMaxAge lets clients and the nginx front reuse responses on live
+	// data briefly; after that they revalidate with the ETag.
+	liveMaxAge = 5 * time.Second
+	// historicalMaxAge applies to responses about a settled past, such as
+	// point-in-time lookups and history windows ending before settleDelay.
+	historicalMaxAge = 5 * time.Minute
+	// settleDelay is how long after an instant late-ingested events may
+	// still change what the API returns for it.
+	settleDelay = 5 * time.Minute
+)
+
+// maxAgeFor returns the cache lifetime of a response describing the state at
+// or up to t.
+func maxAgeFor(t time.Time) time.Duration {
+	if t.Before(time.Now().Add(-settleDelay)) {
+		return historicalMaxAge
+	}
+	return liveMaxAge
+}
+
+// latest returns the latest of the given timestamps, ignoring nil and
+// unparsable ones.
+func latest(timestamps ...*string) time.Time {
+	var t time.Time
+	for _, s := range timestamps {
+		if s == nil {
+			continue
+		}
+		if v, err := time.Parse(time.RFC3339Nano, *s); err == nil && v.After(t) {
+			t = v
+		}
+	}
+	return t
+}
+
+// writeCached writes resp as JSON with an ETag computed from the encoded
+// body, so it changes whenever any route, timestamp or status in the
+// response does. lastModified, when non-zero, is sent as Last-Modified.
+// Requests whose If-None-Match matches the ETag, or that carry no
+// If-None-Match and an If-Modified-Since not before lastModified, get 304
+// Not Modified without a body.
+func writeCached(w http.ResponseWriter, r *http.Request, resp any, maxAge time.Duration, lastModified time.Time) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(resp); err != nil {
+		model.WriteProblem(w, http.StatusInternalServerError, "Failed to encode response.")
+		return
+	}
+	writeCachedBody(w, r, body.Bytes(), maxAge, lastModified)
+}
+
+// writeCachedBody is writeCached for an encoded body, whose Content-Type
+// the caller sets.
+func writeCachedBody(w http.ResponseWriter, r *http.Request, body []byte, maxAge time.Duration, lastModified time.Time) {
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	h := w.Header()
+	h.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	// Responses to authenticated callers must not be stored by shared
+	// caches; anonymous responses are identical for every client.
+	visibility := "public"
+	if p, ok := auth.PrincipalFrom(r.Context()); ok && !p.Anonymous() {
+		visibility = "private"
+	}
+	h.Set("Cache-Control", visibility+", max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	h.Add("Vary", "Authorization, "+auth.APIKeyHeader)
+
+	if notModified(r, etag, lastModified) {
+		h.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Write(body)
+}
+
+// notModified evaluates the conditional headers of r as in RFC 9110
+// section 13.2.2: If-None-Match takes precedence over If-Modified-Since.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
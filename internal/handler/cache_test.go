@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/auth"
+)
+
+func TestWriteCached(t *testing.T) {
+	lastModified := time.Date(2026, 3, 1, 12, 0, 0, 500_000_000, time.UTC)
+	resp := map[string]string{"prefix": "10.0.0.0/8"}
+
+	w := httptest.NewRecorder()
+	writeCached(w, httptest.NewRequest("GET", "/", nil), resp, liveMaxAge, lastModified)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	etag := w.Header().Get("ETag")
+	if len(etag) != 36 || etag[:3] != `W/"` {
+		t.Fatalf("expected weak ETag, got %q", etag)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=5" {
+		t.Errorf("unexpected Cache-Control %q", got)
+	}
+	if got := w.Header().Get("Last-Modified"); got != "Sun, 01 Mar 2026 12:00:00 GMT" {
+		t.Errorf("unexpected Last-Modified %q", got)
+	}
+	if w.Body.String() != `{"prefix":"10.0.0.0/8"}`+"\n" {
+		t.Errorf("unexpected body %q", w.Body.String())
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+	}{
+		{"matching etag", "If-None-Match", etag, http.StatusNotModified},
+		{"strong form of etag", "If-None-Match", etag[2:], http.StatusNotModified},
+		{"etag in list", "If-None-Match", `"other", ` + etag, http.StatusNotModified},
+		{"wildcard", "If-None-Match", "*", http.StatusNotModified},
+		{"stale etag", "If-None-Match", `W/"deadbeef"`, http.StatusOK},
+		{"modified since earlier", "If-Modified-Since", "Sun, 01 Mar 2026 11:59:59 GMT", http.StatusOK},
+		{"not modified since", "If-Modified-Since", "Sun, 01 Mar 2026 12:00:00 GMT", http.StatusNotModified},
+		{"invalid date", "If-Modified-Since", "yesterday", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+			writeCached(w, req, resp, liveMaxAge, lastModified)
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, w.Code)
+			}
+			if w.Code == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("expected empty 304 body, got %q", w.Body.String())
+			}
+			if w.Header().Get("ETag") != etag {
+				t.Errorf("expected ETag on every response")
+			}
+		})
+	}
+}
+
+func TestWriteCachedIfNoneMatchTakesPrecedence(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", `W/"stale"`)
+	req.Header.Set("If-Modified-Since", "Sun, 01 Mar 2026 12:00:00 GMT")
+	w := httptest.NewRecorder()
+	writeCached(w, req, []int{1}, liveMaxAge, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestWriteCachedWithoutLastModified(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-Modified-Since", "Sun, 01 Mar 2026 12:00:00 GMT")
+	w := httptest.NewRecorder()
+	writeCached(w, req, []int{1}, liveMaxAge, time.Time{})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 without Last-Modified, got %d", w.Code)
+	}
+	if w.Header().Get("Last-Modified") != "" {
+		t.Error("unexpected Last-Modified")
+	}
+}
+
+func TestWriteCachedPrivateForAuthenticatedCallers(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "noc", Method: auth.MethodAPIKey}))
+	w := httptest.NewRecorder()
+	writeCached(w, req, []int{1}, historicalMaxAge, time.Time{})
+	if got := w.Header().Get("Cache-Control"); got != "private, max-age=300" {
+		t.Errorf("unexpected Cache-Control %q", got)
+	}
+}
+
+func TestMaxAgeFor(t *testing.T) {
+	if got := maxAgeFor(time.Now()); got != liveMaxAge {
+		t.Errorf("expected live max-age for now, got %v", got)
+	}
+	if got := maxAgeFor(time.Now().Add(-time.Hour)); got != historicalMaxAge {
+		t.Errorf("expected historical max-age for an hour ago, got %v", got)
+	}
+}
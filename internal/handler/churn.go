@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+// HandleGetChurn handles GET /api/v1/routers/{routerId}/routes/churn.
+func HandleGetChurn(db *store.DB, limits config.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routerID := r.PathValue("routerId")
+
+		from, to, ok := parseTimeRange(w, r, time.Duration(limits.MaxAnalyticsRange))
+		if !ok {
+			return
+		}
+
+		interval := r.URL.Query().Get("interval")
+		if interval == "" {
+			interval = "hour"
+		}
+		if interval != "minute" && interval != "hour" {
+			model.WriteProblemWithParams(w, http.StatusBadRequest,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "interval", Reason: "Must be 'minute' or 'hour'."}})
+			return
+		}
+		if interval == "minute" && to.Sub(from) > 24*time.Hour {
+			model.WriteProblemWithParams(w, http.StatusBadRequest,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "interval", Reason: "Per-minute buckets require a time range of at most 24 hours."}})
+			return
+		}
+
+		q := store.ChurnQuery{
+			RouterID: routerID,
+			From:     from,
+			To:       to,
+			Interval: interval,
+		}
+
+		groupBy := []string{}
+		if v := r.URL.Query().Get("group_by"); v != "" {
+			for _, g := range strings.Split(v, ",") {
+				switch strings.TrimSpace(g) {
+				case "afi":
+					q.GroupAFI = true
+				case "origin_asn":
+					q.GroupOriginASN = true
+				default:
+					model.WriteProblemWithParams(w, http.StatusBadRequest,
+						"Request validation failed.",
+						[]model.InvalidParam{{Name: "group_by", Reason: "Must be a comma-separated list of 'afi' and 'origin_asn'."}})
+					return
+				}
+			}
+			if q.GroupAFI {
+				groupBy = append(groupBy, "afi")
+			}
+			if q.GroupOriginASN {
+				groupBy = append(groupBy, "origin_asn")
+			}
+		}
+
+		if v := r.URL.Query().Get("afi"); v != "" {
+			if v != "4" && v != "6" {
+				model.WriteProblemWithParams(w, http.StatusBadRequest,
+					"Request validation failed.",
+					[]model.InvalidParam{{Name: "afi", Reason: "Must be 4 or 6."}})
+				return
+			}
+			afi, _ := strconv.Atoi(v)
+			q.AFI = &afi
+		}
+
+		if v := r.URL.Query().Get("origin_asn"); v != "" {
+			asn, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				model.WriteProblemWithParams(w, http.StatusBadRequest,
+					"Request validation failed.",
+					[]model.InvalidParam{{Name: "origin_asn", Reason: "Must be a valid AS number."}})
+				return
+			}
+			n := int(asn)
+			q.OriginASN = &n
+		}
+
+		// Check router exists
+		routerSummary, _, err := db.GetRouterSummary(r.Context(), routerID)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+			return
+		}
+		if routerSummary == nil {
+			model.WriteProblem(w, http.StatusNotFound, "Router '"+routerID+"' does not exist.")
+			return
+		}
+
+		series, err := db.GetChurn(r.Context(), q)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query update churn.")
+			return
+		}
+
+		resp := model.ChurnResponse{
+			RouterID: routerID,
+			From:     model.FormatTime(from),
+			To:       model.FormatTime(to),
+			Interval: interval,
+			GroupBy:  groupBy,
+			Series:   series,
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}
+}
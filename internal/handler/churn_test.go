@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+)
+
+func TestChurnRejectsInvalidInterval(t *testing.T) {
+	handler := HandleGetChurn(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/churn?interval=week",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestChurnRejectsLongMinuteRange(t *testing.T) {
+	handler := HandleGetChurn(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/churn?interval=minute&from=2025-01-01T00:00:00Z&to=2025-01-03T00:00:00Z",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) == 0 || prob.InvalidParams[0].Name != "interval" {
+		t.Fatalf("expected invalid param 'interval', got %+v", prob.InvalidParams)
+	}
+}
+
+func TestChurnRejectsUnknownGroupBy(t *testing.T) {
+	handler := HandleGetChurn(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/churn?group_by=afi,peer",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestChurnRejectsInvalidAFI(t *testing.T) {
+	handler := HandleGetChurn(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/churn?afi=5",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/auth"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/routercmd"
+	"github.com/pobradovic08/route-beacon/internal/telemetry"
+)
+
+// HandleRouterCommand handles GET /api/v1/routers/{routerId}/cli, which runs
+// an allow-listed show command on the router over SSH and returns its
+// output. Every command reaching the proxy is logged to audit, including
+// cache hits and failures.
+func HandleRouterCommand(st Store, proxy *routercmd.Proxy, audit *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routerID := r.PathValue("routerId")
+		name := r.URL.Query().Get("command")
+		target := r.URL.Query().Get("target")
+
+		c, ok := routercmd.Lookup(name)
+		if !ok {
+			names := make([]string, len(routercmd.Commands))
+			for i, c := range routercmd.Commands {
+				names[i] = c.Name
+			}
+			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "command", Reason: "Must be one of: " + strings.Join(names, ", ") + "."}})
+			return
+		}
+		if _, err := c.CanonicalArg(target); err != nil {
+			reason := "Must be an IPv4 or IPv6 address."
+			if c.Prefixes {
+				reason = "Must be an IPv4 or IPv6 address or prefix."
+			}
+			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "target", Reason: reason}})
+			return
+		}
+
+		// Check router exists
+		routerSummary, _, err := st.GetRouterSummary(r.Context(), routerID)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+			return
+		}
+		if routerSummary == nil {
+			model.WriteProblem(w, http.StatusNotFound, "Router '"+routerID+"' does not exist.")
+			return
+		}
+
+		start := time.Now()
+		res, err := proxy.Run(r.Context(), routerID, name, target)
+		if !errors.Is(err, routercmd.ErrNoRouter) {
+			auditCommand(r, audit, routerID, name, target, res, err, time.Since(start))
+		}
+		switch {
+		case errors.Is(err, routercmd.ErrNoRouter):
+			model.WriteProblem(w, http.StatusNotFound, "Router commands are not available for router '"+routerID+"'.")
+			return
+		case errors.Is(err, routercmd.ErrBusy):
+			w.Header().Set("Retry-After", "5")
+			model.WriteProblem(w, http.StatusServiceUnavailable, "Router '"+routerID+"' is running other commands. Retry in 5 seconds.")
+			return
+		case r.Context().Err() != nil:
+			return
+		case errors.Is(err, context.DeadlineExceeded):
+			model.WriteProblem(w, http.StatusGatewayTimeout, "The command timed out on router '"+routerID+"'.")
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "router command failed",
+				slog.String("request_id", telemetry.RequestID(r.Context())),
+				slog.String("router_id", routerID),
+				slog.Any("error", err))
+			model.WriteProblem(w, http.StatusBadGateway, "Failed to run the command on router '"+routerID+"'.")
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(model.RouterCommandResponse{
+			RouterID:   routerID,
+			Command:    res.Line,
+			Output:     res.Text,
+			Truncated:  res.Truncated,
+			ExecutedAt: res.ExecutedAt.UTC(),
+			Cached:     res.Cached,
+		})
+	}
+}
+
+// auditCommand writes the audit record of a command run through the proxy.
+func auditCommand(r *http.Request, audit *slog.Logger, routerID, name, target string, res *routercmd.Result, err error, d time.Duration) {
+	attrs := []slog.Attr{
+		slog.String("request_id", telemetry.RequestID(r.Context())),
+		slog.String("client_ip", clientIP(r)),
+		slog.String("router_id", routerID),
+		slog.String("command", name),
+		slog.String("target", target),
+		slog.Duration("duration", d),
+	}
+	if p, ok := auth.PrincipalFrom(r.Context()); ok && p.Subject != "" {
+		attrs = append(attrs, slog.String("subject", p.Subject))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		audit.LogAttrs(r.Context(), slog.LevelWarn, "router command", attrs...)
+		return
+	}
+	attrs = append(attrs,
+		slog.String("line", res.Line),
+		slog.Bool("cached", res.Cached),
+		slog.Int("bytes", len(res.Text)),
+		slog.Bool("truncated", res.Truncated),
+	)
+	audit.LogAttrs(r.Context(), slog.LevelInfo, "router command", attrs...)
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/routercmd"
+)
+
+// stubRunner answers every command line with its own text, or fails with
+// err.
+type stubRunner struct {
+	err   error
+	lines []string
+}
+
+func (s *stubRunner) Run(ctx context.Context, line string) (*routercmd.Output, error) {
+	s.lines = append(s.lines, line)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &routercmd.Output{Text: "output of " + line}, nil
+}
+
+func cliRequest(routerID, query string) *http.Request {
+	req := httptest.NewRequest("GET", "/api/v1/routers/"+routerID+"/cli?"+query, nil)
+	req.SetPathValue("routerId", routerID)
+	return req
+}
+
+func newTestProxy(runner routercmd.Runner) *routercmd.Proxy {
+	p := routercmd.New(routercmd.Options{Timeout: time.Second, CacheTTL: time.Minute, MaxConcurrent: 1})
+	p.Register("r1", "iosxr", runner)
+	return p
+}
+
+func TestRouterCommand(t *testing.T) {
+	runner := &stubRunner{}
+	var audit bytes.Buffer
+	handler := HandleRouterCommand(newTestStore(t), newTestProxy(runner), slog.New(slog.NewJSONHandler(&audit, nil)))
+
+	for i, cached := range []bool{false, true} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, cliRequest("r1", "command=bgp&target=10.1.2.3/16"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d: %s", i, w.Code, w.Body)
+		}
+		var resp model.RouterCommandResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Command != "show bgp ipv4 unicast 10.1.0.0/16" || resp.Output != "output of "+resp.Command || resp.Cached != cached {
+			t.Errorf("request %d: unexpected response %+v", i, resp)
+		}
+	}
+	if len(runner.lines) != 1 {
+		t.Errorf("expected the second request to be served from the cache, ran %q", runner.lines)
+	}
+	if n := strings.Count(audit.String(), `"msg":"router command"`); n != 2 {
+		t.Errorf("expected 2 audit records, got %d:\n%s", n, audit.String())
+	}
+	if !strings.Contains(audit.String(), `"line":"show bgp ipv4 unicast 10.1.0.0/16"`) {
+		t.Errorf("expected the command line in the audit log:\n%s", audit.String())
+	}
+}
+
+func TestRouterCommandErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		routerID string
+		query    string
+		err      error
+		status   int
+		param    string
+	}{
+		{"unknown command", "r1", "command=configure&target=10.0.0.1", nil, http.StatusUnprocessableEntity, "command"},
+		{"prefix for route", "r1", "command=route&target=10.0.0.0/8", nil, http.StatusUnprocessableEntity, "target"},
+		{"injection", "r1", "command=bgp&target=10.0.0.1%3Breload", nil, http.StatusUnprocessableEntity, "target"},
+		{"unknown router", "r9", "command=route&target=10.0.0.1", nil, http.StatusNotFound, ""},
+		{"timeout", "r1", "command=route&target=10.0.0.1", context.DeadlineExceeded, http.StatusGatewayTimeout, ""},
+		{"ssh failure", "r1", "command=route&target=10.0.0.1", errors.New("ssh: handshake failed"), http.StatusBadGateway, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &stubRunner{err: tt.err}
+			handler := HandleRouterCommand(newTestStore(t), newTestProxy(runner), slog.New(slog.DiscardHandler))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, cliRequest(tt.routerID, tt.query))
+
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			var prob problemResponse
+			if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+				t.Fatal(err)
+			}
+			if tt.param != "" && (len(prob.InvalidParams) != 1 || prob.InvalidParams[0].Name != tt.param) {
+				t.Errorf("expected invalid param %q, got %+v", tt.param, prob.InvalidParams)
+			}
+			if tt.status == http.StatusBadGateway && strings.Contains(prob.Detail, "handshake") {
+				t.Errorf("SSH error leaked: %q", prob.Detail)
+			}
+		})
+	}
+}
+
+func TestRouterCommandNoCLI(t *testing.T) {
+	p := routercmd.New(routercmd.Options{Timeout: time.Second, MaxConcurrent: 1})
+	handler := HandleRouterCommand(newTestStore(t), p, slog.New(slog.DiscardHandler))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, cliRequest("r1", "command=route&target=10.0.0.1"))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a router without SSH access, got %d", w.Code)
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+// compareStatuses are the statuses accepted by the compare status filter.
+var compareStatuses = []string{"only_a", "only_b", "different"}
+
+// HandleCompareRouters handles GET /api/v1/routers/{routerId}/routes/compare/{otherRouterId}.
+func HandleCompareRouters(db *store.DB, limits config.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := store.CompareQuery{
+			RouterA:  r.PathValue("routerId"),
+			RouterB:  r.PathValue("otherRouterId"),
+			Ignore:   []string{},
+			Statuses: compareStatuses,
+		}
+
+		if q.RouterA == q.RouterB {
+			model.WriteProblemWithParams(w, http.StatusBadRequest,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "otherRouterId", Reason: "Must differ from routerId."}})
+			return
+		}
+
+		if v := r.URL.Query().Get("table"); v != "" {
+			q.Table = &v
+		}
+
+		if v := r.URL.Query().Get("afi"); v != "" {
+			if v != "4" && v != "6" {
+				model.WriteProblemWithParams(w, http.StatusBadRequest,
+					"Request validation failed.",
+					[]model.InvalidParam{{Name: "afi", Reason: "Must be 4 or 6."}})
+				return
+			}
+			afi, _ := strconv.Atoi(v)
+			q.AFI = &afi
+		}
+
+		if v := r.URL.Query().Get("ignore"); v != "" {
+			for _, a := range strings.Split(v, ",") {
+				a = strings.TrimSpace(a)
+				if !slices.Contains(store.CompareAttributes, a) {
+					model.WriteProblemWithParams(w, http.StatusBadRequest,
+						"Request validation failed.",
+						[]model.InvalidParam{{Name: "ignore", Reason: "Unknown attribute '" + a + "'."}})
+					return
+				}
+				q.Ignore = append(q.Ignore, a)
+			}
+		}
+
+		if v := r.URL.Query().Get("status"); v != "" {
+			q.Statuses = nil
+			for _, s := range strings.Split(v, ",") {
+				s = strings.TrimSpace(s)
+				if !slices.Contains(compareStatuses, s) {
+					model.WriteProblemWithParams(w, http.StatusBadRequest,
+						"Request validation failed.",
+						[]model.InvalidParam{{Name: "status", Reason: "Must be a comma-separated list of 'only_a', 'only_b' and 'different'."}})
+					return
+				}
+				q.Statuses = append(q.Statuses, s)
+			}
+		}
+
+		var ok bool
+		if q.Limit, ok = parseIntParam(w, r, "limit", min(100, limits.MaxPageSize), 1, limits.MaxPageSize); !ok {
+			return
+		}
+		if q.Offset, ok = parseIntParam(w, r, "offset", 0, 0, math.MaxInt32); !ok {
+			return
+		}
+
+		// Check both routers exist
+		routerA, _, err := db.GetRouterSummary(r.Context(), q.RouterA)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+			return
+		}
+		if routerA == nil {
+			model.WriteProblem(w, http.StatusNotFound, "Router '"+q.RouterA+"' does not exist.")
+			return
+		}
+		routerB, _, err := db.GetRouterSummary(r.Context(), q.RouterB)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+			return
+		}
+		if routerB == nil {
+			model.WriteProblem(w, http.StatusNotFound, "Router '"+q.RouterB+"' does not exist.")
+			return
+		}
+
+		summary, err := db.GetCompareSummary(r.Context(), q)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to compare routers.")
+			return
+		}
+		entries, err := db.CompareRouters(r.Context(), q)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to compare routers.")
+			return
+		}
+
+		hasMore := len(entries) > q.Limit
+		if hasMore {
+			entries = entries[:q.Limit]
+		}
+
+		resp := model.RouterCompareResponse{
+			RouterA: *routerA,
+			RouterB: *routerB,
+			Ignore:  q.Ignore,
+			Summary: *summary,
+			Entries: entries,
+			Limit:   q.Limit,
+			Offset:  q.Offset,
+			HasMore: hasMore,
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+)
+
+func compareRequest(query string) *http.Request {
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/compare/r2"+query,
+		nil)
+	req.SetPathValue("routerId", "r1")
+	req.SetPathValue("otherRouterId", "r2")
+	return req
+}
+
+func TestCompareRejectsSameRouter(t *testing.T) {
+	handler := HandleCompareRouters(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/compare/r1",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	req.SetPathValue("otherRouterId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestCompareRejectsUnknownIgnoreAttribute(t *testing.T) {
+	handler := HandleCompareRouters(nil, config.DefaultLimits())
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, compareRequest("?ignore=next_hop,weight"))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) == 0 || prob.InvalidParams[0].Name != "ignore" {
+		t.Fatalf("expected invalid param 'ignore', got %+v", prob.InvalidParams)
+	}
+}
+
+func TestCompareRejectsUnknownStatus(t *testing.T) {
+	handler := HandleCompareRouters(nil, config.DefaultLimits())
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, compareRequest("?status=identical"))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestCompareRejectsInvalidLimit(t *testing.T) {
+	handler := HandleCompareRouters(nil, config.DefaultLimits())
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, compareRequest("?limit=0"))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+// HandleGetRIBDiff handles GET /api/v1/routers/{routerId}/routes/diff.
+func HandleGetRIBDiff(db *store.DB, limits config.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routerID := r.PathValue("routerId")
+
+		from, to, ok := parseTimeRange(w, r, time.Duration(limits.MaxAnalyticsRange))
+		if !ok {
+			return
+		}
+
+		var table *string
+		if v := r.URL.Query().Get("table"); v != "" {
+			table = &v
+		}
+
+		limit, ok := parseIntParam(w, r, "limit", min(1000, limits.MaxDiffRoutes), 1, limits.MaxDiffRoutes)
+		if !ok {
+			return
+		}
+
+		// Check router exists
+		routerSummary, _, err := db.GetRouterSummary(r.Context(), routerID)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+			return
+		}
+		if routerSummary == nil {
+			model.WriteProblem(w, http.StatusNotFound, "Router '"+routerID+"' does not exist.")
+			return
+		}
+
+		since := from.Add(-time.Duration(limits.MaxHistoryRange))
+		diff, err := db.GetRIBDiffOverTime(r.Context(), routerID, table, from, to, since)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to compute RIB diff.")
+			return
+		}
+
+		resp := model.RIBDiffResponse{
+			RouterID: routerID,
+			From:     model.FormatTime(from),
+			To:       model.FormatTime(to),
+			Summary: model.RIBDiffSummary{
+				Added:   len(diff.Added),
+				Removed: len(diff.Removed),
+				Changed: len(diff.Changed),
+			},
+			Added:   diff.Added,
+			Removed: diff.Removed,
+			Changed: diff.Changed,
+		}
+		if len(resp.Added) > limit {
+			resp.Added = resp.Added[:limit]
+			resp.Truncated = true
+		}
+		if len(resp.Removed) > limit {
+			resp.Removed = resp.Removed[:limit]
+			resp.Truncated = true
+		}
+		if len(resp.Changed) > limit {
+			resp.Changed = resp.Changed[:limit]
+			resp.Truncated = true
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}
+}
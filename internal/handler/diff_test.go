@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+)
+
+func TestRIBDiffRejectsReversedTimeRange(t *testing.T) {
+	handler := HandleGetRIBDiff(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/diff?from=2025-01-02T00:00:00Z&to=2025-01-01T00:00:00Z",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestRIBDiffRejectsInvalidLimit(t *testing.T) {
+	handler := HandleGetRIBDiff(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/diff?limit=100000",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+// HandleGetFlapStats handles GET /api/v1/routers/{routerId}/routes/flaps.
+func HandleGetFlapStats(db *store.DB, limits config.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routerID := r.PathValue("routerId")
+
+		var prefix *string
+		if v := r.URL.Query().Get("prefix"); v != "" {
+			if _, _, err := net.ParseCIDR(v); err != nil {
+				model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+					"Request validation failed.",
+					[]model.InvalidParam{{Name: "prefix", Reason: "Not a valid IPv4 or IPv6 prefix."}})
+				return
+			}
+			prefix = &v
+		}
+
+		from, to, ok := parseTimeRange(w, r, time.Duration(limits.MaxAnalyticsRange))
+		if !ok {
+			return
+		}
+
+		// Dampening parameters default to the common vendor values.
+		var params model.DampeningParams
+		if params.HalfLifeMinutes, ok = parseIntParam(w, r, "half_life", 15, 1, 45); !ok {
+			return
+		}
+		if params.ReuseThreshold, ok = parseIntParam(w, r, "reuse", 750, 1, 20000); !ok {
+			return
+		}
+		if params.SuppressThreshold, ok = parseIntParam(w, r, "suppress", 2000, 1, 20000); !ok {
+			return
+		}
+		if params.MaxSuppressMinutes, ok = parseIntParam(w, r, "max_suppress", 60, 1, 255); !ok {
+			return
+		}
+		if params.ReuseThreshold >= params.SuppressThreshold {
+			model.WriteProblemWithParams(w, http.StatusBadRequest,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "reuse", Reason: "Must be lower than 'suppress'."}})
+			return
+		}
+		if params.MaxSuppressMinutes < params.HalfLifeMinutes {
+			model.WriteProblemWithParams(w, http.StatusBadRequest,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "max_suppress", Reason: "Must not be lower than 'half_life'."}})
+			return
+		}
+
+		limit, ok := parseIntParam(w, r, "limit", min(100, limits.MaxPageSize), 1, limits.MaxPageSize)
+		if !ok {
+			return
+		}
+		suppressedOnly := r.URL.Query().Get("suppressed_only") == "true"
+
+		// Check router exists
+		routerSummary, _, err := db.GetRouterSummary(r.Context(), routerID)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+			return
+		}
+		if routerSummary == nil {
+			model.WriteProblem(w, http.StatusNotFound, "Router '"+routerID+"' does not exist.")
+			return
+		}
+
+		stats, err := db.GetFlapStats(r.Context(), routerID, prefix, from, to, params, limit)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query flap statistics.")
+			return
+		}
+
+		prefixes := make([]model.PrefixFlapStats, 0, len(stats.Prefixes))
+		for _, s := range stats.Prefixes {
+			if suppressedOnly && s.SuppressCount == 0 {
+				continue
+			}
+			prefixes = append(prefixes, s)
+		}
+
+		// Most unstable prefixes first.
+		sort.SliceStable(prefixes, func(i, j int) bool {
+			if prefixes[i].MaxPenalty != prefixes[j].MaxPenalty {
+				return prefixes[i].MaxPenalty > prefixes[j].MaxPenalty
+			}
+			return prefixes[i].Withdrawals+prefixes[i].Announcements >
+				prefixes[j].Withdrawals+prefixes[j].Announcements
+		})
+		if len(prefixes) > limit {
+			prefixes = prefixes[:limit]
+		}
+
+		resp := model.FlapStatsResponse{
+			RouterID:  routerID,
+			From:      model.FormatTime(from),
+			To:        model.FormatTime(to),
+			Dampening: params,
+			Summary:   stats.Summary,
+			Prefixes:  prefixes,
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+)
+
+func TestFlapsRejectsInvalidPrefix(t *testing.T) {
+	handler := HandleGetFlapStats(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/flaps?prefix=notaprefix",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+}
+
+func TestFlapsRejectsReuseAboveSuppress(t *testing.T) {
+	handler := HandleGetFlapStats(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/flaps?reuse=3000&suppress=2000",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) == 0 || prob.InvalidParams[0].Name != "reuse" {
+		t.Fatalf("expected invalid param 'reuse', got %+v", prob.InvalidParams)
+	}
+}
+
+func TestFlapsRejectsInvalidHalfLife(t *testing.T) {
+	handler := HandleGetFlapStats(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/flaps?half_life=0",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
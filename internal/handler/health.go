@@ -6,11 +6,10 @@ import (
 	"time"
 
 	"github.com/pobradovic08/route-beacon/internal/model"
-	"github.com/pobradovic08/route-beacon/internal/store"
 )
 
 // HandleGetHealth returns the system health status.
-func HandleGetHealth(db *store.DB, startTime time.Time) http.HandlerFunc {
+func HandleGetHealth(db Store, startTime time.Time) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		summary, err := db.GetHealthSummary(r.Context())
 		if err != nil {
@@ -1,123 +1,284 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/pobradovic08/route-beacon/internal/config"
 	"github.com/pobradovic08/route-beacon/internal/model"
 	"github.com/pobradovic08/route-beacon/internal/store"
 )
 
 // HandleGetRouteHistory handles GET /api/v1/routers/{routerId}/routes/history.
-func HandleGetRouteHistory(db *store.DB) http.HandlerFunc {
+func HandleGetRouteHistory(db Store, limits config.Limits) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		routerID := r.PathValue("routerId")
-		prefix := r.URL.Query().Get("prefix")
 
-		if prefix == "" {
-			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
-				"Request validation failed.",
-				[]model.InvalidParam{{Name: "prefix", Reason: "prefix query parameter is required."}})
+		q, ok := parseHistoryQuery(w, r, limits)
+		if !ok {
 			return
 		}
+		q.RouterIDs = []string{routerID}
 
-		// Validate prefix is CIDR
-		_, _, err := net.ParseCIDR(prefix)
+		// Check router exists
+		routerSummary, _, err := db.GetRouterSummary(r.Context(), routerID)
 		if err != nil {
-			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
-				"Request validation failed.",
-				[]model.InvalidParam{{Name: "prefix", Reason: "Not a valid IPv4 or IPv6 prefix."}})
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+			return
+		}
+		if routerSummary == nil {
+			model.WriteProblem(w, http.StatusNotFound, "Router '"+routerID+"' does not exist.")
+			return
+		}
+
+		writeRouteHistory(w, r, db, q, model.RouteHistoryResponse{RouterID: routerID})
+	}
+}
+
+// HandleGetMultiRouterHistory handles GET /api/v1/routes/history, which merges
+// the history of several routers (or all of them) into one timeline.
+func HandleGetMultiRouterHistory(db Store, limits config.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, ok := parseHistoryQuery(w, r, limits)
+		if !ok {
 			return
 		}
 
-		// Parse time range
-		now := time.Now().UTC()
-		from := now.Add(-24 * time.Hour)
-		to := now
+		if q.RouterIDs, ok = parseRouterList(w, r, db, limits.MaxHistoryRouters); !ok {
+			return
+		}
+
+		resp := model.RouteHistoryResponse{Routers: q.RouterIDs}
+		if resp.Routers == nil {
+			resp.Routers = []string{}
+		}
+		writeRouteHistory(w, r, db, q, resp)
+	}
+}
+
+// parseRouterList reads the routers query parameter of the multi-router
+// endpoints: "all" (the default, returned as nil) or a comma-separated list
+// of up to maxRouters existing router IDs. It writes a problem
+// response and returns ok=false when the list is invalid.
+func parseRouterList(w http.ResponseWriter, r *http.Request, db Store, maxRouters int) ([]string, bool) {
+	routers := r.URL.Query().Get("routers")
+	if routers == "" || routers == "all" {
+		return nil, true
+	}
 
-		if v := r.URL.Query().Get("from"); v != "" {
-			parsed, err := time.Parse(time.RFC3339, v)
-			if err != nil {
-				model.WriteProblemWithParams(w, http.StatusBadRequest,
-					"Request validation failed.",
-					[]model.InvalidParam{{Name: "from", Reason: "Must be a valid ISO 8601 timestamp."}})
-				return
-			}
-			from = parsed
+	var ids []string
+	for _, id := range strings.Split(routers, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
 		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 || len(ids) > maxRouters {
+		model.WriteProblemWithParams(w, http.StatusBadRequest,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "routers", Reason: "Must be 'all' or a comma-separated list of 1 to " + strconv.Itoa(maxRouters) + " router IDs."}})
+		return nil, false
+	}
 
-		if v := r.URL.Query().Get("to"); v != "" {
-			parsed, err := time.Parse(time.RFC3339, v)
-			if err != nil {
-				model.WriteProblemWithParams(w, http.StatusBadRequest,
-					"Request validation failed.",
-					[]model.InvalidParam{{Name: "to", Reason: "Must be a valid ISO 8601 timestamp."}})
-				return
-			}
-			to = parsed
+	// Check routers exist
+	for _, id := range ids {
+		routerSummary, _, err := db.GetRouterSummary(r.Context(), id)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+			return nil, false
+		}
+		if routerSummary == nil {
+			model.WriteProblem(w, http.StatusNotFound, "Router '"+id+"' does not exist.")
+			return nil, false
 		}
+	}
+	return ids, true
+}
+
+// parseScope reads the scope query parameter and reports whether
+// more-specific prefixes are included. It writes a problem response and
+// returns ok=false for unknown values.
+func parseScope(w http.ResponseWriter, r *http.Request) (subnets, ok bool) {
+	switch r.URL.Query().Get("scope") {
+	case "", "exact":
+		return false, true
+	case "subnets":
+		return true, true
+	}
+	model.WriteProblemWithParams(w, http.StatusBadRequest,
+		"Request validation failed.",
+		[]model.InvalidParam{{Name: "scope", Reason: "Must be 'exact' or 'subnets'."}})
+	return false, false
+}
+
+// historyRequest is a parsed history query and the requested resolution.
+type historyRequest struct {
+	store.HistoryQuery
+	Resolution string
+}
+
+// historyResolutions are the accepted values of the resolution parameter.
+var historyResolutions = []string{"raw", "intervals", "hour", "day"}
+
+// parseHistoryQuery validates the prefix, scope, time range, limit, cursor and
+// resolution parameters shared by the history endpoints.
+func parseHistoryQuery(w http.ResponseWriter, r *http.Request, limits config.Limits) (historyRequest, bool) {
+	var q historyRequest
+
+	q.Prefix = r.URL.Query().Get("prefix")
+	if q.Prefix == "" {
+		model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "prefix", Reason: "prefix query parameter is required."}})
+		return q, false
+	}
+
+	// Validate prefix is CIDR
+	if _, _, err := net.ParseCIDR(q.Prefix); err != nil {
+		model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "prefix", Reason: "Not a valid IPv4 or IPv6 prefix."}})
+		return q, false
+	}
+
+	var ok bool
+	if q.Subnets, ok = parseScope(w, r); !ok {
+		return q, false
+	}
+	if q.From, q.To, ok = parseTimeRange(w, r, time.Duration(limits.MaxHistoryRange)); !ok {
+		return q, false
+	}
+	q.Since = q.From.Add(-time.Duration(limits.MaxHistoryRange))
+	if q.Limit, ok = parseIntParam(w, r, "limit", min(100, limits.MaxPageSize), 1, limits.MaxPageSize); !ok {
+		return q, false
+	}
 
-		// Validate time range
-		if from.After(to) {
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		cursor, err := store.DecodeHistoryCursor(v)
+		if err != nil {
 			model.WriteProblemWithParams(w, http.StatusBadRequest,
 				"Request validation failed.",
-				[]model.InvalidParam{{Name: "from", Reason: "'from' must not be after 'to'."}})
-			return
+				[]model.InvalidParam{{Name: "cursor", Reason: "Not a valid pagination cursor."}})
+			return q, false
 		}
-		if to.Sub(from) > 7*24*time.Hour {
+		q.After = cursor
+	}
+
+	q.Resolution = r.URL.Query().Get("resolution")
+	if q.Resolution == "" {
+		q.Resolution = "raw"
+	}
+	if !slices.Contains(historyResolutions, q.Resolution) {
+		model.WriteProblemWithParams(w, http.StatusBadRequest,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "resolution", Reason: "Must be 'raw', 'intervals', 'hour' or 'day'."}})
+		return q, false
+	}
+	if q.Resolution != "raw" && q.After != nil {
+		model.WriteProblemWithParams(w, http.StatusBadRequest,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "cursor", Reason: "Cursors are only supported with resolution 'raw'."}})
+		return q, false
+	}
+
+	switch r.URL.Query().Get("view") {
+	case "", "events":
+	case "changes":
+		q.Changes = true
+	default:
+		model.WriteProblemWithParams(w, http.StatusBadRequest,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "view", Reason: "Must be 'events' or 'changes'."}})
+		return q, false
+	}
+	if q.Changes && q.Resolution != "raw" {
+		model.WriteProblemWithParams(w, http.StatusBadRequest,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "view", Reason: "The 'changes' view is only supported with resolution 'raw'."}})
+		return q, false
+	}
+	switch r.URL.Query().Get("hide_unchanged") {
+	case "", "false":
+	case "true":
+		if !q.Changes {
 			model.WriteProblemWithParams(w, http.StatusBadRequest,
 				"Request validation failed.",
-				[]model.InvalidParam{{Name: "from", Reason: "Time range must not exceed 7 days."}})
-			return
+				[]model.InvalidParam{{Name: "hide_unchanged", Reason: "Requires view 'changes'."}})
+			return q, false
 		}
+		q.HideUnchanged = true
+	default:
+		model.WriteProblemWithParams(w, http.StatusBadRequest,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "hide_unchanged", Reason: "Must be 'true' or 'false'."}})
+		return q, false
+	}
+	return q, true
+}
 
-		// Parse limit
-		limit := 100
-		if v := r.URL.Query().Get("limit"); v != "" {
-			parsed, err := strconv.Atoi(v)
-			if err != nil || parsed < 1 || parsed > 1000 {
-				model.WriteProblemWithParams(w, http.StatusBadRequest,
-					"Request validation failed.",
-					[]model.InvalidParam{{Name: "limit", Reason: "Must be between 1 and 1000."}})
-				return
-			}
-			limit = parsed
-		}
+// writeRouteHistory runs a history query at the requested resolution and
+// writes the response, filling in the query-derived fields of resp.
+func writeRouteHistory(w http.ResponseWriter, r *http.Request, db Store, q historyRequest, resp model.RouteHistoryResponse) {
+	resp.Prefix = q.Prefix
+	resp.Scope = "exact"
+	if q.Subnets {
+		resp.Scope = "subnets"
+	}
+	resp.From = model.FormatTime(q.From)
+	resp.To = model.FormatTime(q.To)
+	resp.Resolution = q.Resolution
+	resp.View = "events"
+	if q.Changes {
+		resp.View = "changes"
+	}
+	resp.Events = []model.RouteEvent{}
 
-		// Check router exists
-		routerSummary, _, err := db.GetRouterSummary(r.Context(), routerID)
-		if err != nil {
-			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+	switch q.Resolution {
+	case "intervals":
+		intervals, err := db.GetRouteIntervals(r.Context(), q.HistoryQuery)
+		if errors.Is(err, store.ErrTooManyEvents) {
+			model.WriteProblemWithParams(w, http.StatusBadRequest,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "resolution", Reason: "Too many events in range for intervals; narrow the range or use 'hour' or 'day'."}})
 			return
 		}
-		if routerSummary == nil {
-			model.WriteProblem(w, http.StatusNotFound, "Router '"+routerID+"' does not exist.")
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query route history.")
 			return
 		}
+		if len(intervals) > q.Limit {
+			intervals = intervals[:q.Limit]
+			resp.Truncated = true
+		}
+		resp.Intervals = intervals
 
-		events, err := db.GetRouteHistory(r.Context(), routerID, prefix, from, to, limit)
+	case "hour", "day":
+		buckets, truncated, err := db.GetHistoryBuckets(r.Context(), q.HistoryQuery, q.Resolution)
 		if err != nil {
 			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query route history.")
 			return
 		}
+		resp.Buckets = buckets
+		resp.Truncated = truncated
 
-		hasMore := len(events) > limit
-		if hasMore {
-			events = events[:limit]
+	default:
+		events, next, err := db.GetRouteHistory(r.Context(), q.HistoryQuery)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query route history.")
+			return
 		}
-
-		resp := model.RouteHistoryResponse{
-			RouterID: routerID,
-			Prefix:   prefix,
-			From:     model.FormatTime(from),
-			To:       model.FormatTime(to),
-			Events:   events,
-			HasMore:  hasMore,
+		resp.Events = events
+		if next != nil {
+			c := next.Encode()
+			resp.NextCursor = &c
 		}
-
-		json.NewEncoder(w).Encode(resp)
 	}
+
+	writeCached(w, r, resp, maxAgeFor(q.To), time.Time{})
 }
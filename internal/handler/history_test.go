@@ -5,6 +5,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
 )
 
 // problemResponse is a minimal structure for RFC 7807 error responses.
@@ -12,6 +17,7 @@ type problemResponse struct {
 	Title         string `json:"title"`
 	Status        int    `json:"status"`
 	Detail        string `json:"detail"`
+	Instance      string `json:"instance"`
 	InvalidParams []struct {
 		Name   string `json:"name"`
 		Reason string `json:"reason"`
@@ -20,7 +26,7 @@ type problemResponse struct {
 
 func TestHistoryRejectsReversedTimeRange(t *testing.T) {
 	// Use a nil DB since validation should reject before any DB call.
-	handler := HandleGetRouteHistory(nil)
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
 
 	req := httptest.NewRequest("GET",
 		"/api/v1/routers/r1/routes/history?prefix=10.0.0.0/24&from=2025-01-02T00:00:00Z&to=2025-01-01T00:00:00Z",
@@ -47,7 +53,7 @@ func TestHistoryRejectsReversedTimeRange(t *testing.T) {
 }
 
 func TestHistoryRejectsMissingPrefix(t *testing.T) {
-	handler := HandleGetRouteHistory(nil)
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
 
 	req := httptest.NewRequest("GET",
 		"/api/v1/routers/r1/routes/history",
@@ -63,7 +69,7 @@ func TestHistoryRejectsMissingPrefix(t *testing.T) {
 }
 
 func TestHistoryRejectsInvalidPrefix(t *testing.T) {
-	handler := HandleGetRouteHistory(nil)
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
 
 	req := httptest.NewRequest("GET",
 		"/api/v1/routers/r1/routes/history?prefix=notaprefix",
@@ -78,11 +84,11 @@ func TestHistoryRejectsInvalidPrefix(t *testing.T) {
 	}
 }
 
-func TestHistoryRejectsExceeded7DayRange(t *testing.T) {
-	handler := HandleGetRouteHistory(nil)
+func TestHistoryRejectsExceededRange(t *testing.T) {
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
 
 	req := httptest.NewRequest("GET",
-		"/api/v1/routers/r1/routes/history?prefix=10.0.0.0/24&from=2025-01-01T00:00:00Z&to=2025-01-10T00:00:00Z",
+		"/api/v1/routers/r1/routes/history?prefix=10.0.0.0/24&from=2024-01-01T00:00:00Z&to=2025-01-10T00:00:00Z",
 		nil)
 	req.SetPathValue("routerId", "r1")
 	w := httptest.NewRecorder()
@@ -95,7 +101,7 @@ func TestHistoryRejectsExceeded7DayRange(t *testing.T) {
 }
 
 func TestHistoryRejectsInvalidLimit(t *testing.T) {
-	handler := HandleGetRouteHistory(nil)
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
 
 	req := httptest.NewRequest("GET",
 		"/api/v1/routers/r1/routes/history?prefix=10.0.0.0/24&limit=0",
@@ -109,3 +115,265 @@ func TestHistoryRejectsInvalidLimit(t *testing.T) {
 		t.Fatalf("expected 400, got %d", w.Code)
 	}
 }
+
+func TestHistoryRejectsInvalidScope(t *testing.T) {
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/history?prefix=10.0.0.0/8&scope=supernets",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) == 0 || prob.InvalidParams[0].Name != "scope" {
+		t.Fatalf("expected invalid param 'scope', got %+v", prob.InvalidParams)
+	}
+}
+
+func TestMultiRouterHistoryRejectsMissingPrefix(t *testing.T) {
+	handler := HandleGetMultiRouterHistory(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routes/history?routers=all",
+		nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+}
+
+func TestMultiRouterHistoryRejectsEmptyRouterList(t *testing.T) {
+	handler := HandleGetMultiRouterHistory(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routes/history?prefix=10.0.0.0/8&routers=,,",
+		nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestHistoryRejectsInvalidCursor(t *testing.T) {
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/history?prefix=10.0.0.0/24&cursor=!!",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestHistoryRejectsInvalidResolution(t *testing.T) {
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/history?prefix=10.0.0.0/24&resolution=week",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestHistoryRejectsCursorWithIntervals(t *testing.T) {
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
+
+	cursor := store.HistoryCursor{Time: time.Now(), EventID: []byte{1}}.Encode()
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/history?prefix=10.0.0.0/24&resolution=intervals&cursor="+cursor,
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) == 0 || prob.InvalidParams[0].Name != "cursor" {
+		t.Fatalf("expected invalid param 'cursor', got %+v", prob.InvalidParams)
+	}
+}
+
+func TestHistoryRejectsInvalidView(t *testing.T) {
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/history?prefix=10.0.0.0/24&view=attributes",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestHistoryRejectsChangesWithBuckets(t *testing.T) {
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/history?prefix=10.0.0.0/24&view=changes&resolution=hour",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) == 0 || prob.InvalidParams[0].Name != "view" {
+		t.Fatalf("expected invalid param 'view', got %+v", prob.InvalidParams)
+	}
+}
+
+func TestHistoryRejectsHideUnchangedWithoutChanges(t *testing.T) {
+	handler := HandleGetRouteHistory(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/history?prefix=10.0.0.0/24&hide_unchanged=true",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) == 0 || prob.InvalidParams[0].Name != "hide_unchanged" {
+		t.Fatalf("expected invalid param 'hide_unchanged', got %+v", prob.InvalidParams)
+	}
+}
+
+// historyTestRequest returns a history request for r1 over the day before the
+// test store's routes were last updated.
+func historyTestRequest(query string) *http.Request {
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/history?from=2025-12-31T00:00:00Z&to=2026-01-01T00:00:00Z&"+query,
+		nil)
+	req.SetPathValue("routerId", "r1")
+	return req
+}
+
+func decodeHistory(t *testing.T, w *httptest.ResponseRecorder) model.RouteHistoryResponse {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp model.RouteHistoryResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	return resp
+}
+
+func TestHistoryPagesWithCursor(t *testing.T) {
+	handler := HandleGetRouteHistory(newTestStore(t), config.DefaultLimits())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, historyTestRequest("prefix=10.1.0.0/16&limit=2"))
+	resp := decodeHistory(t, w)
+	if resp.RouterID != "r1" || resp.Resolution != "raw" || resp.View != "events" {
+		t.Errorf("unexpected response fields: %+v", resp)
+	}
+	if len(resp.Events) != 2 || resp.Events[0].Timestamp != "2025-12-31T12:00:00Z" || resp.Events[1].Timestamp != "2025-12-31T10:00:00Z" {
+		t.Fatalf("unexpected first page: %+v", resp.Events)
+	}
+	if resp.Events[0].ChangeType != nil {
+		t.Errorf("expected no change types in the events view, got %q", *resp.Events[0].ChangeType)
+	}
+	if resp.NextCursor == nil {
+		t.Fatal("expected a next cursor")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, historyTestRequest("prefix=10.1.0.0/16&limit=2&cursor="+*resp.NextCursor))
+	resp = decodeHistory(t, w)
+	if len(resp.Events) != 1 || resp.Events[0].Timestamp != "2025-12-31T08:00:00Z" || resp.NextCursor != nil {
+		t.Fatalf("unexpected second page: %+v, next %v", resp.Events, resp.NextCursor)
+	}
+}
+
+func TestHistoryChangesView(t *testing.T) {
+	handler := HandleGetRouteHistory(newTestStore(t), config.DefaultLimits())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, historyTestRequest("prefix=10.1.0.0/16&view=changes&hide_unchanged=true"))
+	resp := decodeHistory(t, w)
+	if resp.View != "changes" || len(resp.Events) != 2 {
+		t.Fatalf("expected 2 events in the changes view, got %+v", resp)
+	}
+	update, initial := resp.Events[0], resp.Events[1]
+	if update.ChangeType == nil || *update.ChangeType != store.ChangeUpdate {
+		t.Errorf("expected an update, got %+v", update)
+	}
+	if len(update.Changes) != 1 || update.Changes[0].Attribute != "med" {
+		t.Errorf("expected a MED change, got %+v", update.Changes)
+	}
+	if initial.ChangeType == nil || *initial.ChangeType != store.ChangeInitial {
+		t.Errorf("expected the initial announcement, got %+v", initial)
+	}
+}
+
+func TestMultiRouterHistory(t *testing.T) {
+	handler := HandleGetMultiRouterHistory(newTestStore(t), config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routes/history?prefix=10.0.0.0/8&scope=subnets&routers=r1&from=2025-12-31T00:00:00Z&to=2026-01-01T00:00:00Z",
+		nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	resp := decodeHistory(t, w)
+	if len(resp.Routers) != 1 || resp.Routers[0] != "r1" || resp.Scope != "subnets" {
+		t.Errorf("unexpected response fields: %+v", resp)
+	}
+	if len(resp.Events) != 3 || resp.Events[0].RouterID != "r1" || resp.Events[0].Prefix != "10.1.0.0/16" {
+		t.Errorf("expected the 3 events of the more-specific, got %+v", resp.Events)
+	}
+}
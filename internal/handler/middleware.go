@@ -1,25 +1,51 @@
 package handler
 
 import (
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/telemetry"
 )
 
-// CORS wraps a handler to allow cross-origin requests from any origin.
-func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+// CORS returns middleware allowing cross-origin requests from the given
+// origins. "*" allows any origin; otherwise the request's Origin is echoed
+// back only when it is listed.
+func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(allowedOrigins, "*")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if anyOrigin {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Add("Vary", "Origin")
+				if origin := r.Header.Get("Origin"); origin != "" && slices.Contains(allowedOrigins, origin) {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Modified-Since, If-None-Match, X-API-Key, X-Request-ID, traceparent, tracestate")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified, Retry-After, X-Request-ID")
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 // JSON sets the Content-Type header to application/json for all responses.
@@ -30,12 +56,16 @@ func JSON(next http.Handler) http.Handler {
 	})
 }
 
-// Recover catches panics and returns an RFC 7807 error response.
+// Recover catches panics, logs them with their stack trace and returns an
+// RFC 7807 error response.
 func Recover(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("panic: %v", err)
+				slog.ErrorContext(r.Context(), "panic",
+					slog.String("request_id", telemetry.RequestID(r.Context())),
+					slog.Any("error", err),
+					slog.String("stack", string(debug.Stack())))
 				model.WriteProblem(w, http.StatusInternalServerError, "An unexpected error occurred.")
 			}
 		}()
@@ -43,10 +73,11 @@ func Recover(next http.Handler) http.Handler {
 	})
 }
 
-// responseWriter captures the status code for logging.
+// responseWriter captures the status code and body size for logging.
 type responseWriter struct {
 	http.ResponseWriter
 	status int
+	bytes  int64
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -54,12 +85,157 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// Logger logs each request with method, path, status code, and duration.
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, for
+// flushing and deadlines of streamed responses.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Logger writes one structured log record per request. Server errors are
+// logged at error level, everything else at info level.
 func Logger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
 		next.ServeHTTP(rw, r)
-		log.Printf("%s %s %d %s", r.Method, r.URL.Path, rw.status, time.Since(start).Round(time.Microsecond))
+
+		attrs := []slog.Attr{
+			slog.String("request_id", telemetry.RequestID(r.Context())),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("client_ip", clientIP(r)),
+			slog.Int("status", rw.status),
+			slog.Int64("bytes", rw.bytes),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if info := telemetry.Info(r.Context()); info != nil {
+			if info.Route != "" {
+				attrs = append(attrs, slog.String("route", info.Route))
+			}
+			if info.RouterID != "" {
+				attrs = append(attrs, slog.String("router_id", info.RouterID))
+			}
+			if info.TraceID != "" {
+				attrs = append(attrs, slog.String("trace_id", info.TraceID))
+			}
+			if info.Subject != "" {
+				attrs = append(attrs, slog.String("subject", info.Subject))
+			}
+		}
+
+		level := slog.LevelInfo
+		if rw.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
+
+// clientIP returns the host part of the request's remote address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// maxRequestIDLen bounds client-supplied request IDs.
+const maxRequestIDLen = 128
+
+// RequestID assigns every request an ID, reusing a well-formed X-Request-ID
+// from the client, stores it in the request context and echoes it in the
+// response header.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(model.RequestIDHeader)
+		if !validRequestID(id) {
+			id = telemetry.NewRequestID()
+		}
+		w.Header().Set(model.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(telemetry.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID accepts non-empty IDs of printable ASCII without spaces.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Instrument wraps the router in a server span and records the request
+// latency per route pattern. It must wrap the ServeMux directly so that the
+// matched pattern is visible once the request has been served; requests
+// that match no route are recorded as "unmatched".
+func Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := telemetry.Tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				attribute.String("request_id", telemetry.RequestID(ctx)),
+			))
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(ctx)
+
+		defer func() {
+			// A panic is turned into a 500 by Recover further out.
+			err := recover()
+			if err != nil {
+				rw.status = http.StatusInternalServerError
+			}
+
+			route := routeOf(r.Pattern)
+			if info := telemetry.Info(r.Context()); info != nil {
+				info.Route = route
+				info.RouterID = r.PathValue("routerId")
+				if sc := span.SpanContext(); sc.IsValid() {
+					info.TraceID = sc.TraceID().String()
+				}
+			}
+			if route == "" {
+				route = "unmatched"
+			} else {
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(semconv.HTTPRoute(route))
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(rw.status))
+			if rw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rw.status))
+			}
+			span.End()
+			telemetry.HTTPRequestDuration.WithLabelValues(r.Method, route, strconv.Itoa(rw.status)).
+				Observe(time.Since(start).Seconds())
+
+			if err != nil {
+				panic(err)
+			}
+		}()
+		next.ServeHTTP(rw, r)
 	})
 }
+
+// routeOf strips the method from a ServeMux pattern such as
+// "GET /api/v1/routers/{routerId}".
+func routeOf(pattern string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+	"github.com/pobradovic08/route-beacon/internal/logging"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/telemetry"
+)
+
+func TestRequestIDGenerated(t *testing.T) {
+	var seen string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = telemetry.RequestID(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/health", nil))
+
+	if len(seen) != 32 {
+		t.Fatalf("expected generated 32-char ID, got %q", seen)
+	}
+	if got := w.Header().Get(model.RequestIDHeader); got != seen {
+		t.Fatalf("expected response header %q, got %q", seen, got)
+	}
+}
+
+func TestRequestIDReusesClientID(t *testing.T) {
+	var seen string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = telemetry.RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/health", nil)
+	req.Header.Set(model.RequestIDHeader, "abc-123")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != "abc-123" {
+		t.Fatalf("expected client ID, got %q", seen)
+	}
+
+	req.Header.Set(model.RequestIDHeader, "bad id\n")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if seen == "bad id\n" || len(seen) != 32 {
+		t.Fatalf("expected malformed ID to be replaced, got %q", seen)
+	}
+}
+
+func TestProblemIncludesRequestID(t *testing.T) {
+	h := RequestID(HandleGetRouteHistory(nil, config.DefaultLimits()))
+
+	req := httptest.NewRequest("GET", "/api/v1/routers/r1/routes/history", nil)
+	req.Header.Set(model.RequestIDHeader, "req-1")
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, req)
+
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if prob.Instance != "req-1" {
+		t.Fatalf("expected instance 'req-1', got %q", prob.Instance)
+	}
+}
+
+func TestInstrumentRecordsRoutePattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := Instrument(mux)
+	labels := [][]string{{"GET", "/test/{id}", "418"}, {"GET", "unmatched", "404"}}
+	// The histogram is global, so compare against the count before the
+	// requests rather than assuming it starts at zero.
+	sampleCount := func(labels []string) uint64 {
+		t.Helper()
+		var m dto.Metric
+		if err := telemetry.HTTPRequestDuration.WithLabelValues(labels...).(prometheus.Histogram).Write(&m); err != nil {
+			t.Fatalf("write metric: %v", err)
+		}
+		return m.GetHistogram().GetSampleCount()
+	}
+	before := make([]uint64, len(labels))
+	for i, l := range labels {
+		before[i] = sampleCount(l)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test/42", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nowhere", nil))
+
+	for i, l := range labels {
+		if got := sampleCount(l) - before[i]; got != 1 {
+			t.Fatalf("expected one observation for %v, got %d", l, got)
+		}
+	}
+}
+
+// captureLogs routes the default slog logger into a buffer for the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", "debug")
+	if err != nil {
+		t.Fatalf("create logger: %v", err)
+	}
+	prev := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func TestLoggerRecordsRequestAttributes(t *testing.T) {
+	buf := captureLogs(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/routers/{routerId}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	h := RequestID(Logger(Instrument(mux)))
+
+	req := httptest.NewRequest("GET", "/api/v1/routers/r1", nil)
+	req.Header.Set(model.RequestIDHeader, "req-1")
+	req.RemoteAddr = "192.0.2.10:5555"
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("expected one JSON record, got %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"msg":        "request",
+		"request_id": "req-1",
+		"router_id":  "r1",
+		"route":      "/api/v1/routers/{routerId}",
+		"client_ip":  "192.0.2.10",
+		"status":     float64(200),
+		"bytes":      float64(5),
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, rec[k])
+		}
+	}
+}
+
+func TestRecoverLogsStack(t *testing.T) {
+	buf := captureLogs(t)
+
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("expected one JSON record, got %q: %v", buf.String(), err)
+	}
+	if rec["level"] != "ERROR" || rec["error"] != "boom" {
+		t.Fatalf("unexpected record: %v", rec)
+	}
+	if stack, _ := rec["stack"].(string); !strings.Contains(stack, "TestRecoverLogsStack") {
+		t.Fatalf("expected stack trace, got %q", stack)
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	h := CORS([]string{"*"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/api/v1/health", nil)
+	req.Header.Set("Origin", "https://example.com")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("expected wildcard origin, got %q", got)
+	}
+}
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	h := CORS([]string{"https://noc.example.com"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		origin string
+		want   string
+	}{
+		{"https://noc.example.com", "https://noc.example.com"},
+		{"https://evil.example.com", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/health", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+			t.Errorf("origin %q: expected allow-origin %q, got %q", tt.origin, tt.want, got)
+		}
+		if got := w.Header().Get("Vary"); got != "Origin" {
+			t.Errorf("origin %q: expected Vary: Origin, got %q", tt.origin, got)
+		}
+	}
+}
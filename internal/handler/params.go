@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// parseTimeRange reads the from/to query parameters, defaulting to the last
+// 24 hours. It writes a problem response and returns ok=false when the range
+// is malformed, reversed, or wider than maxRange.
+func parseTimeRange(w http.ResponseWriter, r *http.Request, maxRange time.Duration) (from, to time.Time, ok bool) {
+	now := time.Now().UTC()
+	from = now.Add(-24 * time.Hour)
+	to = now
+
+	if v := r.URL.Query().Get("from"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			model.WriteProblemWithParams(w, http.StatusBadRequest,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "from", Reason: "Must be a valid ISO 8601 timestamp."}})
+			return from, to, false
+		}
+		from = parsed
+	}
+
+	if v := r.URL.Query().Get("to"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			model.WriteProblemWithParams(w, http.StatusBadRequest,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "to", Reason: "Must be a valid ISO 8601 timestamp."}})
+			return from, to, false
+		}
+		to = parsed
+	}
+
+	if from.After(to) {
+		model.WriteProblemWithParams(w, http.StatusBadRequest,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "from", Reason: "'from' must not be after 'to'."}})
+		return from, to, false
+	}
+	if to.Sub(from) > maxRange {
+		model.WriteProblemWithParams(w, http.StatusBadRequest,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "from", Reason: "Time range must not exceed " + formatRange(maxRange) + "."}})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// formatRange renders a range limit in days when it is a whole number of
+// days.
+func formatRange(d time.Duration) string {
+	const day = 24 * time.Hour
+	if d%day == 0 {
+		return strconv.Itoa(int(d/day)) + " days"
+	}
+	return d.String()
+}
+
+// parseIntParam reads an optional integer query parameter bounded by
+// [min, max]. It writes a problem response and returns ok=false when the
+// value is not an integer or out of range.
+func parseIntParam(w http.ResponseWriter, r *http.Request, name string, def, min, max int) (int, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, true
+	}
+	parsed, err := strconv.Atoi(v)
+	if err != nil || parsed < min || parsed > max {
+		model.WriteProblemWithParams(w, http.StatusBadRequest,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: name, Reason: "Must be between " + strconv.Itoa(min) + " and " + strconv.Itoa(max) + "."}})
+		return 0, false
+	}
+	return parsed, true
+}
+
+// parseTimeParam reads an optional ISO 8601 timestamp query parameter that
+// must not lie in the future. It writes a problem response and returns
+// ok=false when the value is invalid.
+func parseTimeParam(w http.ResponseWriter, r *http.Request, name string) (*time.Time, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, true
+	}
+	parsed, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		model.WriteProblemWithParams(w, http.StatusBadRequest,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: name, Reason: "Must be a valid ISO 8601 timestamp."}})
+		return nil, false
+	}
+	if parsed.After(time.Now()) {
+		model.WriteProblemWithParams(w, http.StatusBadRequest,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: name, Reason: "Must not be in the future."}})
+		return nil, false
+	}
+	return &parsed, true
+}
+
+// parseAtParam parses the "at" parameter of point-in-time RIB queries. Only
+// events within maxRange of now are replayed to reconstruct the RIB, so
+// older times are rejected; from is the earliest time replayed.
+func parseAtParam(w http.ResponseWriter, r *http.Request, maxRange time.Duration) (at *time.Time, from time.Time, ok bool) {
+	if at, ok = parseTimeParam(w, r, "at"); !ok || at == nil {
+		return nil, time.Time{}, ok
+	}
+	from = time.Now().Add(-maxRange)
+	if at.Before(from) {
+		model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "at", Reason: "Must not be more than " + formatRange(maxRange) + " ago."}})
+		return nil, time.Time{}, false
+	}
+	return at, from, true
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/probe"
+	"github.com/pobradovic08/route-beacon/internal/telemetry"
+)
+
+// HandleProbe handles GET /api/v1/routers/{routerId}/ping and
+// GET /api/v1/routers/{routerId}/traceroute. The probe runs on the agent
+// registered for the router and its output is streamed as server-sent
+// events: a "line" event per line of output, "reply" events for ping
+// packets and "hop" events for traceroute hops, then a ping "summary" and
+// "complete", or "error". Probes are cancelled when the client disconnects
+// or after timeout.
+func HandleProbe(st Store, probes *probe.Registry, kind string, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routerID := r.PathValue("routerId")
+
+		req := model.ProbeRequest{Kind: kind, Target: r.URL.Query().Get("target")}
+		if req.Target == "" {
+			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "target", Reason: "Required parameter."}})
+			return
+		}
+		var ok bool
+		if kind == model.ProbePing {
+			req.Count, ok = parseIntParam(w, r, "count", probe.DefCount, 1, probe.MaxCount)
+		} else {
+			req.MaxHops, ok = parseIntParam(w, r, "max_hops", probe.DefMaxHops, 1, probe.MaxHops)
+		}
+		if !ok {
+			return
+		}
+		if err := probe.Normalize(&req); err != nil {
+			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "target", Reason: "Must be a routable unicast IPv4 or IPv6 address."}})
+			return
+		}
+
+		// Check router exists
+		routerSummary, _, err := st.GetRouterSummary(r.Context(), routerID)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+			return
+		}
+		if routerSummary == nil {
+			model.WriteProblem(w, http.StatusNotFound, "Router '"+routerID+"' does not exist.")
+			return
+		}
+
+		agent, release, err := probes.Acquire(routerID)
+		switch {
+		case errors.Is(err, probe.ErrNoAgent):
+			model.WriteProblem(w, http.StatusNotFound, "No probe agent serves router '"+routerID+"'.")
+			return
+		case errors.Is(err, probe.ErrBusy):
+			w.Header().Set("Retry-After", "5")
+			model.WriteProblem(w, http.StatusServiceUnavailable, "The probe agent of router '"+routerID+"' is busy. Retry in 5 seconds.")
+			return
+		}
+		defer release()
+
+		// The stream outlives the server's write timeout.
+		rc := http.NewResponseController(w)
+		rc.SetWriteDeadline(time.Now().Add(timeout + 5*time.Second))
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		send := func(event string, data any) {
+			body, _ := json.Marshal(data)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body)
+			rc.Flush()
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		var (
+			replies []model.PingReply
+			lastHop *model.TracerouteHop
+		)
+		err = agent.Run(ctx, req, func(ev model.ProbeEvent) {
+			send("line", model.ProbeLine{Line: ev.Line})
+			switch {
+			case ev.Reply != nil:
+				replies = append(replies, *ev.Reply)
+				send("reply", ev.Reply)
+			case ev.Hop != nil:
+				lastHop = ev.Hop
+				send("hop", ev.Hop)
+			}
+		})
+		var agentErr *probe.AgentError
+		switch {
+		case r.Context().Err() != nil:
+			return
+		case errors.Is(err, context.DeadlineExceeded):
+			send("error", model.ProbeError{Code: "timeout", Message: "Probe timed out after " + timeout.String() + "."})
+		case errors.As(err, &agentErr):
+			send("error", model.ProbeError{Code: "probe_failed", Message: agentErr.Message})
+		case err != nil:
+			slog.ErrorContext(r.Context(), "probe agent failed",
+				slog.String("request_id", telemetry.RequestID(r.Context())),
+				slog.String("router_id", routerID),
+				slog.Any("error", err))
+			send("error", model.ProbeError{Code: "agent_error", Message: "The probe agent failed."})
+		case kind == model.ProbePing:
+			send("summary", pingSummary(req.Count, replies))
+			send("complete", struct{}{})
+		default:
+			send("complete", model.TracerouteComplete{ReachedDestination: lastHop != nil && lastHop.Address == req.Target})
+		}
+	}
+}
+
+// pingSummary summarises the replies to sent ping packets.
+func pingSummary(sent int, replies []model.PingReply) model.PingSummary {
+	summary := model.PingSummary{PacketsSent: sent}
+	var total float64
+	for _, r := range replies {
+		if !r.Success {
+			continue
+		}
+		if summary.PacketsReceived == 0 || r.RTTMs < summary.RTTMinMs {
+			summary.RTTMinMs = r.RTTMs
+		}
+		summary.RTTMaxMs = max(summary.RTTMaxMs, r.RTTMs)
+		summary.PacketsReceived++
+		total += r.RTTMs
+	}
+	if summary.PacketsReceived > 0 {
+		summary.RTTAvgMs = math.Round(total/float64(summary.PacketsReceived)*1000) / 1000
+	}
+	if sent > 0 {
+		summary.LossPct = math.Round(float64(sent-summary.PacketsReceived)/float64(sent)*1000) / 10
+	}
+	return summary
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/probe"
+)
+
+// sseEvent is a server-sent event.
+type sseEvent struct {
+	name string
+	data string
+}
+
+// sseEvents splits a server-sent event stream into its events.
+func sseEvents(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		name, data, ok := strings.Cut(block, "\n")
+		if !ok || !strings.HasPrefix(name, "event: ") || !strings.HasPrefix(data, "data: ") {
+			t.Fatalf("malformed event %q", block)
+		}
+		events = append(events, sseEvent{strings.TrimPrefix(name, "event: "), strings.TrimPrefix(data, "data: ")})
+	}
+	return events
+}
+
+// decodeEvent decodes the data of ev into v.
+func decodeEvent(t *testing.T, ev sseEvent, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(ev.data), v); err != nil {
+		t.Fatalf("decode %s event: %v", ev.name, err)
+	}
+}
+
+func probeRequest(routerID, path string) *http.Request {
+	req := httptest.NewRequest("GET", "/api/v1/routers/"+routerID+path, nil)
+	req.SetPathValue("routerId", routerID)
+	return req
+}
+
+func TestProbePingStream(t *testing.T) {
+	probes := probe.NewRegistry(1)
+	probes.Register(&probe.Fake{}, "r1")
+	// Served through Logger to check that flushing reaches the recorder.
+	handler := Logger(HandleProbe(newTestStore(t), probes, model.ProbePing, time.Minute))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, probeRequest("r1", "/ping?target=192.0.2.1&count=3"))
+
+	if w.Code != http.StatusOK || !w.Flushed {
+		t.Fatalf("expected a flushed 200, got %d (flushed %v)", w.Code, w.Flushed)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	events := sseEvents(t, w.Body.String())
+	count := map[string]int{}
+	for _, ev := range events {
+		count[ev.name]++
+	}
+	if count["reply"] != 3 || count["line"] == 0 || events[len(events)-1].name != "complete" {
+		t.Fatalf("unexpected events %v", count)
+	}
+	var summary model.PingSummary
+	decodeEvent(t, events[len(events)-2], &summary)
+	if summary.PacketsSent != 3 || summary.PacketsReceived != 3 || summary.LossPct != 0 || summary.RTTMinMs == 0 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+}
+
+func TestProbeTracerouteAgentError(t *testing.T) {
+	probes := probe.NewRegistry(1)
+	probes.Register(&probe.Fake{Err: &probe.AgentError{Message: "connect: Network is unreachable"}}, "r1")
+	handler := HandleProbe(newTestStore(t), probes, model.ProbeTraceroute, time.Minute)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, probeRequest("r1", "/traceroute?target=2001:db8::1&max_hops=2"))
+
+	events := sseEvents(t, w.Body.String())
+	hops := 0
+	for _, ev := range events {
+		if ev.name == "hop" {
+			hops++
+		}
+	}
+	var probeErr model.ProbeError
+	decodeEvent(t, events[len(events)-1], &probeErr)
+	if hops != 2 || probeErr.Code != "probe_failed" || probeErr.Message != "connect: Network is unreachable" {
+		t.Fatalf("unexpected stream: %d hops, last event %+v", hops, events[len(events)-1])
+	}
+}
+
+func TestProbeTracerouteReachesTarget(t *testing.T) {
+	probes := probe.NewRegistry(1)
+	probes.Register(&probe.Fake{}, "r1")
+	handler := HandleProbe(newTestStore(t), probes, model.ProbeTraceroute, time.Minute)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, probeRequest("r1", "/traceroute?target=192.0.2.99"))
+
+	events := sseEvents(t, w.Body.String())
+	var complete model.TracerouteComplete
+	decodeEvent(t, events[len(events)-1], &complete)
+	if events[len(events)-1].name != "complete" || !complete.ReachedDestination {
+		t.Fatalf("expected the destination to be reached, got %+v", events[len(events)-1])
+	}
+}
+
+func TestProbeRejectsRequests(t *testing.T) {
+	probes := probe.NewRegistry(1)
+	probes.Register(&probe.Fake{}, "r1", "r2")
+
+	tests := []struct {
+		routerID, path string
+		wantStatus     int
+	}{
+		{"r1", "/ping", http.StatusUnprocessableEntity},
+		{"r1", "/ping?target=example.com", http.StatusUnprocessableEntity},
+		{"r1", "/ping?target=127.0.0.1", http.StatusUnprocessableEntity},
+		{"r1", "/ping?target=192.0.2.1&count=50", http.StatusBadRequest},
+		{"r9", "/ping?target=192.0.2.1", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		handler := HandleProbe(newTestStore(t), probes, model.ProbePing, time.Minute)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, probeRequest(tt.routerID, tt.path))
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s%s: expected %d, got %d", tt.routerID, tt.path, tt.wantStatus, w.Code)
+		}
+	}
+}
+
+func TestProbeWithoutAgent(t *testing.T) {
+	handler := HandleProbe(newTestStore(t), probe.NewRegistry(1), model.ProbePing, time.Minute)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, probeRequest("r1", "/ping?target=192.0.2.1"))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestProbeAgentBusy(t *testing.T) {
+	probes := probe.NewRegistry(1)
+	probes.Register(&probe.Fake{}, "r1")
+	_, release, err := probes.Acquire("r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	handler := HandleProbe(newTestStore(t), probes, model.ProbePing, time.Minute)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, probeRequest("r1", "/ping?target=192.0.2.1"))
+
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 503 with Retry-After, got %d", w.Code)
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	probes := probe.NewRegistry(1)
+	probes.Register(&probe.Fake{Delay: time.Second}, "r1")
+	handler := HandleProbe(newTestStore(t), probes, model.ProbePing, 10*time.Millisecond)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, probeRequest("r1", "/ping?target=192.0.2.1"))
+
+	events := sseEvents(t, w.Body.String())
+	var probeErr model.ProbeError
+	decodeEvent(t, events[0], &probeErr)
+	if len(events) != 1 || events[0].name != "error" || probeErr.Code != "timeout" {
+		t.Fatalf("expected a timeout error event, got %+v", events)
+	}
+}
+
+func TestPingSummary(t *testing.T) {
+	got := pingSummary(4, []model.PingReply{
+		{Seq: 1, RTTMs: 10, Success: true},
+		{Seq: 2},
+		{Seq: 3, RTTMs: 14, Success: true},
+		{Seq: 4, RTTMs: 12.5, Success: true},
+	})
+	want := model.PingSummary{PacketsSent: 4, PacketsReceived: 3, LossPct: 25, RTTMinMs: 10, RTTAvgMs: 12.167, RTTMaxMs: 14}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
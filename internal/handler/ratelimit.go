@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/pobradovic08/route-beacon/internal/auth"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/ratelimit"
+	"github.com/pobradovic08/route-beacon/internal/telemetry"
+)
+
+// RealIP replaces r.RemoteAddr with the client address from
+// X-Forwarded-For when the request arrives from a trusted proxy. The header
+// is read right to left, skipping trusted proxies, so clients cannot spoof
+// their address by sending their own X-Forwarded-For.
+func RealIP(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	trusted := func(addr netip.Addr) bool {
+		for _, p := range trustedProxies {
+			if p.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(next http.Handler) http.Handler {
+		if len(trustedProxies) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remote, err := netip.ParseAddr(clientIP(r))
+			if err != nil || !trusted(remote) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+				if err != nil {
+					break
+				}
+				if !trusted(addr) {
+					r2 := r.Clone(r.Context())
+					r2.RemoteAddr = net.JoinHostPort(addr.Unmap().String(), "0")
+					next.ServeHTTP(w, r2)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit wraps next with the per-client limiter of a cost class.
+// Authenticated callers are limited per credential, anonymous callers per
+// client IP (per /64 for IPv6). Rejected requests get 429 with
+// Retry-After. A nil limiter disables limiting.
+func RateLimit(class string, l *ratelimit.Limiter, next http.HandlerFunc) http.HandlerFunc {
+	if l == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ok, retryAfter := l.Allow(rateLimitKey(r))
+		if ok {
+			next(w, r)
+			return
+		}
+		telemetry.RateLimitedRequests.WithLabelValues(class).Inc()
+		seconds := max(1, int(math.Ceil(retryAfter.Seconds())))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		model.WriteProblem(w, http.StatusTooManyRequests,
+			fmt.Sprintf("Rate limit for %s requests exceeded. Retry in %d seconds.", class, seconds))
+	}
+}
+
+// rateLimitKey identifies the client of r for rate limiting.
+func rateLimitKey(r *http.Request) string {
+	if p, ok := auth.PrincipalFrom(r.Context()); ok && !p.Anonymous() {
+		return p.Method + ":" + p.Subject
+	}
+	return clientKey(r)
+}
+
+// clientKey identifies the client IP of r, or its /64 for IPv6.
+func clientKey(r *http.Request) string {
+	host := clientIP(r)
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return "ip:" + host
+	}
+	addr = addr.Unmap()
+	if addr.Is6() {
+		prefix, _ := addr.Prefix(64)
+		return "ip:" + prefix.String()
+	}
+	return "ip:" + addr.String()
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/auth"
+	"github.com/pobradovic08/route-beacon/internal/ratelimit"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32")}
+	tests := []struct {
+		name   string
+		remote string
+		xff    string
+		want   string
+	}{
+		{"untrusted peer ignores header", "198.51.100.7:4000", "203.0.113.5", "198.51.100.7:4000"},
+		{"trusted proxy", "10.1.1.1:4000", "203.0.113.5", "203.0.113.5:0"},
+		{"chain of proxies", "10.1.1.1:4000", "203.0.113.5, 192.0.2.1", "203.0.113.5:0"},
+		{"spoofed leftmost entry", "10.1.1.1:4000", "1.2.3.4, 203.0.113.5", "203.0.113.5:0"},
+		{"no header", "10.1.1.1:4000", "", "10.1.1.1:4000"},
+		{"garbage entry", "10.1.1.1:4000", "junk", "10.1.1.1:4000"},
+		{"ipv6 client", "10.1.1.1:4000", "2001:db8::1", "[2001:db8::1]:0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := RateLimit("expensive", ratelimit.New(1, 2), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	do := func(remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	for range 2 {
+		if w := do("198.51.100.7:1000"); w.Code != http.StatusOK {
+			t.Fatalf("expected 200 within burst, got %d", w.Code)
+		}
+	}
+	w := do("198.51.100.7:2000")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected Retry-After 60, got %q", got)
+	}
+	var resp problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	if resp.Status != http.StatusTooManyRequests {
+		t.Errorf("expected problem status 429, got %d", resp.Status)
+	}
+
+	if w := do("198.51.100.8:1000"); w.Code != http.StatusOK {
+		t.Errorf("expected other client to be unaffected, got %d", w.Code)
+	}
+}
+
+func TestRateLimitNilLimiter(t *testing.T) {
+	called := false
+	h := RateLimit("lookup", nil, func(w http.ResponseWriter, r *http.Request) { called = true })
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if !called {
+		t.Fatal("expected pass-through without limiter")
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		remote    string
+		principal *auth.Principal
+		want      string
+	}{
+		{"ipv4", "198.51.100.7:1000", nil, "ip:198.51.100.7"},
+		{"ipv6 per /64", "[2001:db8:1:2:aaaa::1]:1000", nil, "ip:2001:db8:1:2::/64"},
+		{"mapped ipv4", "[::ffff:198.51.100.7]:1000", nil, "ip:198.51.100.7"},
+		{"anonymous principal", "198.51.100.7:1000", &auth.Principal{Method: auth.MethodAnonymous}, "ip:198.51.100.7"},
+		{"api key", "198.51.100.7:1000", &auth.Principal{Subject: "noc", Method: auth.MethodAPIKey}, "api_key:noc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), *tt.principal))
+			}
+			if got := rateLimitKey(req); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,27 +1,29 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/pobradovic08/route-beacon/internal/model"
-	"github.com/pobradovic08/route-beacon/internal/store"
 )
 
 // HandleListRouters returns all monitored routers.
-func HandleListRouters(db *store.DB) http.HandlerFunc {
+func HandleListRouters(db Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		routers, err := db.ListRouters(r.Context())
 		if err != nil {
 			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query routers.")
 			return
 		}
-		json.NewEncoder(w).Encode(model.RouterListResponse{Data: routers})
+		seen := make([]*string, len(routers))
+		for i := range routers {
+			seen[i] = &routers[i].LastSeen
+		}
+		writeCached(w, r, model.RouterListResponse{Data: routers}, liveMaxAge, latest(seen...))
 	}
 }
 
 // HandleGetRouter returns a single router by ID with routing statistics.
-func HandleGetRouter(db *store.DB) http.HandlerFunc {
+func HandleGetRouter(db Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		routerID := r.PathValue("routerId")
 		router, err := db.GetRouterDetail(r.Context(), routerID)
@@ -33,8 +35,8 @@ func HandleGetRouter(db *store.DB) http.HandlerFunc {
 			model.WriteProblem(w, http.StatusNotFound, "Router '"+routerID+"' does not exist.")
 			return
 		}
-		json.NewEncoder(w).Encode(struct {
+		writeCached(w, r, struct {
 			Data *model.RouterDetail `json:"data"`
-		}{Data: router})
+		}{Data: router}, liveMaxAge, latest(&router.LastSeen, router.SyncUpdatedAt))
 	}
 }
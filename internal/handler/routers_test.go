@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+// newTestStore returns an in-memory store with one online router holding
+// a covering route and a more-specific with two paths. Path 0 of the
+// more-specific was announced, refreshed and updated the day before.
+func newTestStore(t *testing.T) *store.Memory {
+	t.Helper()
+	seen := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	eor := true
+	path0 := int64(0)
+	med := func(n int) store.FixtureAttrs { return store.FixtureAttrs{MED: &n} }
+	m, err := store.NewMemory(store.Fixtures{
+		Routers: []store.FixtureRouter{{ID: "r1", FirstSeen: seen, LastSeen: seen, EORReceived: &eor}},
+		Routes: []store.FixtureRoute{
+			{RouterID: "r1", Prefix: "10.0.0.0/8", FirstSeen: seen, UpdatedAt: seen},
+			{RouterID: "r1", Prefix: "10.1.0.0/16", PathID: 0, FirstSeen: seen, UpdatedAt: seen},
+			{RouterID: "r1", Prefix: "10.1.0.0/16", PathID: 1, FirstSeen: seen, UpdatedAt: seen},
+		},
+		Events: []store.FixtureEvent{
+			{Time: seen.Add(-16 * time.Hour), RouterID: "r1", Action: "announce", Prefix: "10.1.0.0/16", PathID: &path0, FixtureAttrs: med(10)},
+			{Time: seen.Add(-14 * time.Hour), RouterID: "r1", Action: "announce", Prefix: "10.1.0.0/16", PathID: &path0, FixtureAttrs: med(10)},
+			{Time: seen.Add(-12 * time.Hour), RouterID: "r1", Action: "announce", Prefix: "10.1.0.0/16", PathID: &path0, FixtureAttrs: med(20)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewMemory: %v", err)
+	}
+	return m
+}
+
+func TestListRoutersFromStore(t *testing.T) {
+	handler := HandleListRouters(newTestStore(t))
+
+	req := httptest.NewRequest("GET", "/api/v1/routers", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp model.RouterListResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].ID != "r1" || resp.Data[0].Status != "up" {
+		t.Fatalf("unexpected routers: %+v", resp.Data)
+	}
+	if w.Header().Get("Last-Modified") != "Thu, 01 Jan 2026 00:00:00 GMT" {
+		t.Fatalf("unexpected Last-Modified %q", w.Header().Get("Last-Modified"))
+	}
+}
+
+func TestGetRouterNotFound(t *testing.T) {
+	handler := HandleGetRouter(newTestStore(t))
+
+	req := httptest.NewRequest("GET", "/api/v1/routers/r9", nil)
+	req.SetPathValue("routerId", "r9")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestLookupFromStore(t *testing.T) {
+	m := newTestStore(t)
+	handler := HandleLookupRoutes(m, m, config.DefaultLimits())
+
+	tests := []struct {
+		query      string
+		wantPrefix string
+		wantRoutes int
+	}{
+		{"prefix=10.1.2.3", "10.1.0.0/16", 2},
+		{"prefix=10.2.0.1", "10.0.0.0/8", 1},
+		{"prefix=10.1.0.0/16&match_type=exact", "10.1.0.0/16", 2},
+		{"prefix=10.0.0.0/8&match_type=subnets", "10.0.0.0/8", 3},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/routers/r1/routes/lookup?"+tt.query, nil)
+		req.SetPathValue("routerId", "r1")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", tt.query, w.Code)
+		}
+		var resp model.RouteLookupResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: decode error: %v", tt.query, err)
+		}
+		if resp.Prefix != tt.wantPrefix || len(resp.Routes) != tt.wantRoutes {
+			t.Errorf("%s: got prefix %s with %d routes", tt.query, resp.Prefix, len(resp.Routes))
+		}
+	}
+}
+
+func TestHealthFromStore(t *testing.T) {
+	handler := HandleGetHealth(newTestStore(t), time.Now())
+
+	req := httptest.NewRequest("GET", "/api/v1/health", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	var resp model.HealthResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if w.Code != http.StatusOK || resp.Status != "healthy" || resp.TotalRoutes != 3 {
+		t.Fatalf("unexpected health: %d %+v", w.Code, resp)
+	}
+}
+
+func TestLookupTextFormats(t *testing.T) {
+	m := newTestStore(t)
+	handler := HandleLookupRoutes(m, m, config.DefaultLimits())
+
+	tests := []struct {
+		query, accept string
+		wantType      string
+		wantPrefix    string
+	}{
+		{"prefix=10.1.2.3", "", "application/json", "{"},
+		{"prefix=10.1.2.3", "text/plain", "text/plain; charset=utf-8", "BGP routing table entry for 10.1.0.0/16 on r1\n"},
+		{"prefix=10.1.2.3", "application/json, text/plain", "application/json", "{"},
+		{"prefix=10.1.2.3&format=json", "text/plain", "application/json", "{"},
+		{"prefix=10.1.2.3&format=iosxr", "", "text/plain; charset=utf-8", "BGP routing table entry for 10.1.0.0/16\nPaths: (2 available, best #1)\n"},
+		{"prefix=10.1.2.3&format=junos", "", "text/plain; charset=utf-8", "\ninet.0: 1 destinations, 2 routes\n"},
+		{"prefix=10.1.2.3&format=bird", "", "text/plain; charset=utf-8", "Table master4:\n"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/routers/r1/routes/lookup?"+tt.query, nil)
+		req.SetPathValue("routerId", "r1")
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		w.Header().Set("Content-Type", "application/json")
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", tt.query, w.Code)
+		}
+		if got := w.Header().Get("Content-Type"); got != tt.wantType {
+			t.Errorf("%s (Accept %q): expected Content-Type %q, got %q", tt.query, tt.accept, tt.wantType, got)
+		}
+		if !strings.HasPrefix(w.Body.String(), tt.wantPrefix) {
+			t.Errorf("%s (Accept %q): unexpected body:\n%s", tt.query, tt.accept, w.Body.String())
+		}
+		if w.Header().Get("ETag") == "" || !strings.Contains(strings.Join(w.Header().Values("Vary"), ","), "Accept") {
+			t.Errorf("%s: missing ETag or Vary: Accept", tt.query)
+		}
+		if got := w.Header().Get("Last-Modified"); got != "" {
+			t.Errorf("%s: unexpected Last-Modified %q", tt.query, got)
+		}
+	}
+}
+
+// TestLookupIgnoresIfModifiedSince checks that lookups are only validated
+// by ETag: a withdrawn path does not advance the updated_at of the routes
+// left, so a date would answer 304 with a stale body.
+func TestLookupIgnoresIfModifiedSince(t *testing.T) {
+	m := newTestStore(t)
+	handler := HandleLookupRoutes(m, m, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET", "/api/v1/routers/r1/routes/lookup?prefix=10.1.2.3&format=iosxr", nil)
+	req.SetPathValue("routerId", "r1")
+	req.Header.Set("If-Modified-Since", "Thu, 01 Jan 2026 00:00:00 GMT")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestLookupRejectsUnknownFormat(t *testing.T) {
+	handler := HandleLookupRoutes(nil, nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET", "/api/v1/routers/r1/routes/lookup?prefix=10.0.0.1&format=eos", nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) != 1 || prob.InvalidParams[0].Name != "format" {
+		t.Fatalf("expected invalid param 'format', got %+v", prob.InvalidParams)
+	}
+}
@@ -1,21 +1,39 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/pobradovic08/route-beacon/internal/config"
 	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/render"
 	"github.com/pobradovic08/route-beacon/internal/store"
 )
 
+// RouteLookup answers live route lookups. It is implemented by *store.DB
+// and by the in-memory RIB cache, which falls back to the database.
+type RouteLookup interface {
+	ExactLookup(ctx context.Context, routerID, prefix string) ([]model.Route, error)
+	LPMLookup(ctx context.Context, routerID, ip string) ([]model.Route, error)
+	SubnetLookup(ctx context.Context, routerID, prefix string, limit int) ([]model.Route, bool, error)
+}
+
 // HandleLookupRoutes handles GET /api/v1/routers/{routerId}/routes/lookup.
-func HandleLookupRoutes(db *store.DB) http.HandlerFunc {
+// Lookups without an at parameter are answered by routes. The response is
+// JSON, or a text rendering selected by the format parameter or by
+// Accept: text/plain.
+func HandleLookupRoutes(db Store, routes RouteLookup, limits config.Limits) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		routerID := r.PathValue("routerId")
 		prefix := r.URL.Query().Get("prefix")
 		matchType := r.URL.Query().Get("match_type")
+		w.Header().Add("Vary", "Accept")
 
 		if prefix == "" {
 			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
@@ -33,15 +51,15 @@ func HandleLookupRoutes(db *store.DB) http.HandlerFunc {
 			}
 		}
 
-		if matchType != "exact" && matchType != "longest" {
+		if matchType != "exact" && matchType != "longest" && matchType != "subnets" {
 			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
 				"Request validation failed.",
-				[]model.InvalidParam{{Name: "match_type", Reason: "Must be 'exact' or 'longest'."}})
+				[]model.InvalidParam{{Name: "match_type", Reason: "Must be 'exact', 'longest' or 'subnets'."}})
 			return
 		}
 
 		// Validate prefix format
-		if matchType == "exact" {
+		if matchType != "longest" {
 			_, _, err := net.ParseCIDR(prefix)
 			if err != nil {
 				model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
@@ -59,6 +77,22 @@ func HandleLookupRoutes(db *store.DB) http.HandlerFunc {
 			}
 		}
 
+		renderer, ok := parseLookupFormat(w, r)
+		if !ok {
+			return
+		}
+
+		at, from, ok := parseAtParam(w, r, time.Duration(limits.MaxHistoryRange))
+		if !ok {
+			return
+		}
+		if at != nil && matchType == "subnets" {
+			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "at", Reason: "Not supported with match_type 'subnets'."}})
+			return
+		}
+
 		// Check router exists
 		routerSummary, routerStatus, err := db.GetRouterSummary(r.Context(), routerID)
 		if err != nil {
@@ -71,11 +105,21 @@ func HandleLookupRoutes(db *store.DB) http.HandlerFunc {
 		}
 
 		// Execute lookup
-		var routes []model.Route
-		if matchType == "exact" {
-			routes, err = db.ExactLookup(r.Context(), routerID, prefix)
-		} else {
-			routes, err = db.LPMLookup(r.Context(), routerID, prefix)
+		var (
+			found     []model.Route
+			truncated bool
+		)
+		switch {
+		case matchType == "subnets":
+			found, truncated, err = routes.SubnetLookup(r.Context(), routerID, prefix, min(1000, limits.MaxPageSize))
+		case matchType == "exact" && at != nil:
+			found, err = db.ExactLookupAt(r.Context(), routerID, prefix, *at, from)
+		case matchType == "exact":
+			found, err = routes.ExactLookup(r.Context(), routerID, prefix)
+		case at != nil:
+			found, err = db.LPMLookupAt(r.Context(), routerID, prefix, *at, from)
+		default:
+			found, err = routes.LPMLookup(r.Context(), routerID, prefix)
 		}
 		if err != nil {
 			model.WriteProblem(w, http.StatusInternalServerError, "Route lookup failed.")
@@ -84,20 +128,153 @@ func HandleLookupRoutes(db *store.DB) http.HandlerFunc {
 
 		// Determine the matched prefix for the response
 		responsePrefix := prefix
-		if matchType == "longest" && len(routes) > 0 {
-			responsePrefix = routes[0].Prefix
+		if matchType == "longest" && len(found) > 0 {
+			responsePrefix = found[0].Prefix
+		}
+
+		// Live route ages are computed at the start of the max-age window, so
+		// the rendered text, and with it the ETag, is stable for that long.
+		lookup := render.Lookup{Prefix: responsePrefix, Router: *routerSummary, Routes: found, Now: time.Now().Truncate(liveMaxAge)}
+		maxAge := liveMaxAge
+		if at != nil {
+			lookup.Now = *at
+			maxAge = maxAgeFor(*at)
+		}
+		// Lookups carry no Last-Modified: withdrawing a path removes a row
+		// without advancing any updated_at, so only the body-hash ETag
+		// reliably changes with the result.
+		if renderer != nil {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			writeCachedBody(w, r, []byte(render.String(renderer, lookup)), maxAge, time.Time{})
+			return
 		}
 
 		resp := model.RouteLookupResponse{
 			Prefix:    responsePrefix,
 			Router:    *routerSummary,
-			Routes:    routes,
-			PlainText: store.GeneratePlainText(responsePrefix, routerID, routes),
+			Routes:    found,
+			PlainText: render.String(render.Plain, lookup),
 			Meta: model.RouteLookupMeta{
 				MatchType:    matchType,
 				RouterStatus: routerStatus,
+				Truncated:    truncated,
 			},
 		}
+		if at != nil {
+			v := model.FormatTime(*at)
+			resp.Meta.At = &v
+		}
+
+		writeCached(w, r, resp, maxAge, time.Time{})
+	}
+}
+
+// parseLookupFormat returns the text renderer a lookup response is
+// requested in, or nil for JSON. The format parameter is "json" or the
+// name of a renderer; without it, an Accept header preferring text/plain
+// selects the default renderer. It writes a problem response and returns
+// ok=false for unknown formats.
+func parseLookupFormat(w http.ResponseWriter, r *http.Request) (render.Renderer, bool) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "json":
+		return nil, true
+	case "":
+		if !acceptsTextFirst(r.Header.Get("Accept")) {
+			return nil, true
+		}
+		format = render.Default
+	}
+	renderer, found := render.Get(format)
+	if !found {
+		model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+			"Request validation failed.",
+			[]model.InvalidParam{{Name: "format", Reason: "Must be 'json' or one of '" + strings.Join(render.Names(), "', '") + "'."}})
+		return nil, false
+	}
+	return renderer, true
+}
+
+// acceptsTextFirst reports whether text/plain comes before JSON among the
+// media ranges of an Accept header. Quality values are not weighed.
+func acceptsTextFirst(accept string) bool {
+	for _, mr := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(mr, ";")
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "text/plain", "text/*":
+			return true
+		case "application/json", "application/*", "*/*":
+			return false
+		}
+	}
+	return false
+}
+
+// HandleListRoutes handles GET /api/v1/routers/{routerId}/routes.
+func HandleListRoutes(db *store.DB, limits config.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routerID := r.PathValue("routerId")
+		q := store.RouteListQuery{RouterID: routerID}
+
+		if v := r.URL.Query().Get("table"); v != "" {
+			q.Table = &v
+		}
+
+		if v := r.URL.Query().Get("afi"); v != "" {
+			if v != "4" && v != "6" {
+				model.WriteProblemWithParams(w, http.StatusBadRequest,
+					"Request validation failed.",
+					[]model.InvalidParam{{Name: "afi", Reason: "Must be 4 or 6."}})
+				return
+			}
+			afi, _ := strconv.Atoi(v)
+			q.AFI = &afi
+		}
+
+		var ok bool
+		if q.At, q.From, ok = parseAtParam(w, r, time.Duration(limits.MaxHistoryRange)); !ok {
+			return
+		}
+		if q.Limit, ok = parseIntParam(w, r, "limit", min(100, limits.MaxPageSize), 1, limits.MaxPageSize); !ok {
+			return
+		}
+		if q.Offset, ok = parseIntParam(w, r, "offset", 0, 0, math.MaxInt32); !ok {
+			return
+		}
+
+		// Check router exists
+		routerSummary, _, err := db.GetRouterSummary(r.Context(), routerID)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+			return
+		}
+		if routerSummary == nil {
+			model.WriteProblem(w, http.StatusNotFound, "Router '"+routerID+"' does not exist.")
+			return
+		}
+
+		routes, err := db.ListRoutes(r.Context(), q)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query routes.")
+			return
+		}
+
+		hasMore := len(routes) > q.Limit
+		if hasMore {
+			routes = routes[:q.Limit]
+		}
+
+		resp := model.RouteListResponse{
+			RouterID: routerID,
+			Routes:   routes,
+			Limit:    q.Limit,
+			Offset:   q.Offset,
+			HasMore:  hasMore,
+		}
+		if q.At != nil {
+			v := model.FormatTime(*q.At)
+			resp.At = &v
+		}
 
 		json.NewEncoder(w).Encode(resp)
 	}
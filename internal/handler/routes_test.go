@@ -5,10 +5,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
 )
 
 func TestLookupRejectsMissingPrefix(t *testing.T) {
-	handler := HandleLookupRoutes(nil)
+	handler := HandleLookupRoutes(nil, nil, config.DefaultLimits())
 
 	req := httptest.NewRequest("GET",
 		"/api/v1/routers/r1/routes/lookup",
@@ -24,7 +27,7 @@ func TestLookupRejectsMissingPrefix(t *testing.T) {
 }
 
 func TestLookupRejectsInvalidMatchType(t *testing.T) {
-	handler := HandleLookupRoutes(nil)
+	handler := HandleLookupRoutes(nil, nil, config.DefaultLimits())
 
 	req := httptest.NewRequest("GET",
 		"/api/v1/routers/r1/routes/lookup?prefix=10.0.0.0/24&match_type=invalid",
@@ -40,7 +43,7 @@ func TestLookupRejectsInvalidMatchType(t *testing.T) {
 }
 
 func TestLookupRejectsInvalidCIDR(t *testing.T) {
-	handler := HandleLookupRoutes(nil)
+	handler := HandleLookupRoutes(nil, nil, config.DefaultLimits())
 
 	req := httptest.NewRequest("GET",
 		"/api/v1/routers/r1/routes/lookup?prefix=not-a-cidr/24&match_type=exact",
@@ -67,7 +70,7 @@ func TestLookupRejectsInvalidCIDR(t *testing.T) {
 }
 
 func TestLookupRejectsInvalidIPForLongest(t *testing.T) {
-	handler := HandleLookupRoutes(nil)
+	handler := HandleLookupRoutes(nil, nil, config.DefaultLimits())
 
 	req := httptest.NewRequest("GET",
 		"/api/v1/routers/r1/routes/lookup?prefix=not-an-ip&match_type=longest",
@@ -82,3 +85,123 @@ func TestLookupRejectsInvalidIPForLongest(t *testing.T) {
 	}
 }
 
+func TestLookupRejectsSubnetsWithAddress(t *testing.T) {
+	handler := HandleLookupRoutes(nil, nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/lookup?prefix=10.0.0.1&match_type=subnets",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+}
+
+func TestLookupRejectsSubnetsWithAt(t *testing.T) {
+	handler := HandleLookupRoutes(nil, nil, config.DefaultLimits())
+
+	at := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/lookup?prefix=10.0.0.0/8&match_type=subnets&at="+at,
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) == 0 || prob.InvalidParams[0].Name != "at" {
+		t.Fatalf("expected invalid param 'at', got %+v", prob.InvalidParams)
+	}
+}
+
+func TestLookupRejectsFutureAt(t *testing.T) {
+	handler := HandleLookupRoutes(nil, nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/lookup?prefix=10.0.0.0/24&at=2999-01-01T00:00:00Z",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestLookupRejectsAtBeyondHistoryRange(t *testing.T) {
+	limits := config.DefaultLimits()
+	limits.MaxHistoryRange = config.Duration(24 * time.Hour)
+	handler := HandleLookupRoutes(newTestStore(t), nil, limits)
+
+	at := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	req := httptest.NewRequest("GET", "/api/v1/routers/r1/routes/lookup?prefix=10.0.0.0/8&at="+at, nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) == 0 || prob.InvalidParams[0].Name != "at" {
+		t.Fatalf("expected invalid param 'at', got %+v", prob.InvalidParams)
+	}
+}
+
+func TestListRoutesRejectsInvalidAt(t *testing.T) {
+	handler := HandleListRoutes(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes?at=yesterday",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	var prob problemResponse
+	if err := json.NewDecoder(w.Body).Decode(&prob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(prob.InvalidParams) == 0 || prob.InvalidParams[0].Name != "at" {
+		t.Fatalf("expected invalid param 'at', got %+v", prob.InvalidParams)
+	}
+}
+
+func TestListRoutesRejectsInvalidAFI(t *testing.T) {
+	handler := HandleListRoutes(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes?afi=8",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
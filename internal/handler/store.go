@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+// Store is the storage behind the router, route lookup, route history and
+// health endpoints. *store.DB implements it on PostgreSQL and *store.Memory
+// on fixtures, for demo mode and tests.
+type Store interface {
+	RouteLookup
+	ListRouters(ctx context.Context) ([]model.Router, error)
+	GetRouterDetail(ctx context.Context, routerID string) (*model.RouterDetail, error)
+	GetRouterSummary(ctx context.Context, routerID string) (*model.RouterSummary, string, error)
+	ExactLookupAt(ctx context.Context, routerID, prefix string, at, from time.Time) ([]model.Route, error)
+	LPMLookupAt(ctx context.Context, routerID, ip string, at, from time.Time) ([]model.Route, error)
+	GetRouteHistory(ctx context.Context, q store.HistoryQuery) ([]model.RouteEvent, *store.HistoryCursor, error)
+	GetRouteIntervals(ctx context.Context, q store.HistoryQuery) ([]model.RouteInterval, error)
+	GetHistoryBuckets(ctx context.Context, q store.HistoryQuery, resolution string) ([]model.HistoryBucket, bool, error)
+	GetHealthSummary(ctx context.Context) (*store.HealthSummary, error)
+}
+
+var (
+	_ Store = (*store.DB)(nil)
+	_ Store = (*store.Memory)(nil)
+)
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+// HandleGetPrefixTimeline handles GET /api/v1/routers/{routerId}/routes/timeline.
+func HandleGetPrefixTimeline(db *store.DB, limits config.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routerID := r.PathValue("routerId")
+
+		prefix := r.URL.Query().Get("prefix")
+		if prefix == "" {
+			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "prefix", Reason: "prefix query parameter is required."}})
+			return
+		}
+
+		// Validate prefix is CIDR
+		if _, _, err := net.ParseCIDR(prefix); err != nil {
+			model.WriteProblemWithParams(w, http.StatusUnprocessableEntity,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "prefix", Reason: "Not a valid IPv4 or IPv6 prefix."}})
+			return
+		}
+
+		from, to, ok := parseTimeRange(w, r, time.Duration(limits.MaxHistoryRange))
+		if !ok {
+			return
+		}
+
+		// Check router exists
+		routerSummary, _, err := db.GetRouterSummary(r.Context(), routerID)
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query router.")
+			return
+		}
+		if routerSummary == nil {
+			model.WriteProblem(w, http.StatusNotFound, "Router '"+routerID+"' does not exist.")
+			return
+		}
+
+		resp, err := db.GetPrefixTimeline(r.Context(), routerID, prefix, from, to,
+			from.Add(-time.Duration(limits.MaxHistoryRange)))
+		if errors.Is(err, store.ErrTooManyEvents) {
+			model.WriteProblemWithParams(w, http.StatusBadRequest,
+				"Request validation failed.",
+				[]model.InvalidParam{{Name: "from", Reason: "Too many events in range; narrow the time range."}})
+			return
+		}
+		if err != nil {
+			model.WriteProblem(w, http.StatusInternalServerError, "Failed to query prefix timeline.")
+			return
+		}
+		resp.RouterID = routerID
+		resp.Prefix = prefix
+
+		json.NewEncoder(w).Encode(resp)
+	}
+}
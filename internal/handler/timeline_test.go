@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/config"
+)
+
+func TestTimelineRejectsMissingPrefix(t *testing.T) {
+	handler := HandleGetPrefixTimeline(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET", "/api/v1/routers/r1/routes/timeline", nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+}
+
+func TestTimelineRejectsReversedRange(t *testing.T) {
+	handler := HandleGetPrefixTimeline(nil, config.DefaultLimits())
+
+	req := httptest.NewRequest("GET",
+		"/api/v1/routers/r1/routes/timeline?prefix=10.0.0.0/24&from=2025-01-02T00:00:00Z&to=2025-01-01T00:00:00Z",
+		nil)
+	req.SetPathValue("routerId", "r1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestNewJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "warn")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger.Info("dropped")
+	logger.Warn("kept", slog.String("router_id", "r1"))
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("expected one JSON record, got %q: %v", buf.String(), err)
+	}
+	if rec["msg"] != "kept" || rec["router_id"] != "r1" {
+		t.Fatalf("unexpected record: %v", rec)
+	}
+}
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", "info"); err == nil {
+		t.Fatal("expected error for invalid format")
+	}
+	if _, err := New(&bytes.Buffer{}, "text", "verbose"); err == nil {
+		t.Fatal("expected error for invalid level")
+	}
+}
@@ -0,0 +1,191 @@
+// Package metrics exports RIB and ingest metrics in the Prometheus format.
+// Values are refreshed by a background collector so that scrapes never hit
+// the database.
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+const namespace = "routebeacon"
+
+// ingestDelay holds back the end of each event counting window so that rows
+// from inserts still in flight are counted in the next window rather than
+// skipped.
+const ingestDelay = 10 * time.Second
+
+var tableLabels = []string{"router_id", "table", "afi"}
+
+// Collector periodically queries the database and keeps the exported
+// metrics up to date.
+type Collector struct {
+	db       *store.DB
+	interval time.Duration
+	registry *prometheus.Registry
+
+	routes         *prometheus.GaugeVec
+	uniquePrefixes *prometheus.GaugeVec
+	eorReceived    *prometheus.GaugeVec
+	sessionStart   *prometheus.GaugeVec
+	parseLag       *prometheus.GaugeVec
+	events         *prometheus.CounterVec
+
+	collectDuration prometheus.Gauge
+	lastSuccess     prometheus.Gauge
+	collectErrors   prometheus.Counter
+
+	// eventsUntil is the end of the last counted event window.
+	eventsUntil time.Time
+}
+
+// NewCollector creates a collector that refreshes metrics every interval.
+func NewCollector(db *store.DB, interval time.Duration) *Collector {
+	c := &Collector{
+		db:       db,
+		interval: interval,
+		registry: prometheus.NewRegistry(),
+
+		routes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "rib_routes",
+			Help: "Number of paths in current_routes.",
+		}, tableLabels),
+		uniquePrefixes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "rib_unique_prefixes",
+			Help: "Number of distinct prefixes in current_routes.",
+		}, tableLabels),
+		eorReceived: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "rib_eor_received",
+			Help: "1 if End-of-RIB was received for the table, 0 otherwise.",
+		}, tableLabels),
+		sessionStart: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "rib_session_start_timestamp_seconds",
+			Help: "Start of the current BMP session as a Unix timestamp.",
+		}, tableLabels),
+		parseLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "rib_last_parsed_message_lag_seconds",
+			Help: "Seconds since the last parsed BMP message, as of the last collection.",
+		}, tableLabels),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "route_events_total",
+			Help: "Route events ingested since the API started.",
+		}, append(tableLabels, "action")),
+
+		collectDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "metrics_collection_duration_seconds",
+			Help: "Duration of the last metrics collection.",
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "metrics_last_success_timestamp_seconds",
+			Help: "Unix timestamp of the last successful metrics collection.",
+		}),
+		collectErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "metrics_collection_errors_total",
+			Help: "Failed metrics collections.",
+		}),
+	}
+
+	c.registry.MustRegister(
+		c.routes, c.uniquePrefixes, c.eorReceived, c.sessionStart, c.parseLag, c.events,
+		c.collectDuration, c.lastSuccess, c.collectErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return c
+}
+
+// MustRegister adds metrics maintained elsewhere, such as request latency
+// histograms, to the exported registry.
+func (c *Collector) MustRegister(cs ...prometheus.Collector) {
+	c.registry.MustRegister(cs...)
+}
+
+// Handler returns the HTTP handler serving the metrics.
+func (c *Collector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+// Run collects metrics immediately and then every interval until ctx is
+// cancelled. Failed collections are logged and keep the previous values.
+func (c *Collector) Run(ctx context.Context) {
+	c.eventsUntil = time.Now().Add(-ingestDelay)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		if err := c.collect(ctx); err != nil && ctx.Err() == nil {
+			c.collectErrors.Inc()
+			slog.Error("metrics collection failed", slog.Any("error", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect refreshes all database-derived metrics.
+func (c *Collector) collect(ctx context.Context) error {
+	start := time.Now()
+
+	stats, err := c.db.GetRIBTableStats(ctx)
+	if err != nil {
+		return err
+	}
+	c.setRIBStats(stats, start)
+
+	until := start.Add(-ingestDelay)
+	counts, err := c.db.CountRouteEvents(ctx, c.eventsUntil, until)
+	if err != nil {
+		return err
+	}
+	c.addEventCounts(counts)
+	c.eventsUntil = until
+
+	c.collectDuration.Set(time.Since(start).Seconds())
+	c.lastSuccess.Set(float64(start.Unix()))
+	return nil
+}
+
+// setRIBStats replaces the per-table gauges with stats. Tables that
+// disappeared since the previous collection are dropped.
+func (c *Collector) setRIBStats(stats []store.RIBTableStats, now time.Time) {
+	for _, g := range []*prometheus.GaugeVec{c.routes, c.uniquePrefixes, c.eorReceived, c.sessionStart, c.parseLag} {
+		g.Reset()
+	}
+	for _, s := range stats {
+		labels := prometheus.Labels{"router_id": s.RouterID, "table": s.Table, "afi": strconv.Itoa(s.AFI)}
+		c.routes.With(labels).Set(float64(s.Routes))
+		c.uniquePrefixes.With(labels).Set(float64(s.UniquePrefixes))
+		eor := 0.0
+		if s.EORSeen {
+			eor = 1
+		}
+		c.eorReceived.With(labels).Set(eor)
+		if s.SessionStart != nil {
+			c.sessionStart.With(labels).Set(float64(s.SessionStart.Unix()))
+		}
+		if s.LastParsed != nil {
+			c.parseLag.With(labels).Set(max(now.Sub(*s.LastParsed).Seconds(), 0))
+		}
+	}
+}
+
+// addEventCounts adds the events of one counting window to the counters.
+func (c *Collector) addEventCounts(counts []store.EventCount) {
+	for _, e := range counts {
+		c.events.With(prometheus.Labels{
+			"router_id": e.RouterID, "table": e.Table, "afi": strconv.Itoa(e.AFI), "action": e.Action,
+		}).Add(float64(e.Count))
+	}
+}
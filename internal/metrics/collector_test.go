@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+func TestSetRIBStats(t *testing.T) {
+	c := NewCollector(nil, time.Minute)
+	now := time.Date(2025, 1, 1, 0, 1, 0, 0, time.UTC)
+	parsed := now.Add(-30 * time.Second)
+
+	c.setRIBStats([]store.RIBTableStats{
+		{RouterID: "r1", Table: "global", AFI: 4, Routes: 10, UniquePrefixes: 8, EORSeen: true, LastParsed: &parsed},
+		{RouterID: "r2", Table: "global", AFI: 6, Routes: 3, UniquePrefixes: 3},
+	}, now)
+
+	if v := testutil.ToFloat64(c.routes.WithLabelValues("r1", "global", "4")); v != 10 {
+		t.Fatalf("expected 10 routes, got %f", v)
+	}
+	if v := testutil.ToFloat64(c.eorReceived.WithLabelValues("r1", "global", "4")); v != 1 {
+		t.Fatalf("expected EOR 1, got %f", v)
+	}
+	if v := testutil.ToFloat64(c.parseLag.WithLabelValues("r1", "global", "4")); v != 30 {
+		t.Fatalf("expected 30s lag, got %f", v)
+	}
+	// Missing timestamps produce no series.
+	if n := testutil.CollectAndCount(c.sessionStart); n != 0 {
+		t.Fatalf("expected no session start series, got %d", n)
+	}
+
+	// Tables that disappear are dropped on the next collection.
+	c.setRIBStats([]store.RIBTableStats{{RouterID: "r1", Table: "global", AFI: 4}}, now)
+	if n := testutil.CollectAndCount(c.routes); n != 1 {
+		t.Fatalf("expected 1 routes series, got %d", n)
+	}
+}
+
+func TestAddEventCounts(t *testing.T) {
+	c := NewCollector(nil, time.Minute)
+
+	c.addEventCounts([]store.EventCount{{RouterID: "r1", Table: "global", AFI: 4, Action: "announce", Count: 5}})
+	c.addEventCounts([]store.EventCount{{RouterID: "r1", Table: "global", AFI: 4, Action: "announce", Count: 2}})
+
+	if v := testutil.ToFloat64(c.events.WithLabelValues("r1", "global", "4", "announce")); v != 7 {
+		t.Fatalf("expected 7 events, got %f", v)
+	}
+}
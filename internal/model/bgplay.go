@@ -0,0 +1,59 @@
+package model
+
+// BGPlayTimeFormat is the timestamp layout used by the BGPlay data format:
+// UTC without a zone designator.
+const BGPlayTimeFormat = "2006-01-02T15:04:05"
+
+// BGPlayNode is an AS appearing in at least one path.
+type BGPlayNode struct {
+	ASNumber int64  `json:"as_number"`
+	Owner    string `json:"owner"`
+}
+
+// BGPlaySource is a vantage point. Every (router, path ID) pair is a separate
+// source so that Add-Path routes are shown as independent paths.
+type BGPlaySource struct {
+	ID       string `json:"id"`
+	ASNumber *int64 `json:"as_number"`
+	IP       string `json:"ip"`
+	RRC      string `json:"rrc"`
+}
+
+// BGPlayTarget is a prefix whose paths are visualised.
+type BGPlayTarget struct {
+	Prefix string `json:"prefix"`
+}
+
+// BGPlayRoute is the path of one source towards one target. Path and
+// Community are omitted for withdrawals.
+type BGPlayRoute struct {
+	TargetPrefix string   `json:"target_prefix"`
+	SourceID     string   `json:"source_id"`
+	Path         []int64  `json:"path,omitempty"`
+	Community    []string `json:"community,omitempty"`
+}
+
+// BGPlayEvent is an announcement ("A") or withdrawal ("W") of a route.
+type BGPlayEvent struct {
+	Attrs     BGPlayRoute `json:"attrs"`
+	Timestamp string      `json:"timestamp"`
+	Type      string      `json:"type"`
+}
+
+// BGPlayData holds the initial state at the start of the query window and
+// the events within it, in the layout consumed by BGPlay.
+type BGPlayData struct {
+	Resource       string         `json:"resource"`
+	QueryStartTime string         `json:"query_starttime"`
+	QueryEndTime   string         `json:"query_endtime"`
+	Nodes          []BGPlayNode   `json:"nodes"`
+	Sources        []BGPlaySource `json:"sources"`
+	Targets        []BGPlayTarget `json:"targets"`
+	InitialState   []BGPlayRoute  `json:"initial_state"`
+	Events         []BGPlayEvent  `json:"events"`
+}
+
+// BGPlayResponse wraps BGPlayData the way BGPlay expects it.
+type BGPlayResponse struct {
+	Data BGPlayData `json:"data"`
+}
@@ -0,0 +1,26 @@
+package model
+
+// ChurnPoint holds update counts for one time bucket.
+type ChurnPoint struct {
+	Time          string `json:"time"`
+	Announcements int64  `json:"announcements"`
+	Withdrawals   int64  `json:"withdrawals"`
+}
+
+// ChurnSeries is a time series of update counts for one AFI/origin ASN group.
+// AFI and OriginASN are nil when the series is not grouped by that dimension.
+type ChurnSeries struct {
+	AFI       *int         `json:"afi"`
+	OriginASN *int         `json:"origin_asn"`
+	Points    []ChurnPoint `json:"points"`
+}
+
+// ChurnResponse is the response for update churn queries.
+type ChurnResponse struct {
+	RouterID string        `json:"router_id"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Interval string        `json:"interval"`
+	GroupBy  []string      `json:"group_by"`
+	Series   []ChurnSeries `json:"series"`
+}
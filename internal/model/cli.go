@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// RouterCommandResponse is the output of an allow-listed show command run
+// on a router.
+type RouterCommandResponse struct {
+	RouterID string `json:"router_id"`
+	// Command is the command line as sent to the router.
+	Command string `json:"command"`
+	Output  string `json:"output"`
+	// Truncated is set when output beyond the size limit was dropped.
+	Truncated  bool      `json:"truncated"`
+	ExecutedAt time.Time `json:"executed_at"`
+	// Cached is set when the output was served from the proxy's cache.
+	Cached bool `json:"cached"`
+}
@@ -0,0 +1,34 @@
+package model
+
+// RouterCompareSummary counts prefixes by comparison status.
+type RouterCompareSummary struct {
+	OnlyA     int64 `json:"only_a"`
+	OnlyB     int64 `json:"only_b"`
+	Different int64 `json:"different"`
+	Identical int64 `json:"identical"`
+}
+
+// PrefixComparison compares the best path of one prefix on two routers.
+// Status is "only_a", "only_b" or "different"; A and B are nil when the
+// prefix is missing on that router.
+type PrefixComparison struct {
+	Prefix  string            `json:"prefix"`
+	Table   string            `json:"table"`
+	AFI     int               `json:"afi"`
+	Status  string            `json:"status"`
+	A       *Route            `json:"a"`
+	B       *Route            `json:"b"`
+	Changes []AttributeChange `json:"changes"`
+}
+
+// RouterCompareResponse is the response for a RIB comparison of two routers.
+type RouterCompareResponse struct {
+	RouterA RouterSummary        `json:"router_a"`
+	RouterB RouterSummary        `json:"router_b"`
+	Ignore  []string             `json:"ignore"`
+	Summary RouterCompareSummary `json:"summary"`
+	Entries []PrefixComparison   `json:"entries"`
+	Limit   int                  `json:"limit"`
+	Offset  int                  `json:"offset"`
+	HasMore bool                 `json:"has_more"`
+}
@@ -0,0 +1,51 @@
+package model
+
+// AttributeChange describes how one path attribute differs between two
+// versions of a route. For community attributes Added and Removed list the
+// individual values that differ.
+type AttributeChange struct {
+	Attribute string   `json:"attribute"`
+	Old       any      `json:"old"`
+	New       any      `json:"new"`
+	Added     []string `json:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+}
+
+// DiffRoute is a path present on only one side of a diff, with the table
+// and address family it belongs to.
+type DiffRoute struct {
+	Table string `json:"table"`
+	AFI   int    `json:"afi"`
+	Route
+}
+
+// RouteChange is a path present on both sides of a diff with different
+// attributes.
+type RouteChange struct {
+	Prefix  string            `json:"prefix"`
+	Table   string            `json:"table"`
+	AFI     int               `json:"afi"`
+	PathID  int64             `json:"path_id"`
+	Changes []AttributeChange `json:"changes"`
+	Old     Route             `json:"old"`
+	New     Route             `json:"new"`
+}
+
+// RIBDiffSummary counts the entries of a RIB diff.
+type RIBDiffSummary struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Changed int `json:"changed"`
+}
+
+// RIBDiffResponse is the response for a RIB diff between two points in time.
+type RIBDiffResponse struct {
+	RouterID  string         `json:"router_id"`
+	From      string         `json:"from"`
+	To        string         `json:"to"`
+	Summary   RIBDiffSummary `json:"summary"`
+	Added     []DiffRoute    `json:"added"`
+	Removed   []DiffRoute    `json:"removed"`
+	Changed   []RouteChange  `json:"changed"`
+	Truncated bool           `json:"truncated"`
+}
@@ -0,0 +1,46 @@
+package model
+
+// DampeningParams are the RFC 2439 route flap dampening parameters used for
+// the penalty simulation.
+type DampeningParams struct {
+	HalfLifeMinutes    int `json:"half_life_minutes"`
+	ReuseThreshold     int `json:"reuse_threshold"`
+	SuppressThreshold  int `json:"suppress_threshold"`
+	MaxSuppressMinutes int `json:"max_suppress_minutes"`
+}
+
+// PrefixFlapStats holds flap counters and the simulated dampening state for
+// one prefix on a router.
+type PrefixFlapStats struct {
+	Prefix            string  `json:"prefix"`
+	Announcements     int64   `json:"announcements"`
+	Withdrawals       int64   `json:"withdrawals"`
+	AttributeChanges  int64   `json:"attribute_changes"`
+	Penalty           float64 `json:"penalty"`
+	MaxPenalty        float64 `json:"max_penalty"`
+	Suppressed        bool    `json:"suppressed"`
+	SuppressCount     int     `json:"suppress_count"`
+	SuppressedSeconds int64   `json:"suppressed_seconds"`
+	FirstEvent        string  `json:"first_event"`
+	LastEvent         string  `json:"last_event"`
+}
+
+// RouterFlapSummary aggregates flap counters across all prefixes of a router.
+type RouterFlapSummary struct {
+	Prefixes           int   `json:"prefixes"`
+	Announcements      int64 `json:"announcements"`
+	Withdrawals        int64 `json:"withdrawals"`
+	AttributeChanges   int64 `json:"attribute_changes"`
+	SuppressedPrefixes int   `json:"suppressed_prefixes"`
+	EverSuppressed     int   `json:"ever_suppressed_prefixes"`
+}
+
+// FlapStatsResponse is the response for route flap statistics.
+type FlapStatsResponse struct {
+	RouterID  string            `json:"router_id"`
+	From      string            `json:"from"`
+	To        string            `json:"to"`
+	Dampening DampeningParams   `json:"dampening"`
+	Summary   RouterFlapSummary `json:"summary"`
+	Prefixes  []PrefixFlapStats `json:"prefixes"`
+}
@@ -0,0 +1,77 @@
+package model
+
+// Probe kinds.
+const (
+	ProbePing       = "ping"
+	ProbeTraceroute = "traceroute"
+)
+
+// Probe agent event types. Output events carry a line of probe output,
+// parsed into a ping reply or traceroute hop when recognised; a stream ends
+// with exactly one done or error event.
+const (
+	ProbeEventOutput = "output"
+	ProbeEventDone   = "done"
+	ProbeEventError  = "error"
+)
+
+// ProbeRequest is a ping or traceroute job sent to a probe agent.
+type ProbeRequest struct {
+	Kind   string `json:"kind"`
+	Target string `json:"target"`
+	// Count is the number of ping packets; MaxHops bounds traceroute.
+	Count   int `json:"count,omitempty"`
+	MaxHops int `json:"max_hops,omitempty"`
+}
+
+// ProbeEvent is one event of the probe agent protocol.
+type ProbeEvent struct {
+	Type  string         `json:"type"`
+	Line  string         `json:"line,omitempty"`
+	Reply *PingReply     `json:"reply,omitempty"`
+	Hop   *TracerouteHop `json:"hop,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// PingReply is the outcome of one ping packet, streamed as a "reply"
+// event. Unanswered packets have Success false.
+type PingReply struct {
+	Seq     int     `json:"seq"`
+	RTTMs   float64 `json:"rtt_ms"`
+	TTL     int     `json:"ttl"`
+	Success bool    `json:"success"`
+}
+
+// PingSummary is streamed as a "summary" event once a ping has finished.
+type PingSummary struct {
+	PacketsSent     int     `json:"packets_sent"`
+	PacketsReceived int     `json:"packets_received"`
+	LossPct         float64 `json:"loss_pct"`
+	RTTMinMs        float64 `json:"rtt_min_ms"`
+	RTTAvgMs        float64 `json:"rtt_avg_ms"`
+	RTTMaxMs        float64 `json:"rtt_max_ms"`
+}
+
+// TracerouteHop is a traceroute hop, streamed as a "hop" event. Address is
+// empty when no probe of the hop was answered.
+type TracerouteHop struct {
+	HopNumber int       `json:"hop_number"`
+	Address   string    `json:"address"`
+	RTTMs     []float64 `json:"rtt_ms"`
+}
+
+// TracerouteComplete is the "complete" event of a traceroute.
+type TracerouteComplete struct {
+	ReachedDestination bool `json:"reached_destination"`
+}
+
+// ProbeLine is a raw line of probe output, streamed as a "line" event.
+type ProbeLine struct {
+	Line string `json:"line"`
+}
+
+// ProbeError is the "error" event ending a failed probe.
+type ProbeError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -15,6 +15,10 @@ type ProblemDetail struct {
 	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
 }
 
+// RequestIDHeader carries the request ID on requests and responses. Problem
+// responses repeat it in the instance member.
+const RequestIDHeader = "X-Request-ID"
+
 // InvalidParam describes a single invalid request parameter.
 type InvalidParam struct {
 	Name   string `json:"name"`
@@ -34,6 +38,7 @@ func NewProblem(status int, detail string) ProblemDetail {
 // WriteProblem writes an RFC 7807 error response.
 func WriteProblem(w http.ResponseWriter, status int, detail string) {
 	p := NewProblem(status, detail)
+	p.Instance = w.Header().Get(RequestIDHeader)
 	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(p)
@@ -42,6 +47,7 @@ func WriteProblem(w http.ResponseWriter, status int, detail string) {
 // WriteProblemWithParams writes an RFC 7807 error with invalid parameter details.
 func WriteProblemWithParams(w http.ResponseWriter, status int, detail string, params []InvalidParam) {
 	p := NewProblem(status, detail)
+	p.Instance = w.Header().Get(RequestIDHeader)
 	p.InvalidParams = params
 	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(status)
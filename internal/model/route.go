@@ -35,8 +35,10 @@ type RouterSummary struct {
 
 // RouteLookupMeta contains metadata about the lookup.
 type RouteLookupMeta struct {
-	MatchType    string `json:"match_type"`
-	RouterStatus string `json:"router_status"`
+	MatchType    string  `json:"match_type"`
+	RouterStatus string  `json:"router_status"`
+	At           *string `json:"at,omitempty"`
+	Truncated    bool    `json:"truncated,omitempty"`
 }
 
 // RouteLookupResponse is the response for a route lookup.
@@ -48,9 +50,20 @@ type RouteLookupResponse struct {
 	Meta      RouteLookupMeta `json:"meta"`
 }
 
+// RouteListResponse is the response for a paginated RIB listing.
+type RouteListResponse struct {
+	RouterID string  `json:"router_id"`
+	At       *string `json:"at,omitempty"`
+	Routes   []Route `json:"routes"`
+	Limit    int     `json:"limit"`
+	Offset   int     `json:"offset"`
+	HasMore  bool    `json:"has_more"`
+}
+
 // RouteEvent represents a historical route change.
 type RouteEvent struct {
 	Timestamp           string      `json:"timestamp"`
+	RouterID            string      `json:"router_id"`
 	Action              string      `json:"action"`
 	Prefix              string      `json:"prefix"`
 	PathID              *int64      `json:"path_id"`
@@ -63,14 +76,63 @@ type RouteEvent struct {
 	Communities         []Community `json:"communities"`
 	ExtendedCommunities []Community `json:"extended_communities"`
 	LargeCommunities    []Community `json:"large_communities"`
+
+	// Set only in the history changes view.
+	ChangeType *string           `json:"change_type,omitempty"`
+	Changes    []AttributeChange `json:"changes,omitempty"`
+}
+
+// RouteInterval is a continuous period during which a path was announced
+// with the same attributes. Intervals are clipped to the queried window;
+// Ongoing is true when the path was still announced at its end.
+type RouteInterval struct {
+	RouterID            string      `json:"router_id"`
+	Prefix              string      `json:"prefix"`
+	PathID              *int64      `json:"path_id"`
+	Start               string      `json:"start"`
+	End                 string      `json:"end"`
+	Ongoing             bool        `json:"ongoing"`
+	DurationSeconds     float64     `json:"duration_seconds"`
+	NextHop             *string     `json:"next_hop"`
+	ASPath              []any       `json:"as_path"`
+	Origin              *string     `json:"origin"`
+	LocalPref           *int        `json:"local_pref"`
+	MED                 *int        `json:"med"`
+	OriginASN           *int        `json:"origin_asn"`
+	Communities         []Community `json:"communities"`
+	ExtendedCommunities []Community `json:"extended_communities"`
+	LargeCommunities    []Community `json:"large_communities"`
+}
+
+// HistoryBucket summarises the events of one prefix on one router within a
+// time bucket.
+type HistoryBucket struct {
+	Time          string `json:"time"`
+	RouterID      string `json:"router_id"`
+	Prefix        string `json:"prefix"`
+	Announcements int64  `json:"announcements"`
+	Withdrawals   int64  `json:"withdrawals"`
+	Paths         int64  `json:"paths"`
+	LastAction    string `json:"last_action"`
 }
 
-// RouteHistoryResponse is the response for route history queries.
+// RouteHistoryResponse is the response for route history queries. RouterID
+// is set for single-router queries and Routers for multi-router queries,
+// where an empty list means all routers. Depending on Resolution either
+// Events, Intervals or Buckets is populated. In the "changes" view every event
+// carries its change type and attribute changes.
 type RouteHistoryResponse struct {
-	RouterID string       `json:"router_id"`
-	Prefix   string       `json:"prefix"`
-	From     string       `json:"from"`
-	To       string       `json:"to"`
-	Events   []RouteEvent `json:"events"`
-	HasMore  bool         `json:"has_more"`
+	RouterID   string          `json:"router_id,omitempty"`
+	Routers    []string        `json:"routers,omitempty"`
+	Prefix     string          `json:"prefix"`
+	Scope      string          `json:"scope"`
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	Resolution string          `json:"resolution"`
+	View       string          `json:"view"`
+	Events     []RouteEvent    `json:"events"`
+	Intervals  []RouteInterval `json:"intervals,omitempty"`
+	Buckets    []HistoryBucket `json:"buckets,omitempty"`
+	NextCursor *string         `json:"next_cursor"`
+	Truncated  bool            `json:"truncated,omitempty"`
 }
@@ -35,7 +35,11 @@ type RouterListResponse struct {
 	Data []Router `json:"data"`
 }
 
-// FormatTime formats a time.Time to ISO 8601.
+// timeFormat is RFC 3339 with up to microsecond precision, matching
+// PostgreSQL timestamptz. Trailing zero fractions are omitted.
+const timeFormat = "2006-01-02T15:04:05.999999Z07:00"
+
+// FormatTime formats a time.Time to ISO 8601 with microsecond precision.
 func FormatTime(t time.Time) string {
-	return t.UTC().Format(time.RFC3339)
+	return t.UTC().Format(timeFormat)
 }
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatTime_WholeSeconds(t *testing.T) {
+	got := FormatTime(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	if got != "2025-01-01T12:00:00Z" {
+		t.Fatalf("unexpected format: %s", got)
+	}
+}
+
+func TestFormatTime_Microseconds(t *testing.T) {
+	ts := time.Date(2025, 1, 1, 12, 0, 0, 123456789, time.FixedZone("CET", 3600))
+	got := FormatTime(ts)
+	if got != "2025-01-01T11:00:00.123456Z" {
+		t.Fatalf("unexpected format: %s", got)
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+// PathTimeline holds the state intervals of one path of a prefix.
+type PathTimeline struct {
+	PathID           *int64          `json:"path_id"`
+	AnnouncedSeconds float64         `json:"announced_seconds"`
+	Intervals        []RouteInterval `json:"intervals"`
+}
+
+// Outage is a period within the window during which no path of the prefix
+// was announced. Ongoing is true when the prefix was still unreachable at
+// the end of the window.
+type Outage struct {
+	Start           string  `json:"start"`
+	End             string  `json:"end"`
+	Ongoing         bool    `json:"ongoing"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// TimelineSummary aggregates availability over the queried window. The
+// prefix counts as available while at least one path is announced.
+type TimelineSummary struct {
+	WindowSeconds       float64 `json:"window_seconds"`
+	AvailableSeconds    float64 `json:"available_seconds"`
+	OutageSeconds       float64 `json:"outage_seconds"`
+	AvailabilityPercent float64 `json:"availability_percent"`
+	Outages             int     `json:"outages"`
+	Intervals           int     `json:"intervals"`
+}
+
+// PrefixTimelineResponse is the response for the prefix timeline endpoint.
+type PrefixTimelineResponse struct {
+	RouterID string          `json:"router_id"`
+	Prefix   string          `json:"prefix"`
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Summary  TimelineSummary `json:"summary"`
+	Paths    []PathTimeline  `json:"paths"`
+	Outages  []Outage        `json:"outages"`
+}
@@ -0,0 +1,143 @@
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// Exec runs probes with the local iputils ping and traceroute commands,
+// parsing their numeric output into replies and hops.
+type Exec struct {
+	// Ping and Traceroute are the command paths, "ping" and "traceroute"
+	// by default.
+	Ping       string
+	Traceroute string
+}
+
+// Run implements Agent.
+func (e *Exec) Run(ctx context.Context, req model.ProbeRequest, emit func(model.ProbeEvent)) error {
+	var cmd *exec.Cmd
+	switch req.Kind {
+	case model.ProbePing:
+		cmd = exec.CommandContext(ctx, orDefault(e.Ping, "ping"),
+			"-n", "-O", "-c", strconv.Itoa(req.Count), "-W", "2", req.Target)
+	case model.ProbeTraceroute:
+		cmd = exec.CommandContext(ctx, orDefault(e.Traceroute, "traceroute"),
+			"-n", "-q", "3", "-w", "2", "-m", strconv.Itoa(req.MaxHops), req.Target)
+	default:
+		return fmt.Errorf("unknown probe kind %q", req.Kind)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		emit(outputEvent(req.Kind, scanner.Text()))
+	}
+
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case errors.As(err, &exitErr) && req.Kind == model.ProbePing && exitErr.ExitCode() == 1:
+		// ping exits 1 when no reply was received, which is a result.
+		return nil
+	case err != nil:
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %s", cmd.Args[0], msg)
+		}
+		return fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// outputEvent returns the output event of a line of probe output.
+func outputEvent(kind, line string) model.ProbeEvent {
+	ev := model.ProbeEvent{Type: model.ProbeEventOutput, Line: line}
+	if kind == model.ProbePing {
+		ev.Reply = parsePingReply(line)
+	} else {
+		ev.Hop = parseTracerouteHop(line)
+	}
+	return ev
+}
+
+// pingReplyRE matches iputils echo replies such as
+// "64 bytes from 192.0.2.1: icmp_seq=1 ttl=57 time=12.3 ms", and
+// pingLostRE the "no answer yet for icmp_seq=2" lines of ping -O.
+var (
+	pingReplyRE = regexp.MustCompile(`icmp_seq=(\d+) ttl=(\d+) time=([\d.]+) ms`)
+	pingLostRE  = regexp.MustCompile(`^no answer yet for icmp_seq=(\d+)`)
+)
+
+// parsePingReply returns the reply of a ping output line, or nil.
+func parsePingReply(line string) *model.PingReply {
+	if m := pingLostRE.FindStringSubmatch(line); m != nil {
+		seq, _ := strconv.Atoi(m[1])
+		return &model.PingReply{Seq: seq}
+	}
+	m := pingReplyRE.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	seq, _ := strconv.Atoi(m[1])
+	ttl, _ := strconv.Atoi(m[2])
+	rtt, _ := strconv.ParseFloat(m[3], 64)
+	return &model.PingReply{Seq: seq, RTTMs: rtt, TTL: ttl, Success: true}
+}
+
+// parseTracerouteHop returns the hop of a numeric traceroute output line
+// such as " 3  192.0.2.9  1.204 ms  192.0.2.10  1.310 ms  *", or nil. When
+// the probes of a hop were answered by several addresses, the first one is
+// reported.
+func parseTracerouteHop(line string) *model.TracerouteHop {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return nil
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil
+	}
+	hop := &model.TracerouteHop{HopNumber: n, RTTMs: []float64{}}
+	for i := 1; i < len(fields); i++ {
+		f := fields[i]
+		if i+1 < len(fields) && fields[i+1] == "ms" {
+			if rtt, err := strconv.ParseFloat(f, 64); err == nil {
+				hop.RTTMs = append(hop.RTTMs, rtt)
+				i++
+				continue
+			}
+		}
+		if hop.Address == "" && f != "*" && !strings.HasPrefix(f, "!") {
+			hop.Address = f
+		}
+	}
+	return hop
+}
@@ -0,0 +1,70 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// Fake is an agent returning scripted results, for tests and demos. Each
+// output line is emitted Delay after the previous one.
+type Fake struct {
+	// Script returns the output events of a request. When nil, plausible
+	// iputils output is synthesised for the target.
+	Script func(req model.ProbeRequest) []model.ProbeEvent
+	// Err, when set, is returned once the script has been emitted.
+	Err   error
+	Delay time.Duration
+}
+
+// Run implements Agent.
+func (f *Fake) Run(ctx context.Context, req model.ProbeRequest, emit func(model.ProbeEvent)) error {
+	script := f.Script
+	if script == nil {
+		script = synthesize
+	}
+	for _, ev := range script(req) {
+		if f.Delay > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(f.Delay):
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		emit(ev)
+	}
+	return f.Err
+}
+
+// synthesize renders a loss-free ping, or a traceroute through three
+// documentation-range hops, in the format of the iputils commands.
+func synthesize(req model.ProbeRequest) []model.ProbeEvent {
+	var lines []string
+	switch req.Kind {
+	case model.ProbePing:
+		lines = append(lines, fmt.Sprintf("PING %s (%s) 56(84) bytes of data.", req.Target, req.Target))
+		for seq := 1; seq <= req.Count; seq++ {
+			lines = append(lines, fmt.Sprintf("64 bytes from %s: icmp_seq=%d ttl=57 time=%.1f ms", req.Target, seq, 10+float64(seq%3)/10))
+		}
+		lines = append(lines, "",
+			fmt.Sprintf("--- %s ping statistics ---", req.Target),
+			fmt.Sprintf("%d packets transmitted, %d received, 0%% packet loss", req.Count, req.Count))
+	case model.ProbeTraceroute:
+		lines = append(lines, fmt.Sprintf("traceroute to %s (%s), %d hops max, 60 byte packets", req.Target, req.Target, req.MaxHops))
+		hops := []string{"192.0.2.1", "198.51.100.1", "203.0.113.1", req.Target}
+		for i, addr := range hops[:min(len(hops), req.MaxHops)] {
+			rtt := float64(i*4) + 0.5
+			lines = append(lines, fmt.Sprintf("%2d  %s  %.3f ms  %.3f ms  %.3f ms", i+1, addr, rtt, rtt+0.1, rtt+0.2))
+		}
+	}
+
+	events := make([]model.ProbeEvent, len(lines))
+	for i, line := range lines {
+		events[i] = outputEvent(req.Kind, line)
+	}
+	return events
+}
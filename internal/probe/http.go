@@ -0,0 +1,124 @@
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// The agent protocol: the API POSTs a model.ProbeRequest as JSON to
+// ProtocolPath with the agent's token as bearer token. The agent answers
+// 200 with newline-delimited model.ProbeEvent objects, flushed as they are
+// produced and ending in a done or error event, or with a non-200 status
+// and a plain-text reason when it rejects the job.
+const (
+	ProtocolPath        = "/v1/probe"
+	protocolContentType = "application/x-ndjson"
+)
+
+// HTTPAgent is a remote agent reached over the agent protocol.
+type HTTPAgent struct {
+	// URL is the agent's base URL, e.g. "http://probe-fra1:9180".
+	URL   string
+	Token string
+	// Client defaults to http.DefaultClient. Probes are bounded by the
+	// context passed to Run, so it should not set a Timeout.
+	Client *http.Client
+}
+
+// Run implements Agent.
+func (a *HTTPAgent) Run(ctx context.Context, req model.ProbeRequest, emit func(model.ProbeEvent)) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(a.URL, "/")+ProtocolPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+	hreq.Header.Set("Accept", protocolContentType)
+	hreq.Header.Set("Authorization", "Bearer "+a.Token)
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(hreq)
+	if err != nil {
+		return fmt.Errorf("probe agent: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		reason, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("probe agent: %s: %s", resp.Status, strings.TrimSpace(string(reason)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var ev model.ProbeEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			return fmt.Errorf("probe agent: malformed event: %w", err)
+		}
+		switch ev.Type {
+		case model.ProbeEventDone:
+			return nil
+		case model.ProbeEventError:
+			return &AgentError{Message: ev.Error}
+		default:
+			emit(ev)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("probe agent: %w", err)
+	}
+	return errors.New("probe agent: stream ended without a result")
+}
+
+// Handler serves the agent protocol, running the probes of requests that
+// carry token on agent.
+func Handler(agent Agent, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+ProtocolPath, func(w http.ResponseWriter, r *http.Request) {
+		got, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		var req model.ProbeRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil {
+			http.Error(w, "malformed request", http.StatusBadRequest)
+			return
+		}
+		if err := Normalize(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.Header().Set("Content-Type", protocolContentType)
+		rc := http.NewResponseController(w)
+		enc := json.NewEncoder(w)
+		send := func(ev model.ProbeEvent) {
+			enc.Encode(ev)
+			rc.Flush()
+		}
+		if err := agent.Run(r.Context(), req, send); err != nil {
+			send(model.ProbeEvent{Type: model.ProbeEventError, Error: err.Error()})
+			return
+		}
+		send(model.ProbeEvent{Type: model.ProbeEventDone})
+	})
+	return mux
+}
@@ -0,0 +1,132 @@
+// Package probe runs ping and traceroute from the location of a router.
+// Jobs are dispatched to probe agents, usually remote processes reached
+// over HTTP, which stream back the probe output as it is produced.
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// Limits bounding probe requests.
+const (
+	MaxCount   = 10
+	MaxHops    = 30
+	DefCount   = 5
+	DefMaxHops = 30
+)
+
+// Errors returned by Registry.Acquire.
+var (
+	ErrNoAgent = errors.New("no probe agent for router")
+	ErrBusy    = errors.New("probe agent busy")
+)
+
+// AgentError is a probe failure reported by an agent, such as an
+// unreachable network. Unlike transport errors, its message is meant for
+// the user.
+type AgentError struct {
+	Message string
+}
+
+func (e *AgentError) Error() string {
+	return e.Message
+}
+
+// Agent runs probes. Run calls emit with each output event, in order, and
+// returns once the probe has finished; the terminal done or error event is
+// left to the caller. Run must return promptly when ctx is done.
+type Agent interface {
+	Run(ctx context.Context, req model.ProbeRequest, emit func(model.ProbeEvent)) error
+}
+
+// Normalize fills in the defaults of req and checks it. Targets must be
+// unicast IP addresses; host names are not resolved.
+func Normalize(req *model.ProbeRequest) error {
+	addr, err := netip.ParseAddr(req.Target)
+	if err != nil {
+		return fmt.Errorf("target %q is not an IP address", req.Target)
+	}
+	addr = addr.Unmap()
+	if addr.IsUnspecified() || addr.IsLoopback() || addr.IsMulticast() || addr.IsLinkLocalUnicast() {
+		return fmt.Errorf("target %s is not a routable unicast address", addr)
+	}
+	req.Target = addr.String()
+
+	switch req.Kind {
+	case model.ProbePing:
+		if req.Count == 0 {
+			req.Count = DefCount
+		}
+		if req.Count < 1 || req.Count > MaxCount {
+			return fmt.Errorf("count must be between 1 and %d", MaxCount)
+		}
+		req.MaxHops = 0
+	case model.ProbeTraceroute:
+		if req.MaxHops == 0 {
+			req.MaxHops = DefMaxHops
+		}
+		if req.MaxHops < 1 || req.MaxHops > MaxHops {
+			return fmt.Errorf("max_hops must be between 1 and %d", MaxHops)
+		}
+		req.Count = 0
+	default:
+		return fmt.Errorf("unknown probe kind %q", req.Kind)
+	}
+	return nil
+}
+
+// Registry maps routers to the agents probing from their location and
+// bounds the probes running on each agent at a time. It is safe for
+// concurrent use once populated.
+type Registry struct {
+	maxConcurrent int
+	agents        map[string]*slot
+}
+
+// slot is an agent with its concurrency semaphore, shared by every router
+// the agent serves.
+type slot struct {
+	agent Agent
+	sem   chan struct{}
+}
+
+// NewRegistry returns an empty registry allowing maxConcurrent probes per
+// agent.
+func NewRegistry(maxConcurrent int) *Registry {
+	return &Registry{maxConcurrent: maxConcurrent, agents: map[string]*slot{}}
+}
+
+// Register makes agent probe on behalf of routers. It must not be called
+// concurrently with Acquire.
+func (r *Registry) Register(agent Agent, routers ...string) {
+	s := &slot{agent: agent, sem: make(chan struct{}, r.maxConcurrent)}
+	for _, id := range routers {
+		r.agents[id] = s
+	}
+}
+
+// Has reports whether an agent probes on behalf of routerID.
+func (r *Registry) Has(routerID string) bool {
+	_, ok := r.agents[routerID]
+	return ok
+}
+
+// Acquire reserves a probe on the agent of routerID. The caller must call
+// release once the probe has finished.
+func (r *Registry) Acquire(routerID string) (agent Agent, release func(), err error) {
+	s, ok := r.agents[routerID]
+	if !ok {
+		return nil, nil, ErrNoAgent
+	}
+	select {
+	case s.sem <- struct{}{}:
+		return s.agent, func() { <-s.sem }, nil
+	default:
+		return nil, nil, ErrBusy
+	}
+}
@@ -0,0 +1,127 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		req     model.ProbeRequest
+		want    model.ProbeRequest
+		wantErr bool
+	}{
+		{model.ProbeRequest{Kind: "ping", Target: "192.0.2.1"}, model.ProbeRequest{Kind: "ping", Target: "192.0.2.1", Count: DefCount}, false},
+		{model.ProbeRequest{Kind: "traceroute", Target: "2001:DB8::1", Count: 3}, model.ProbeRequest{Kind: "traceroute", Target: "2001:db8::1", MaxHops: DefMaxHops}, false},
+		{model.ProbeRequest{Kind: "ping", Target: "::ffff:192.0.2.1", Count: 2}, model.ProbeRequest{Kind: "ping", Target: "192.0.2.1", Count: 2}, false},
+		{req: model.ProbeRequest{Kind: "ping", Target: "example.com"}, wantErr: true},
+		{req: model.ProbeRequest{Kind: "ping", Target: "127.0.0.1"}, wantErr: true},
+		{req: model.ProbeRequest{Kind: "ping", Target: "ff02::1"}, wantErr: true},
+		{req: model.ProbeRequest{Kind: "ping", Target: "192.0.2.1", Count: MaxCount + 1}, wantErr: true},
+		{req: model.ProbeRequest{Kind: "traceroute", Target: "192.0.2.1", MaxHops: -1}, wantErr: true},
+		{req: model.ProbeRequest{Kind: "mtr", Target: "192.0.2.1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		req := tt.req
+		err := Normalize(&req)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v: error %v, want error %v", tt.req, err, tt.wantErr)
+			continue
+		}
+		if err == nil && req != tt.want {
+			t.Errorf("%+v: normalized to %+v, want %+v", tt.req, req, tt.want)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry(1)
+	agent := &Fake{}
+	reg.Register(agent, "r1", "r2")
+
+	if _, _, err := reg.Acquire("r3"); !errors.Is(err, ErrNoAgent) {
+		t.Fatalf("expected ErrNoAgent, got %v", err)
+	}
+	got, release, err := reg.Acquire("r1")
+	if err != nil || got != agent {
+		t.Fatalf("Acquire: %v %v", got, err)
+	}
+	// r2 shares the agent and its concurrency limit with r1.
+	if _, _, err := reg.Acquire("r2"); !errors.Is(err, ErrBusy) {
+		t.Fatalf("expected ErrBusy, got %v", err)
+	}
+	release()
+	if _, _, err := reg.Acquire("r2"); err != nil {
+		t.Fatalf("Acquire after release: %v", err)
+	}
+}
+
+func TestParsePingReply(t *testing.T) {
+	tests := []struct {
+		line string
+		want *model.PingReply
+	}{
+		{"64 bytes from 2001:db8::1: icmp_seq=3 ttl=57 time=12.3 ms", &model.PingReply{Seq: 3, RTTMs: 12.3, TTL: 57, Success: true}},
+		{"no answer yet for icmp_seq=4", &model.PingReply{Seq: 4}},
+		{"PING 192.0.2.1 (192.0.2.1) 56(84) bytes of data.", nil},
+	}
+	for _, tt := range tests {
+		if got := parsePingReply(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseTracerouteHop(t *testing.T) {
+	tests := []struct {
+		line string
+		want *model.TracerouteHop
+	}{
+		{" 1  192.0.2.1  0.512 ms  0.480 ms  0.470 ms", &model.TracerouteHop{HopNumber: 1, Address: "192.0.2.1", RTTMs: []float64{0.512, 0.480, 0.470}}},
+		{" 3  192.0.2.9  1.204 ms  192.0.2.10  1.310 ms  *", &model.TracerouteHop{HopNumber: 3, Address: "192.0.2.9", RTTMs: []float64{1.204, 1.310}}},
+		{"12  * * *", &model.TracerouteHop{HopNumber: 12, RTTMs: []float64{}}},
+		{"traceroute to 192.0.2.1 (192.0.2.1), 30 hops max, 60 byte packets", nil},
+	}
+	for _, tt := range tests {
+		if got := parseTracerouteHop(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestHTTPAgent(t *testing.T) {
+	srv := httptest.NewServer(Handler(&Fake{}, "secret"))
+	defer srv.Close()
+
+	req := model.ProbeRequest{Kind: model.ProbePing, Target: "192.0.2.1", Count: 2}
+	var events []model.ProbeEvent
+	agent := &HTTPAgent{URL: srv.URL, Token: "secret"}
+	if err := agent.Run(context.Background(), req, func(ev model.ProbeEvent) { events = append(events, ev) }); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if want := synthesize(req); !reflect.DeepEqual(events, want) {
+		t.Fatalf("got events %+v, want %+v", events, want)
+	}
+
+	agent.Token = "wrong"
+	if err := agent.Run(context.Background(), req, func(model.ProbeEvent) {}); err == nil {
+		t.Fatal("expected an error for a wrong token")
+	}
+}
+
+func TestHTTPAgentError(t *testing.T) {
+	srv := httptest.NewServer(Handler(&Fake{Err: errors.New("network unreachable")}, "secret"))
+	defer srv.Close()
+
+	agent := &HTTPAgent{URL: srv.URL, Token: "secret"}
+	err := agent.Run(context.Background(), model.ProbeRequest{Kind: model.ProbeTraceroute, Target: "192.0.2.1", MaxHops: 2}, func(model.ProbeEvent) {})
+	var agentErr *AgentError
+	if !errors.As(err, &agentErr) || agentErr.Message != "network unreachable" {
+		t.Fatalf("expected the agent's error, got %v", err)
+	}
+}
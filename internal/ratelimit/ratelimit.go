@@ -0,0 +1,108 @@
+// Package ratelimit implements per-client token buckets.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are dropped.
+const sweepInterval = time.Minute
+
+// Limiter holds one token bucket per client key. Each bucket holds up to
+// burst tokens and refills at the configured rate; a request takes one
+// token. A Limiter is safe for concurrent use.
+type Limiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New returns a Limiter allowing perMinute requests per minute per client,
+// with bursts of up to burst requests.
+func New(perMinute, burst int) *Limiter {
+	return &Limiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+// Burst returns the bucket size.
+func (l *Limiter) Burst() int {
+	return int(l.burst)
+}
+
+// Allow takes a token from key's bucket. When the bucket is empty it
+// returns false and how long until a token is available.
+func (l *Limiter) Allow(key string) (ok bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, l.rate, l.burst)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := (1 - b.tokens) / l.rate
+	return false, time.Duration(math.Ceil(wait * float64(time.Second)))
+}
+
+// Wait returns how long until key's bucket holds a token, without taking
+// one; zero when a request would be allowed now.
+func (l *Limiter) Wait(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, found := l.buckets[key]
+	if !found {
+		return 0
+	}
+	b.refill(l.now(), l.rate, l.burst)
+	if b.tokens >= 1 {
+		return 0
+	}
+	wait := (1 - b.tokens) / l.rate
+	return time.Duration(math.Ceil(wait * float64(time.Second)))
+}
+
+func (b *bucket) refill(now time.Time, rate, burst float64) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(burst, b.tokens+elapsed*rate)
+		b.last = now
+	}
+}
+
+// sweep drops buckets that have refilled completely; they are
+// indistinguishable from new ones.
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		b.refill(now, l.rate, l.burst)
+		if b.tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
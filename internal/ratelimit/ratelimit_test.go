@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLimiter(perMinute, burst int) (*Limiter, *time.Time) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := New(perMinute, burst)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestAllowBurstThenRefill(t *testing.T) {
+	l, now := newTestLimiter(60, 3) // one token per second
+
+	for i := range 3 {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d within burst rejected", i)
+		}
+	}
+	ok, retry := l.Allow("a")
+	if ok {
+		t.Fatal("expected rejection after burst")
+	}
+	if retry != time.Second {
+		t.Errorf("expected retry after 1s, got %v", retry)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if ok, retry := l.Allow("a"); ok || retry != 500*time.Millisecond {
+		t.Errorf("expected rejection with 500ms retry, got %v %v", ok, retry)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("expected a refilled token")
+	}
+}
+
+func TestWaitDoesNotTakeTokens(t *testing.T) {
+	l, now := newTestLimiter(60, 1)
+	if wait := l.Wait("a"); wait != 0 {
+		t.Fatalf("expected no wait for a new key, got %v", wait)
+	}
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("Wait took the only token")
+	}
+	if wait := l.Wait("a"); wait != time.Second {
+		t.Errorf("expected 1s wait, got %v", wait)
+	}
+	*now = now.Add(time.Second)
+	if wait := l.Wait("a"); wait != 0 {
+		t.Errorf("expected no wait after refill, got %v", wait)
+	}
+}
+
+func TestAllowKeysAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter(1, 1)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("first request of a rejected")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatal("second request of a allowed")
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Fatal("first request of b rejected")
+	}
+}
+
+func TestRefillCapsAtBurst(t *testing.T) {
+	l, now := newTestLimiter(60, 2)
+	l.Allow("a")
+	*now = now.Add(time.Hour)
+	for range 2 {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatal("expected full bucket")
+		}
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatal("bucket exceeded burst")
+	}
+}
+
+func TestSweepDropsIdleBuckets(t *testing.T) {
+	l, now := newTestLimiter(60, 5)
+	l.Allow("idle")
+	*now = now.Add(sweepInterval)
+	l.Allow("active")
+
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("expected idle bucket to be swept")
+	}
+	if _, ok := l.buckets["active"]; !ok {
+		t.Error("expected active bucket to remain")
+	}
+}
@@ -0,0 +1,111 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// birdTimeFormat is the ISO long route time format of BIRD 2.
+const birdTimeFormat = "2006-01-02 15:04:05"
+
+// BIRD renders a lookup like BIRD 2 "show route all for <prefix>". The
+// protocol is named after the router.
+func BIRD(w io.Writer, l Lookup) {
+	groups := groupByPrefix(l.Routes)
+	if len(groups) == 0 {
+		io.WriteString(w, "Network not found\n")
+		return
+	}
+
+	for _, table := range []struct {
+		name string
+		v6   bool
+	}{{"master4", false}, {"master6", true}} {
+		header := false
+		for _, g := range groups {
+			if isIPv6(g.prefix) != table.v6 {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(w, "Table %s:\n", table.name)
+				header = true
+			}
+			for i, r := range g.routes {
+				network := g.prefix
+				if i > 0 {
+					network = ""
+				}
+				fmt.Fprintf(w, "%-20s unicast [%s", network, l.Router.ID)
+				if t, ok := firstSeen(r); ok {
+					fmt.Fprintf(w, " %s", t.UTC().Format(birdTimeFormat))
+				}
+				io.WriteString(w, "]")
+				if r.PathID == 0 {
+					io.WriteString(w, " *")
+				}
+				io.WriteString(w, " (100)")
+				if r.OriginASN != nil {
+					fmt.Fprintf(w, " [AS%d%s]", *r.OriginASN, originCode(r))
+				}
+				io.WriteString(w, "\n")
+
+				if r.NextHop != nil {
+					fmt.Fprintf(w, "\tvia %s\n", *r.NextHop)
+				}
+				io.WriteString(w, "\tType: BGP univ\n")
+				if o := origin(r); o != "" {
+					if o == "incomplete" {
+						o = "Incomplete"
+					}
+					fmt.Fprintf(w, "\tBGP.origin: %s\n", o)
+				}
+				asPath := asPathParts(r.ASPath, func(set []string) string { return "{" + strings.Join(set, " ") + "}" })
+				fmt.Fprintf(w, "\tBGP.as_path: %s\n", strings.Join(asPath, " "))
+				if r.NextHop != nil {
+					fmt.Fprintf(w, "\tBGP.next_hop: %s\n", *r.NextHop)
+				}
+				if r.MED != nil {
+					fmt.Fprintf(w, "\tBGP.med: %d\n", *r.MED)
+				}
+				if r.LocalPref != nil {
+					fmt.Fprintf(w, "\tBGP.local_pref: %d\n", *r.LocalPref)
+				}
+				writeBIRDCommunities(w, "BGP.community", values(r.Communities), birdCommunity)
+				writeBIRDCommunities(w, "BGP.ext_community", values(r.ExtendedCommunities), birdExtCommunity)
+				writeBIRDCommunities(w, "BGP.large_community", values(r.LargeCommunities), birdCommunity)
+			}
+		}
+	}
+}
+
+func writeBIRDCommunities(w io.Writer, attr string, cs []string, format func(string) string) {
+	if len(cs) == 0 {
+		return
+	}
+	parts := make([]string, len(cs))
+	for i, c := range cs {
+		parts[i] = format(c)
+	}
+	fmt.Fprintf(w, "\t%s: %s\n", attr, strings.Join(parts, " "))
+}
+
+// birdCommunity formats a standard or large community "a:b[:c]" as
+// "(a, b[, c])".
+func birdCommunity(c string) string {
+	return "(" + strings.ReplaceAll(c, ":", ", ") + ")"
+}
+
+// birdExtCommunity formats an extended community "RT:a:b" as
+// "(rt, a, b)"; site of origin is BIRD's "ro".
+func birdExtCommunity(c string) string {
+	typ, rest, ok := strings.Cut(c, ":")
+	if !ok {
+		return "(" + c + ")"
+	}
+	typ = strings.ToLower(typ)
+	if typ == "soo" {
+		typ = "ro"
+	}
+	return "(" + typ + ", " + strings.ReplaceAll(rest, ":", ", ") + ")"
+}
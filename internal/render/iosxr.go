@@ -0,0 +1,98 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// IOSXR renders a lookup like Cisco IOS XR "show bgp <prefix>".
+func IOSXR(w io.Writer, l Lookup) {
+	if len(l.Routes) == 0 {
+		io.WriteString(w, "% Network not in table\n")
+		return
+	}
+
+	for i, g := range groupByPrefix(l.Routes) {
+		if i > 0 {
+			io.WriteString(w, "\n")
+		}
+		fmt.Fprintf(w, "BGP routing table entry for %s\n", g.prefix)
+		best := ""
+		for j, r := range g.routes {
+			if r.PathID == 0 {
+				best = fmt.Sprintf(", best #%d", j+1)
+			}
+		}
+		fmt.Fprintf(w, "Paths: (%d available%s)\n", len(g.routes), best)
+
+		for j, r := range g.routes {
+			fmt.Fprintf(w, "  Path #%d: Received by speaker 0\n", j+1)
+			asPath := strings.Join(asPathParts(r.ASPath, func(set []string) string {
+				return "{" + strings.Join(set, ",") + "}"
+			}), " ")
+			if asPath == "" {
+				asPath = "Local"
+			}
+			fmt.Fprintf(w, "  %s\n", asPath)
+			if r.NextHop != nil {
+				fmt.Fprintf(w, "    %s\n", *r.NextHop)
+			}
+
+			var attrs []string
+			if o := origin(r); o != "" {
+				attrs = append(attrs, "Origin "+o)
+			}
+			if r.MED != nil {
+				attrs = append(attrs, fmt.Sprintf("metric %d", *r.MED))
+			}
+			if r.LocalPref != nil {
+				attrs = append(attrs, fmt.Sprintf("localpref %d", *r.LocalPref))
+			}
+			attrs = append(attrs, "valid")
+			if r.PathID == 0 {
+				attrs = append(attrs, "best")
+			}
+			fmt.Fprintf(w, "      %s\n", strings.Join(attrs, ", "))
+
+			fmt.Fprintf(w, "      Received Path ID %d", r.PathID)
+			if d, ok := age(r, l.Now); ok {
+				fmt.Fprintf(w, ", age %s", ciscoAge(d))
+			}
+			io.WriteString(w, "\n")
+			if len(r.Communities) > 0 {
+				fmt.Fprintf(w, "      Community: %s\n", strings.Join(values(r.Communities), " "))
+			}
+			if len(r.ExtendedCommunities) > 0 {
+				fmt.Fprintf(w, "      Extended community: %s\n", strings.Join(values(r.ExtendedCommunities), " "))
+			}
+			if len(r.LargeCommunities) > 0 {
+				fmt.Fprintf(w, "      Large Community: %s\n", strings.Join(values(r.LargeCommunities), " "))
+			}
+			if r.OriginASN != nil {
+				fmt.Fprintf(w, "      Origin-AS: %d\n", *r.OriginASN)
+			}
+		}
+	}
+}
+
+// ciscoAge formats d the way Cisco uptime columns do: 01:02:03 below a
+// day, 2d03h below a week, 1w2d below a year and 1y2w beyond.
+func ciscoAge(d time.Duration) string {
+	const (
+		day  = 24 * time.Hour
+		week = 7 * day
+		year = 365 * day
+	)
+	switch {
+	case d < day:
+		return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+	case d < week:
+		return fmt.Sprintf("%dd%02dh", d/day, (d%day)/time.Hour)
+	case d < year:
+		return fmt.Sprintf("%dw%dd", d/week, (d%week)/day)
+	default:
+		return fmt.Sprintf("%dy%dw", d/year, (d%year)/week)
+	}
+}
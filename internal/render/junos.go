@@ -0,0 +1,145 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Junos renders a lookup like Junos "show route <prefix> detail".
+func Junos(w io.Writer, l Lookup) {
+	groups := groupByPrefix(l.Routes)
+	for _, table := range []struct {
+		name string
+		v6   bool
+	}{{"inet.0", false}, {"inet6.0", true}} {
+		var in []prefixGroup
+		routes := 0
+		for _, g := range groups {
+			if isIPv6(g.prefix) == table.v6 {
+				in = append(in, g)
+				routes += len(g.routes)
+			}
+		}
+		if len(in) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n%s: %d destinations, %d routes\n", table.name, len(in), routes)
+		for _, g := range in {
+			announced := 0
+			for _, r := range g.routes {
+				if r.PathID == 0 {
+					announced++
+				}
+			}
+			fmt.Fprintf(w, "%s (%d %s, %d announced)\n", g.prefix, len(g.routes), plural(len(g.routes), "entry", "entries"), announced)
+
+			for _, r := range g.routes {
+				const indent = "                "
+				mark := " "
+				if r.PathID == 0 {
+					mark = "*"
+				}
+				// Junos shows the negated local preference, minus one, as
+				// the secondary preference of BGP routes.
+				pref := "170"
+				if r.LocalPref != nil {
+					pref += fmt.Sprintf("/-%d", *r.LocalPref+1)
+				}
+				fmt.Fprintf(w, "       %sBGP    Preference: %s\n", mark, pref)
+				if r.NextHop != nil {
+					fmt.Fprintf(w, "%sNext hop: %s\n", indent, *r.NextHop)
+				}
+				state := "<Active Ext>"
+				if r.PathID != 0 {
+					state = "<NotBest Ext>"
+				}
+				fmt.Fprintf(w, "%sState: %s\n", indent, state)
+
+				var line []string
+				if d, ok := age(r, l.Now); ok {
+					line = append(line, "Age: "+junosAge(d))
+				}
+				if r.MED != nil {
+					line = append(line, fmt.Sprintf("Metric: %d", *r.MED))
+				}
+				if len(line) > 0 {
+					fmt.Fprintf(w, "%s%s\n", indent, strings.Join(line, " \t"))
+				}
+
+				asPath := asPathParts(r.ASPath, func(set []string) string { return "{" + strings.Join(set, " ") + "}" })
+				if code := strings.ToUpper(originCode(r)); code != "" {
+					asPath = append(asPath, code)
+				}
+				fmt.Fprintf(w, "%sAS path: %s\n", indent, strings.Join(asPath, " "))
+
+				var comms []string
+				comms = append(comms, values(r.Communities)...)
+				for _, c := range values(r.ExtendedCommunities) {
+					comms = append(comms, junosExtCommunity(c))
+				}
+				for _, c := range values(r.LargeCommunities) {
+					comms = append(comms, "large:"+c)
+				}
+				if len(comms) > 0 {
+					fmt.Fprintf(w, "%sCommunities: %s\n", indent, strings.Join(comms, " "))
+				}
+				if r.LocalPref != nil {
+					fmt.Fprintf(w, "%sLocalpref: %d\n", indent, *r.LocalPref)
+				}
+				if r.OriginASN != nil {
+					fmt.Fprintf(w, "%sOrigin AS: %d\n", indent, *r.OriginASN)
+				}
+			}
+		}
+	}
+	if len(groups) == 0 {
+		fmt.Fprintf(w, "\nNo route to %s\n", l.Prefix)
+	}
+}
+
+// junosExtCommunity renames the route target and site of origin types of
+// an extended community to their Junos names.
+func junosExtCommunity(c string) string {
+	typ, rest, ok := strings.Cut(c, ":")
+	if !ok {
+		return c
+	}
+	switch strings.ToLower(typ) {
+	case "rt":
+		return "target:" + rest
+	case "soo":
+		return "origin:" + rest
+	}
+	return c
+}
+
+// junosAge formats d the way Junos route ages are: 1w2d 3:04:05,
+// 2d 3:04:05, 3:04:05, or 4:05 below an hour.
+func junosAge(d time.Duration) string {
+	const (
+		day  = 24 * time.Hour
+		week = 7 * day
+	)
+	h, m, s := int((d%day)/time.Hour), int(d.Minutes())%60, int(d.Seconds())%60
+	clock := fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	switch {
+	case d >= week:
+		return fmt.Sprintf("%dw%dd %s", d/week, (d%week)/day, clock)
+	case d >= day:
+		return fmt.Sprintf("%dd %s", d/day, clock)
+	case d >= time.Hour:
+		return clock
+	default:
+		return fmt.Sprintf("%d:%02d", m, s)
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
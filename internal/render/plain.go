@@ -0,0 +1,66 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Plain is the route-beacon rendering. It lists every path with its
+// attributes and absolute timestamps only, so that it is stable while the
+// routes do not change.
+func Plain(w io.Writer, l Lookup) {
+	routerID := l.Router.ID
+	if len(l.Routes) == 0 {
+		fmt.Fprintf(w, "No routes found for %s on %s\n", l.Prefix, routerID)
+		return
+	}
+
+	fmt.Fprintf(w, "BGP routing table entry for %s on %s\n", l.Prefix, routerID)
+	fmt.Fprintf(w, "%d path(s) available\n\n", len(l.Routes))
+
+	for i, r := range l.Routes {
+		fmt.Fprintf(w, "Path #%d", i+1)
+		if r.PathID == 0 {
+			io.WriteString(w, " (best)")
+		}
+		io.WriteString(w, "\n")
+
+		if r.Prefix != l.Prefix {
+			fmt.Fprintf(w, "  Prefix: %s\n", r.Prefix)
+		}
+		if r.NextHop != nil {
+			fmt.Fprintf(w, "  Next Hop: %s\n", *r.NextHop)
+		}
+		asPath := asPathParts(r.ASPath, func(set []string) string { return "{" + strings.Join(set, ",") + "}" })
+		fmt.Fprintf(w, "  AS Path: %s\n", strings.Join(asPath, " "))
+		if r.Origin != nil {
+			fmt.Fprintf(w, "  Origin: %s\n", *r.Origin)
+		}
+		if r.OriginASN != nil {
+			fmt.Fprintf(w, "  Origin AS: %d\n", *r.OriginASN)
+		}
+		if r.LocalPref != nil {
+			fmt.Fprintf(w, "  Local Pref: %d\n", *r.LocalPref)
+		}
+		if r.MED != nil {
+			fmt.Fprintf(w, "  MED: %d\n", *r.MED)
+		}
+		if len(r.Communities) > 0 {
+			fmt.Fprintf(w, "  Communities: %s\n", strings.Join(values(r.Communities), " "))
+		}
+		if len(r.ExtendedCommunities) > 0 {
+			fmt.Fprintf(w, "  Extended Communities: %s\n", strings.Join(values(r.ExtendedCommunities), " "))
+		}
+		if len(r.LargeCommunities) > 0 {
+			fmt.Fprintf(w, "  Large Communities: %s\n", strings.Join(values(r.LargeCommunities), " "))
+		}
+		if r.FirstSeen != "" {
+			fmt.Fprintf(w, "  First Seen: %s\n", r.FirstSeen)
+		}
+		if r.UpdatedAt != "" && r.UpdatedAt != r.FirstSeen {
+			fmt.Fprintf(w, "  Last Update: %s\n", r.UpdatedAt)
+		}
+		io.WriteString(w, "\n")
+	}
+}
@@ -0,0 +1,173 @@
+// Package render produces text renderings of route lookups in the style of
+// router command-line interfaces, for operators to paste into tickets.
+package render
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// Lookup is the result of a route lookup.
+type Lookup struct {
+	// Prefix is the queried prefix, or the matched one for longest-prefix
+	// lookups.
+	Prefix string
+	Router model.RouterSummary
+	// Routes are ordered by prefix and path ID; path ID 0 is the best path.
+	Routes []model.Route
+	// Now is the time route ages are computed at: the lookup time of
+	// point-in-time lookups.
+	Now time.Time
+}
+
+// Renderer writes a text rendering of a lookup.
+type Renderer func(w io.Writer, l Lookup)
+
+// Default is the name of the renderer used for the plain_text field of
+// lookup responses and for text/plain requests without a format.
+const Default = "text"
+
+var renderers = map[string]Renderer{
+	Default: Plain,
+	"iosxr": IOSXR,
+	"junos": Junos,
+	"bird":  BIRD,
+}
+
+// Get returns the renderer registered under name.
+func Get(name string) (Renderer, bool) {
+	r, ok := renderers[name]
+	return r, ok
+}
+
+// Names returns the names of all renderers, sorted.
+func Names() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// String renders l with r.
+func String(r Renderer, l Lookup) string {
+	var b strings.Builder
+	r(&b, l)
+	return b.String()
+}
+
+// prefixGroup is the routes of one prefix.
+type prefixGroup struct {
+	prefix string
+	routes []model.Route
+}
+
+// groupByPrefix splits routes ordered by prefix into one group per prefix.
+func groupByPrefix(routes []model.Route) []prefixGroup {
+	var groups []prefixGroup
+	for _, r := range routes {
+		if n := len(groups); n > 0 && groups[n-1].prefix == r.Prefix {
+			groups[n-1].routes = append(groups[n-1].routes, r)
+			continue
+		}
+		groups = append(groups, prefixGroup{prefix: r.Prefix, routes: []model.Route{r}})
+	}
+	return groups
+}
+
+// isIPv6 reports whether prefix is an IPv6 prefix.
+func isIPv6(prefix string) bool {
+	p, err := netip.ParsePrefix(prefix)
+	return err == nil && p.Addr().Is6() && !p.Addr().Is4In6()
+}
+
+// asPathParts renders the segments of an AS path, with AS sets formatted
+// by set.
+func asPathParts(path []any, set func([]string) string) []string {
+	parts := make([]string, len(path))
+	for i, seg := range path {
+		if members, ok := seg.([]any); ok {
+			nums := make([]string, len(members))
+			for j, n := range members {
+				nums[j] = asn(n)
+			}
+			parts[i] = set(nums)
+			continue
+		}
+		parts[i] = asn(seg)
+	}
+	return parts
+}
+
+// asn formats an AS number decoded from JSON or read from the database.
+func asn(v any) string {
+	switch n := v.(type) {
+	case int:
+		return strconv.Itoa(n)
+	case int64:
+		return strconv.FormatInt(n, 10)
+	case float64:
+		return strconv.FormatInt(int64(n), 10)
+	default:
+		return fmt.Sprint(n)
+	}
+}
+
+// values returns the values of communities.
+func values(cs []model.Community) []string {
+	vals := make([]string, len(cs))
+	for i, c := range cs {
+		vals[i] = c.Value
+	}
+	return vals
+}
+
+// origin returns the origin attribute as IGP, EGP or incomplete.
+func origin(r model.Route) string {
+	if r.Origin == nil {
+		return ""
+	}
+	switch o := strings.ToLower(*r.Origin); o {
+	case "igp", "egp":
+		return strings.ToUpper(o)
+	default:
+		return o
+	}
+}
+
+// originCode returns the one-letter origin code: i, e or ?.
+func originCode(r model.Route) string {
+	switch origin(r) {
+	case "IGP":
+		return "i"
+	case "EGP":
+		return "e"
+	case "":
+		return ""
+	default:
+		return "?"
+	}
+}
+
+// firstSeen parses the first_seen timestamp of r.
+func firstSeen(r model.Route) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, r.FirstSeen)
+	return t, err == nil
+}
+
+// age returns how long before now r was first seen, never negative.
+func age(r model.Route, now time.Time) (time.Duration, bool) {
+	t, ok := firstSeen(r)
+	if !ok {
+		return 0, false
+	}
+	return max(now.Sub(t), 0).Truncate(time.Second), true
+}
@@ -0,0 +1,212 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// testLookup has a best and a second path for one prefix, with every
+// attribute set on the best path.
+func testLookup() Lookup {
+	return Lookup{
+		Prefix: "10.100.0.0/24",
+		Router: model.RouterSummary{ID: "10.0.0.2", DisplayName: "Alpha Core"},
+		Routes: []model.Route{
+			{
+				Prefix:              "10.100.0.0/24",
+				PathID:              0,
+				NextHop:             ptr("172.28.0.10"),
+				ASPath:              []any{65001.0, 174.0, []any{13335.0, 13336.0}},
+				Origin:              ptr("igp"),
+				LocalPref:           ptr(200),
+				MED:                 ptr(50),
+				OriginASN:           ptr(13335),
+				Communities:         []model.Community{{Type: "standard", Value: "174:100"}},
+				ExtendedCommunities: []model.Community{{Type: "extended", Value: "RT:64496:100"}},
+				LargeCommunities:    []model.Community{{Type: "large", Value: "65001:1:2"}},
+				FirstSeen:           "2026-01-28T19:00:00Z",
+				UpdatedAt:           "2026-01-30T22:00:00Z",
+			},
+			{
+				Prefix:    "10.100.0.0/24",
+				PathID:    1,
+				NextHop:   ptr("172.28.0.11"),
+				ASPath:    []any{65001.0, 3356.0, 13335.0},
+				Origin:    ptr("incomplete"),
+				FirstSeen: "2026-01-31T21:30:00Z",
+			},
+		},
+		Now: time.Date(2026, 1, 31, 22, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestPlain_NoRoutes(t *testing.T) {
+	result := String(Plain, Lookup{Prefix: "10.0.0.0/24", Router: model.RouterSummary{ID: "router1"}})
+	expected := "No routes found for 10.0.0.0/24 on router1\n"
+	if result != expected {
+		t.Fatalf("expected %q, got %q", expected, result)
+	}
+}
+
+func TestPlain_BestPath(t *testing.T) {
+	nh := "192.0.2.1"
+	origin := "igp"
+	routes := []model.Route{
+		{
+			Prefix:  "10.0.0.0/24",
+			PathID:  0,
+			NextHop: &nh,
+			ASPath:  []any{64500, 65000},
+			Origin:  &origin,
+		},
+		{
+			Prefix:  "10.0.0.0/24",
+			PathID:  1,
+			NextHop: &nh,
+			ASPath:  []any{64501, 65000},
+			Origin:  &origin,
+		},
+	}
+	result := String(Plain, Lookup{Prefix: "10.0.0.0/24", Router: model.RouterSummary{ID: "router1"}, Routes: routes})
+
+	// Path #1 with path_id=0 should be marked best even with multiple paths
+	if !strings.Contains(result, "Path #1 (best)") {
+		t.Fatalf("expected Path #1 to be marked as best, got:\n%s", result)
+	}
+	// Path #2 should NOT be marked best
+	if strings.Contains(result, "Path #2 (best)") {
+		t.Fatalf("Path #2 should not be marked as best, got:\n%s", result)
+	}
+}
+
+func TestPlainIsIndependentOfNow(t *testing.T) {
+	l := testLookup()
+	a := String(Plain, l)
+	l.Now = l.Now.Add(time.Hour)
+	if b := String(Plain, l); a != b {
+		t.Fatalf("rendering changed with the time:\n%s\n%s", a, b)
+	}
+	for _, want := range []string{
+		"  AS Path: 65001 174 {13335,13336}\n",
+		"  Origin AS: 13335\n",
+		"  Extended Communities: RT:64496:100\n",
+		"  Large Communities: 65001:1:2\n",
+		"  First Seen: 2026-01-28T19:00:00Z\n",
+		"  Last Update: 2026-01-30T22:00:00Z\n",
+	} {
+		if !strings.Contains(a, want) {
+			t.Errorf("missing %q in:\n%s", want, a)
+		}
+	}
+}
+
+func TestIOSXR(t *testing.T) {
+	want := `BGP routing table entry for 10.100.0.0/24
+Paths: (2 available, best #1)
+  Path #1: Received by speaker 0
+  65001 174 {13335,13336}
+    172.28.0.10
+      Origin IGP, metric 50, localpref 200, valid, best
+      Received Path ID 0, age 3d03h
+      Community: 174:100
+      Extended community: RT:64496:100
+      Large Community: 65001:1:2
+      Origin-AS: 13335
+  Path #2: Received by speaker 0
+  65001 3356 13335
+    172.28.0.11
+      Origin incomplete, valid
+      Received Path ID 1, age 00:30:00
+`
+	if got := String(IOSXR, testLookup()); got != want {
+		t.Errorf("unexpected rendering:\n%s", got)
+	}
+	if got := String(IOSXR, Lookup{Prefix: "10.0.0.0/8"}); got != "% Network not in table\n" {
+		t.Errorf("unexpected empty rendering %q", got)
+	}
+}
+
+func TestJunos(t *testing.T) {
+	want := `
+inet.0: 1 destinations, 2 routes
+10.100.0.0/24 (2 entries, 1 announced)
+       *BGP    Preference: 170/-201
+                Next hop: 172.28.0.10
+                State: <Active Ext>
+                Age: 3d 3:00:00 	Metric: 50
+                AS path: 65001 174 {13335 13336} I
+                Communities: 174:100 target:64496:100 large:65001:1:2
+                Localpref: 200
+                Origin AS: 13335
+        BGP    Preference: 170
+                Next hop: 172.28.0.11
+                State: <NotBest Ext>
+                Age: 30:00
+                AS path: 65001 3356 13335 ?
+`
+	if got := String(Junos, testLookup()); got != want {
+		t.Errorf("unexpected rendering:\n%s", got)
+	}
+}
+
+func TestBIRD(t *testing.T) {
+	want := "Table master4:\n" +
+		"10.100.0.0/24        unicast [10.0.0.2 2026-01-28 19:00:00] * (100) [AS13335i]\n" +
+		"\tvia 172.28.0.10\n" +
+		"\tType: BGP univ\n" +
+		"\tBGP.origin: IGP\n" +
+		"\tBGP.as_path: 65001 174 {13335 13336}\n" +
+		"\tBGP.next_hop: 172.28.0.10\n" +
+		"\tBGP.med: 50\n" +
+		"\tBGP.local_pref: 200\n" +
+		"\tBGP.community: (174, 100)\n" +
+		"\tBGP.ext_community: (rt, 64496, 100)\n" +
+		"\tBGP.large_community: (65001, 1, 2)\n" +
+		"                     unicast [10.0.0.2 2026-01-31 21:30:00] (100)\n" +
+		"\tvia 172.28.0.11\n" +
+		"\tType: BGP univ\n" +
+		"\tBGP.origin: Incomplete\n" +
+		"\tBGP.as_path: 65001 3356 13335\n" +
+		"\tBGP.next_hop: 172.28.0.11\n"
+	if got := String(BIRD, testLookup()); got != want {
+		t.Errorf("unexpected rendering:\n%s", got)
+	}
+}
+
+func TestAges(t *testing.T) {
+	tests := []struct {
+		d            time.Duration
+		cisco, junos string
+	}{
+		{42 * time.Second, "00:00:42", "0:42"},
+		{5*time.Hour + 3*time.Minute, "05:03:00", "5:03:00"},
+		{50 * time.Hour, "2d02h", "2d 2:00:00"},
+		{16 * 24 * time.Hour, "2w2d", "2w2d 0:00:00"},
+		{400 * 24 * time.Hour, "1y5w", "57w1d 0:00:00"},
+	}
+	for _, tt := range tests {
+		if got := ciscoAge(tt.d); got != tt.cisco {
+			t.Errorf("ciscoAge(%v) = %s, want %s", tt.d, got, tt.cisco)
+		}
+		if got := junosAge(tt.d); got != tt.junos {
+			t.Errorf("junosAge(%v) = %s, want %s", tt.d, got, tt.junos)
+		}
+	}
+}
+
+func TestRenderersAreRegistered(t *testing.T) {
+	for _, name := range Names() {
+		r, ok := Get(name)
+		if !ok || r == nil {
+			t.Errorf("renderer %s not registered", name)
+		}
+	}
+	if _, ok := Get(Default); !ok {
+		t.Errorf("default renderer %s not registered", Default)
+	}
+}
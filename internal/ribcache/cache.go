@@ -0,0 +1,398 @@
+// Package ribcache serves live route lookups from in-memory Patricia tries,
+// one per router, table and AFI. The tries are loaded from current_routes,
+// kept fresh by polling updated_at and withdraw events, and rebuilt
+// periodically. Lookups fall back to the database while the cache is
+// loading or when it has not synced recently.
+package ribcache
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+// pollOverlap re-reads changes this far before the previous poll, so rows
+// committed late by long ingest transactions are not missed. Replaying a
+// change is harmless.
+const pollOverlap = 10 * time.Second
+
+// Source is the database side of the cache, implemented by *store.DB.
+type Source interface {
+	Now(ctx context.Context) (time.Time, error)
+	StreamCurrentRoutes(ctx context.Context, since time.Time, fn func(store.TableRoute) error) error
+	ListWithdrawals(ctx context.Context, since time.Time) ([]store.Withdrawal, error)
+	ExactLookup(ctx context.Context, routerID, prefix string) ([]model.Route, error)
+	LPMLookup(ctx context.Context, routerID, ip string) ([]model.Route, error)
+	SubnetLookup(ctx context.Context, routerID, prefix string, limit int) ([]model.Route, bool, error)
+}
+
+// Options tunes cache refreshes.
+type Options struct {
+	// PollInterval is how often changes are read from the database.
+	PollInterval time.Duration
+	// ReloadInterval is how often the tries are rebuilt from scratch,
+	// dropping anything incremental polling missed.
+	ReloadInterval time.Duration
+	// MaxStaleness is how long after the last successful sync lookups are
+	// still served from memory.
+	MaxStaleness time.Duration
+}
+
+var (
+	cachedRoutes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "routebeacon",
+		Subsystem: "rib_cache",
+		Name:      "routes",
+		Help:      "Routes held in the in-memory RIB cache.",
+	})
+	lastSync = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "routebeacon",
+		Subsystem: "rib_cache",
+		Name:      "last_sync_timestamp_seconds",
+		Help:      "Time of the last successful RIB cache load or poll.",
+	})
+	lookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "routebeacon",
+		Subsystem: "rib_cache",
+		Name:      "lookups_total",
+		Help:      "Route lookups by the source that answered them.",
+	}, []string{"source"})
+)
+
+// Collectors returns the metrics of the cache for registration.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{cachedRoutes, lastSync, lookups}
+}
+
+// Cache is an in-memory copy of current_routes. It implements the live
+// route lookups of the API and is safe for concurrent use.
+type Cache struct {
+	src  Source
+	opts Options
+
+	mu       sync.RWMutex
+	routers  map[string][]*table // per router, ordered by table name and AFI
+	routes   int
+	ready    bool
+	cursor   time.Time // database time up to which changes were applied
+	lastSync time.Time
+}
+
+// table is the trie of one router, table and AFI.
+type table struct {
+	name string
+	afi  int
+	trie trie[[]entry]
+}
+
+// entry is one path of a prefix.
+type entry struct {
+	route     model.Route
+	updatedAt time.Time
+}
+
+// New returns an empty cache. Call Run to load and refresh it.
+func New(src Source, opts Options) *Cache {
+	return &Cache{src: src, opts: opts, routers: map[string][]*table{}}
+}
+
+// Run loads the cache and keeps it fresh until ctx is cancelled.
+func (c *Cache) Run(ctx context.Context) {
+	for {
+		err := c.reload(ctx)
+		if err == nil {
+			break
+		}
+		slog.Error("RIB cache load failed", slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.opts.PollInterval):
+		}
+	}
+
+	poll := time.NewTicker(c.opts.PollInterval)
+	defer poll.Stop()
+	reload := time.NewTicker(c.opts.ReloadInterval)
+	defer reload.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			if err := c.poll(ctx); err != nil && ctx.Err() == nil {
+				slog.Warn("RIB cache poll failed", slog.Any("error", err))
+			}
+		case <-reload.C:
+			if err := c.reload(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("RIB cache reload failed", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// reload rebuilds every trie from current_routes and swaps them in.
+func (c *Cache) reload(ctx context.Context) error {
+	start := time.Now()
+	now, err := c.src.Now(ctx)
+	if err != nil {
+		return err
+	}
+	next := &Cache{routers: map[string][]*table{}}
+	err = c.src.StreamCurrentRoutes(ctx, time.Time{}, func(tr store.TableRoute) error {
+		next.upsert(tr)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.routers, c.routes = next.routers, next.routes
+	c.cursor, c.lastSync, c.ready = now, time.Now(), true
+	c.mu.Unlock()
+
+	cachedRoutes.Set(float64(next.routes))
+	lastSync.SetToCurrentTime()
+	slog.Info("RIB cache loaded",
+		slog.Int("routes", next.routes),
+		slog.Int("routers", len(next.routers)),
+		slog.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// poll applies the announcements and withdrawals since the last sync.
+func (c *Cache) poll(ctx context.Context) error {
+	now, err := c.src.Now(ctx)
+	if err != nil {
+		return err
+	}
+	c.mu.RLock()
+	since := c.cursor.Add(-pollOverlap)
+	c.mu.RUnlock()
+
+	var updates []store.TableRoute
+	err = c.src.StreamCurrentRoutes(ctx, since, func(tr store.TableRoute) error {
+		updates = append(updates, tr)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	withdrawals, err := c.src.ListWithdrawals(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	for _, tr := range updates {
+		c.upsert(tr)
+	}
+	for _, w := range withdrawals {
+		c.withdraw(w)
+	}
+	c.cursor, c.lastSync = now, time.Now()
+	routes := c.routes
+	c.mu.Unlock()
+
+	cachedRoutes.Set(float64(routes))
+	lastSync.SetToCurrentTime()
+	return nil
+}
+
+// tableFor returns the trie of a router table, creating it if needed.
+// Callers hold the write lock.
+func (c *Cache) tableFor(routerID, name string, afi int) *table {
+	tables := c.routers[routerID]
+	i, found := slices.BinarySearchFunc(tables, table{name: name, afi: afi}, func(t *table, key table) int {
+		return cmp.Or(cmp.Compare(t.name, key.name), cmp.Compare(t.afi, key.afi))
+	})
+	if !found {
+		tables = slices.Insert(tables, i, &table{name: name, afi: afi})
+		c.routers[routerID] = tables
+	}
+	return tables[i]
+}
+
+// upsert stores a path unless the cache already holds a newer version.
+// Callers hold the write lock.
+func (c *Cache) upsert(tr store.TableRoute) {
+	t := c.tableFor(tr.RouterID, tr.TableName, tr.AFI)
+	entries, _ := t.trie.get(tr.Prefix)
+	i, found := slices.BinarySearchFunc(entries, tr.Route.PathID, func(e entry, id int64) int {
+		return cmp.Compare(e.route.PathID, id)
+	})
+	e := entry{route: tr.Route, updatedAt: tr.UpdatedAt}
+	if found {
+		if entries[i].updatedAt.After(tr.UpdatedAt) {
+			return
+		}
+		entries = slices.Clone(entries)
+		entries[i] = e
+	} else {
+		entries = slices.Insert(slices.Clone(entries), i, e)
+		c.routes++
+	}
+	t.trie.put(tr.Prefix, entries)
+}
+
+// withdraw removes a path unless it was announced again after the
+// withdrawal. Callers hold the write lock.
+func (c *Cache) withdraw(w store.Withdrawal) {
+	t := c.tableFor(w.RouterID, w.TableName, w.AFI)
+	entries, _ := t.trie.get(w.Prefix)
+	i, found := slices.BinarySearchFunc(entries, w.PathID, func(e entry, id int64) int {
+		return cmp.Compare(e.route.PathID, id)
+	})
+	if !found || entries[i].updatedAt.After(w.IngestTime) {
+		return
+	}
+	c.routes--
+	if len(entries) == 1 {
+		t.trie.delete(w.Prefix)
+		return
+	}
+	t.trie.put(w.Prefix, slices.Delete(slices.Clone(entries), i, i+1))
+}
+
+// fresh reports whether lookups may be served from memory. Callers hold a
+// read lock.
+func (c *Cache) fresh() bool {
+	return c.ready && time.Since(c.lastSync) <= c.opts.MaxStaleness
+}
+
+// tables returns the tries of a router for one address family. Callers hold
+// a read lock.
+func (c *Cache) tables(routerID string, addr netip.Addr) []*table {
+	afi := 4
+	if addr.Is6() {
+		afi = 6
+	}
+	var tables []*table
+	for _, t := range c.routers[routerID] {
+		if t.afi == afi {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// ExactLookup returns the routes of exactly prefix on a router.
+func (c *Cache) ExactLookup(ctx context.Context, routerID, prefix string) ([]model.Route, error) {
+	p, err := netip.ParsePrefix(prefix)
+	c.mu.RLock()
+	if err != nil || p != p.Masked() || !c.fresh() {
+		c.mu.RUnlock()
+		lookups.WithLabelValues("database").Inc()
+		return c.src.ExactLookup(ctx, routerID, prefix)
+	}
+	routes := []model.Route{}
+	for _, t := range c.tables(routerID, p.Addr()) {
+		entries, _ := t.trie.get(p)
+		routes = appendRoutes(routes, entries)
+	}
+	c.mu.RUnlock()
+	lookups.WithLabelValues("cache").Inc()
+
+	sortByPathID(routes)
+	return routes, nil
+}
+
+// LPMLookup returns the routes of the longest prefix containing ip on a
+// router, across all of its tables.
+func (c *Cache) LPMLookup(ctx context.Context, routerID, ip string) ([]model.Route, error) {
+	addr, err := netip.ParseAddr(ip)
+	c.mu.RLock()
+	if err != nil || addr.Zone() != "" || !c.fresh() {
+		c.mu.RUnlock()
+		lookups.WithLabelValues("database").Inc()
+		return c.src.LPMLookup(ctx, routerID, ip)
+	}
+	var (
+		best    netip.Prefix
+		matches [][]entry
+	)
+	for _, t := range c.tables(routerID, addr) {
+		p, entries, ok := t.trie.longestMatch(addr)
+		switch {
+		case !ok:
+		case !best.IsValid() || p.Bits() > best.Bits():
+			best, matches = p, [][]entry{entries}
+		case p.Bits() == best.Bits():
+			matches = append(matches, entries)
+		}
+	}
+	routes := []model.Route{}
+	for _, entries := range matches {
+		routes = appendRoutes(routes, entries)
+	}
+	c.mu.RUnlock()
+	lookups.WithLabelValues("cache").Inc()
+
+	sortByPathID(routes)
+	return routes, nil
+}
+
+// SubnetLookup returns the routes of prefix and its more-specifics on a
+// router, ordered by prefix and path ID, up to limit routes.
+func (c *Cache) SubnetLookup(ctx context.Context, routerID, prefix string, limit int) ([]model.Route, bool, error) {
+	p, err := netip.ParsePrefix(prefix)
+	c.mu.RLock()
+	if err != nil || p != p.Masked() || !c.fresh() {
+		c.mu.RUnlock()
+		lookups.WithLabelValues("database").Inc()
+		return c.src.SubnetLookup(ctx, routerID, prefix, limit)
+	}
+	type match struct {
+		prefix netip.Prefix
+		route  model.Route
+	}
+	var matches []match
+	for _, t := range c.tables(routerID, p.Addr()) {
+		n := 0
+		t.trie.walkWithin(p, func(q netip.Prefix, entries []entry) bool {
+			for _, e := range entries {
+				matches = append(matches, match{q, e.route})
+			}
+			n += len(entries)
+			return n <= limit
+		})
+	}
+	c.mu.RUnlock()
+	lookups.WithLabelValues("cache").Inc()
+
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return cmp.Or(store.ComparePrefix(a.prefix, b.prefix), cmp.Compare(a.route.PathID, b.route.PathID))
+	})
+	truncated := len(matches) > limit
+	if truncated {
+		matches = matches[:limit]
+	}
+	routes := make([]model.Route, len(matches))
+	for i, m := range matches {
+		routes[i] = m.route
+	}
+	return routes, truncated, nil
+}
+
+func appendRoutes(routes []model.Route, entries []entry) []model.Route {
+	for _, e := range entries {
+		routes = append(routes, e.route)
+	}
+	return routes
+}
+
+func sortByPathID(routes []model.Route) {
+	slices.SortStableFunc(routes, func(a, b model.Route) int {
+		return cmp.Compare(a.PathID, b.PathID)
+	})
+}
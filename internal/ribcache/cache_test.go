@@ -0,0 +1,219 @@
+package ribcache
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+var errFallback = errors.New("database fallback")
+
+// fakeSource serves rows and withdrawals from memory and fails lookups, so
+// tests notice when the cache falls back to the database.
+type fakeSource struct {
+	now         time.Time
+	rows        []store.TableRoute
+	withdrawals []store.Withdrawal
+}
+
+func (f *fakeSource) Now(context.Context) (time.Time, error) { return f.now, nil }
+
+func (f *fakeSource) StreamCurrentRoutes(_ context.Context, since time.Time, fn func(store.TableRoute) error) error {
+	for _, r := range f.rows {
+		if since.IsZero() || r.UpdatedAt.After(since) {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeSource) ListWithdrawals(_ context.Context, since time.Time) ([]store.Withdrawal, error) {
+	var out []store.Withdrawal
+	for _, w := range f.withdrawals {
+		if w.IngestTime.After(since) {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSource) ExactLookup(context.Context, string, string) ([]model.Route, error) {
+	return nil, errFallback
+}
+
+func (f *fakeSource) LPMLookup(context.Context, string, string) ([]model.Route, error) {
+	return nil, errFallback
+}
+
+func (f *fakeSource) SubnetLookup(context.Context, string, string, int) ([]model.Route, bool, error) {
+	return nil, false, errFallback
+}
+
+func row(table, prefix string, pathID int64, at time.Time) store.TableRoute {
+	p := netip.MustParsePrefix(prefix)
+	afi := 4
+	if p.Addr().Is6() {
+		afi = 6
+	}
+	return store.TableRoute{
+		RouterID:  "r1",
+		TableName: table,
+		AFI:       afi,
+		Prefix:    p,
+		UpdatedAt: at,
+		Route:     model.Route{Prefix: prefix, PathID: pathID},
+	}
+}
+
+func prefixes(routes []model.Route) []string {
+	out := make([]string, len(routes))
+	for i, r := range routes {
+		out[i] = r.Prefix
+	}
+	return out
+}
+
+func newTestCache(t *testing.T, src *fakeSource) *Cache {
+	t.Helper()
+	c := New(src, Options{PollInterval: time.Second, ReloadInterval: time.Hour, MaxStaleness: time.Minute})
+	if err := c.reload(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	return c
+}
+
+func TestCacheLookups(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	src := &fakeSource{now: t0, rows: []store.TableRoute{
+		row("global", "10.0.0.0/8", 0, t0),
+		row("global", "10.1.0.0/16", 2, t0),
+		row("global", "10.1.0.0/16", 1, t0),
+		row("global", "10.1.2.0/24", 0, t0),
+		row("vrf-a", "10.1.2.0/24", 7, t0),
+		row("global", "2001:db8::/32", 0, t0),
+	}}
+	c := newTestCache(t, src)
+	ctx := context.Background()
+
+	routes, err := c.ExactLookup(ctx, "r1", "10.1.0.0/16")
+	if err != nil || len(routes) != 2 || routes[0].PathID != 1 || routes[1].PathID != 2 {
+		t.Errorf("ExactLookup: %+v %v", routes, err)
+	}
+	routes, err = c.ExactLookup(ctx, "r2", "10.1.0.0/16")
+	if err != nil || len(routes) != 0 {
+		t.Errorf("ExactLookup unknown router: %+v %v", routes, err)
+	}
+
+	routes, err = c.LPMLookup(ctx, "r1", "10.1.2.3")
+	if err != nil || len(routes) != 2 || routes[0].PathID != 0 || routes[1].PathID != 7 {
+		t.Errorf("LPMLookup across tables: %+v %v", routes, err)
+	}
+	routes, err = c.LPMLookup(ctx, "r1", "2001:db8::1")
+	if err != nil || len(routes) != 1 || routes[0].Prefix != "2001:db8::/32" {
+		t.Errorf("LPMLookup IPv6: %+v %v", routes, err)
+	}
+
+	routes, truncated, err := c.SubnetLookup(ctx, "r1", "10.0.0.0/8", 10)
+	want := []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.0/24"}
+	if err != nil || truncated || !slices.Equal(prefixes(routes), want) {
+		t.Errorf("SubnetLookup: %v %v %v", prefixes(routes), truncated, err)
+	}
+	routes, truncated, err = c.SubnetLookup(ctx, "r1", "10.0.0.0/8", 2)
+	if err != nil || !truncated || len(routes) != 2 {
+		t.Errorf("SubnetLookup truncated: %v %v %v", prefixes(routes), truncated, err)
+	}
+
+	if _, err := c.ExactLookup(ctx, "r1", "10.1.0.1/16"); !errors.Is(err, errFallback) {
+		t.Errorf("unmasked prefix should fall back to the database, got %v", err)
+	}
+}
+
+func TestCacheFallbackWhenStale(t *testing.T) {
+	c := New(&fakeSource{}, Options{PollInterval: time.Second, ReloadInterval: time.Hour, MaxStaleness: time.Minute})
+	if _, err := c.LPMLookup(context.Background(), "r1", "10.0.0.1"); !errors.Is(err, errFallback) {
+		t.Errorf("unloaded cache should fall back to the database, got %v", err)
+	}
+
+	c = newTestCache(t, &fakeSource{})
+	c.lastSync = time.Now().Add(-2 * time.Minute)
+	if _, err := c.LPMLookup(context.Background(), "r1", "10.0.0.1"); !errors.Is(err, errFallback) {
+		t.Errorf("stale cache should fall back to the database, got %v", err)
+	}
+}
+
+func TestCachePoll(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	src := &fakeSource{now: t0, rows: []store.TableRoute{
+		row("global", "10.0.0.0/8", 0, t0.Add(-time.Minute)),
+		row("global", "192.0.2.0/24", 0, t0.Add(-time.Minute)),
+	}}
+	c := newTestCache(t, src)
+	ctx := context.Background()
+
+	t1 := t0.Add(time.Minute)
+	src.now = t1
+	src.rows = append(src.rows, row("global", "10.1.0.0/16", 0, t1))
+	src.withdrawals = []store.Withdrawal{
+		{RouterID: "r1", TableName: "global", AFI: 4, Prefix: netip.MustParsePrefix("192.0.2.0/24"), IngestTime: t1},
+	}
+	// An announcement newer than its withdrawal survives the replay.
+	src.rows = append(src.rows, row("global", "198.51.100.0/24", 0, t1))
+	src.withdrawals = append(src.withdrawals, store.Withdrawal{
+		RouterID: "r1", TableName: "global", AFI: 4, Prefix: netip.MustParsePrefix("198.51.100.0/24"), IngestTime: t0,
+	})
+	if err := c.poll(ctx); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+
+	routes, _, err := c.SubnetLookup(ctx, "r1", "0.0.0.0/0", 10)
+	want := []string{"10.0.0.0/8", "10.1.0.0/16", "198.51.100.0/24"}
+	if err != nil || !slices.Equal(prefixes(routes), want) {
+		t.Errorf("after poll: %v %v", prefixes(routes), err)
+	}
+	if c.routes != 3 {
+		t.Errorf("expected 3 cached routes, got %d", c.routes)
+	}
+}
+
+// TestCacheSubnetLookupMatchesStore checks that truncated subnet lookups
+// return the same routes from the cache as from the store, in address
+// order rather than the text order of the prefixes.
+func TestCacheSubnetLookupMatchesStore(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	all := []string{"10.100.0.0/16", "10.20.0.0/16", "10.0.0.0/8", "10.3.0.0/16", "10.2.0.0/16"}
+	src := &fakeSource{now: t0}
+	fixtures := store.Fixtures{Routers: []store.FixtureRouter{{ID: "r1"}}}
+	for _, p := range all {
+		src.rows = append(src.rows, row("global", p, 0, t0))
+		fixtures.Routes = append(fixtures.Routes, store.FixtureRoute{RouterID: "r1", Prefix: p, UpdatedAt: t0})
+	}
+	m, err := store.NewMemory(fixtures)
+	if err != nil {
+		t.Fatalf("NewMemory: %v", err)
+	}
+	c := newTestCache(t, src)
+	ctx := context.Background()
+
+	want := []string{"10.0.0.0/8", "10.2.0.0/16", "10.3.0.0/16", "10.20.0.0/16", "10.100.0.0/16"}
+	for limit := 1; limit <= len(want); limit++ {
+		cached, _, err := c.SubnetLookup(ctx, "r1", "10.0.0.0/8", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored, _, err := m.SubnetLookup(ctx, "r1", "10.0.0.0/8", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(prefixes(cached), want[:limit]) || !slices.Equal(prefixes(stored), want[:limit]) {
+			t.Errorf("limit %d: cache %v, store %v; want %v", limit, prefixes(cached), prefixes(stored), want[:limit])
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package ribcache
+
+import "net/netip"
+
+// trie is a path-compressed binary (Patricia) trie keyed by prefixes of a
+// single address family. Nodes without a value only join two subtrees, so
+// the trie holds at most two nodes per stored prefix.
+type trie[V any] struct {
+	root *node[V]
+	size int
+}
+
+type node[V any] struct {
+	prefix netip.Prefix // masked
+	value  V
+	set    bool
+	child  [2]*node[V]
+}
+
+// bit returns bit i of addr, counting from the most significant bit.
+func bit(addr netip.Addr, i int) int {
+	b := addr.AsSlice()
+	return int(b[i/8]>>(7-i%8)) & 1
+}
+
+// commonBits returns the length of the longest prefix shared by a and b.
+func commonBits(a, b netip.Prefix) int {
+	limit := min(a.Bits(), b.Bits())
+	ab, bb := a.Addr().AsSlice(), b.Addr().AsSlice()
+	n := 0
+	for i := 0; i < len(ab) && n < limit; i++ {
+		x := ab[i] ^ bb[i]
+		if x == 0 {
+			n += 8
+			continue
+		}
+		for x&0x80 == 0 {
+			n++
+			x <<= 1
+		}
+		break
+	}
+	return min(n, limit)
+}
+
+// get returns the value stored for p.
+func (t *trie[V]) get(p netip.Prefix) (V, bool) {
+	p = p.Masked()
+	n := t.root
+	for n != nil && n.prefix.Bits() <= p.Bits() {
+		if commonBits(n.prefix, p) < n.prefix.Bits() {
+			break
+		}
+		if n.prefix.Bits() == p.Bits() {
+			return n.value, n.set
+		}
+		n = n.child[bit(p.Addr(), n.prefix.Bits())]
+	}
+	var zero V
+	return zero, false
+}
+
+// put stores v for p, replacing any previous value.
+func (t *trie[V]) put(p netip.Prefix, v V) {
+	p = p.Masked()
+	np := &t.root
+	for {
+		n := *np
+		if n == nil {
+			*np = &node[V]{prefix: p, value: v, set: true}
+			t.size++
+			return
+		}
+		common := commonBits(n.prefix, p)
+		switch {
+		case common == n.prefix.Bits() && common == p.Bits():
+			if !n.set {
+				t.size++
+			}
+			n.value, n.set = v, true
+			return
+		case common == n.prefix.Bits():
+			np = &n.child[bit(p.Addr(), common)]
+			continue
+		case common == p.Bits():
+			parent := &node[V]{prefix: p, value: v, set: true}
+			parent.child[bit(n.prefix.Addr(), common)] = n
+			*np = parent
+		default:
+			glue := &node[V]{prefix: netip.PrefixFrom(p.Addr(), common).Masked()}
+			glue.child[bit(n.prefix.Addr(), common)] = n
+			glue.child[bit(p.Addr(), common)] = &node[V]{prefix: p, value: v, set: true}
+			*np = glue
+		}
+		t.size++
+		return
+	}
+}
+
+// delete removes the value stored for p, if any.
+func (t *trie[V]) delete(p netip.Prefix) {
+	var deleted bool
+	t.root, deleted = deleteNode(t.root, p.Masked())
+	if deleted {
+		t.size--
+	}
+}
+
+func deleteNode[V any](n *node[V], p netip.Prefix) (*node[V], bool) {
+	if n == nil || n.prefix.Bits() > p.Bits() || commonBits(n.prefix, p) < n.prefix.Bits() {
+		return n, false
+	}
+	if n.prefix.Bits() == p.Bits() {
+		if !n.set {
+			return n, false
+		}
+		var zero V
+		n.value, n.set = zero, false
+		return compact(n), true
+	}
+	c := bit(p.Addr(), n.prefix.Bits())
+	child, deleted := deleteNode(n.child[c], p)
+	n.child[c] = child
+	if !deleted {
+		return n, false
+	}
+	return compact(n), true
+}
+
+// compact removes n if it holds no value and joins fewer than two subtrees.
+func compact[V any](n *node[V]) *node[V] {
+	if n.set {
+		return n
+	}
+	switch {
+	case n.child[0] == nil:
+		return n.child[1]
+	case n.child[1] == nil:
+		return n.child[0]
+	}
+	return n
+}
+
+// longestMatch returns the most specific stored prefix containing addr.
+func (t *trie[V]) longestMatch(addr netip.Addr) (netip.Prefix, V, bool) {
+	var (
+		best  *node[V]
+		n     = t.root
+		probe = netip.PrefixFrom(addr, addr.BitLen())
+	)
+	for n != nil && commonBits(n.prefix, probe) >= n.prefix.Bits() {
+		if n.set {
+			best = n
+		}
+		if n.prefix.Bits() == addr.BitLen() {
+			break
+		}
+		n = n.child[bit(addr, n.prefix.Bits())]
+	}
+	if best == nil {
+		var zero V
+		return netip.Prefix{}, zero, false
+	}
+	return best.prefix, best.value, true
+}
+
+// walkWithin calls fn for every stored prefix equal to or more specific
+// than p, in address order with covering prefixes first. It stops when fn
+// returns false.
+func (t *trie[V]) walkWithin(p netip.Prefix, fn func(netip.Prefix, V) bool) {
+	p = p.Masked()
+	n := t.root
+	for n != nil {
+		if n.prefix.Bits() >= p.Bits() {
+			if commonBits(n.prefix, p) >= p.Bits() {
+				walk(n, fn)
+			}
+			return
+		}
+		if commonBits(n.prefix, p) < n.prefix.Bits() {
+			return
+		}
+		n = n.child[bit(p.Addr(), n.prefix.Bits())]
+	}
+}
+
+func walk[V any](n *node[V], fn func(netip.Prefix, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	if n.set && !fn(n.prefix, n.value) {
+		return false
+	}
+	return walk(n.child[0], fn) && walk(n.child[1], fn)
+}
@@ -0,0 +1,136 @@
+package ribcache
+
+import (
+	"math/rand/v2"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/store"
+)
+
+func TestTrieBasic(t *testing.T) {
+	var tr trie[string]
+	for _, s := range []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.2.0.0/16", "192.0.2.0/24", "0.0.0.0/0"} {
+		tr.put(netip.MustParsePrefix(s), s)
+	}
+	if tr.size != 6 {
+		t.Fatalf("expected size 6, got %d", tr.size)
+	}
+
+	if v, ok := tr.get(netip.MustParsePrefix("10.1.0.0/16")); !ok || v != "10.1.0.0/16" {
+		t.Errorf("get 10.1.0.0/16: %q %v", v, ok)
+	}
+	if _, ok := tr.get(netip.MustParsePrefix("10.0.0.0/9")); ok {
+		t.Error("get 10.0.0.0/9: unexpected match")
+	}
+
+	lpm := []struct{ addr, want string }{
+		{"10.1.2.3", "10.1.2.0/24"},
+		{"10.1.3.1", "10.1.0.0/16"},
+		{"10.3.0.1", "10.0.0.0/8"},
+		{"172.16.0.1", "0.0.0.0/0"},
+	}
+	for _, tt := range lpm {
+		p, v, ok := tr.longestMatch(netip.MustParseAddr(tt.addr))
+		if !ok || p.String() != tt.want || v != tt.want {
+			t.Errorf("longestMatch(%s) = %s %q %v, want %s", tt.addr, p, v, ok, tt.want)
+		}
+	}
+
+	var within []string
+	tr.walkWithin(netip.MustParsePrefix("10.0.0.0/8"), func(p netip.Prefix, _ string) bool {
+		within = append(within, p.String())
+		return true
+	})
+	want := []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.2.0.0/16"}
+	if !slices.Equal(within, want) {
+		t.Errorf("walkWithin 10/8 = %v, want %v", within, want)
+	}
+
+	within = nil
+	tr.walkWithin(netip.MustParsePrefix("10.1.0.0/15"), func(p netip.Prefix, _ string) bool {
+		within = append(within, p.String())
+		return len(within) < 1
+	})
+	if !slices.Equal(within, []string{"10.1.0.0/16"}) {
+		t.Errorf("walkWithin 10.0/15 with early stop = %v", within)
+	}
+
+	tr.delete(netip.MustParsePrefix("10.1.0.0/16"))
+	tr.delete(netip.MustParsePrefix("10.1.0.0/16"))
+	if tr.size != 5 {
+		t.Errorf("expected size 5 after delete, got %d", tr.size)
+	}
+	if p, _, _ := tr.longestMatch(netip.MustParseAddr("10.1.3.1")); p.String() != "10.0.0.0/8" {
+		t.Errorf("longestMatch after delete = %s", p)
+	}
+}
+
+// TestTrieRandom checks the trie against a brute-force map.
+func TestTrieRandom(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	randPrefix := func() netip.Prefix {
+		var b [16]byte
+		b[0], b[1] = 0x20, 0x01
+		b[2] = byte(rng.IntN(4))
+		b[3] = byte(rng.IntN(256))
+		return netip.PrefixFrom(netip.AddrFrom16(b), 16+rng.IntN(17)).Masked()
+	}
+
+	var tr trie[int]
+	ref := map[netip.Prefix]int{}
+	for i := range 5000 {
+		p := randPrefix()
+		if rng.IntN(3) == 0 {
+			tr.delete(p)
+			delete(ref, p)
+		} else {
+			tr.put(p, i)
+			ref[p] = i
+		}
+	}
+	if tr.size != len(ref) {
+		t.Fatalf("size %d, reference %d", tr.size, len(ref))
+	}
+
+	for p, v := range ref {
+		if got, ok := tr.get(p); !ok || got != v {
+			t.Fatalf("get(%s) = %d %v, want %d", p, got, ok, v)
+		}
+	}
+
+	for range 1000 {
+		addr := randPrefix().Addr()
+		var want netip.Prefix
+		for p := range ref {
+			if p.Contains(addr) && (!want.IsValid() || p.Bits() > want.Bits()) {
+				want = p
+			}
+		}
+		got, _, ok := tr.longestMatch(addr)
+		if ok != want.IsValid() || got != want {
+			t.Fatalf("longestMatch(%s) = %s %v, want %s", addr, got, ok, want)
+		}
+
+		q := randPrefix()
+		var wantWithin []netip.Prefix
+		for p := range ref {
+			if p.Bits() >= q.Bits() && q.Contains(p.Addr()) {
+				wantWithin = append(wantWithin, p)
+			}
+		}
+		var gotWithin []netip.Prefix
+		tr.walkWithin(q, func(p netip.Prefix, _ int) bool {
+			gotWithin = append(gotWithin, p)
+			return true
+		})
+		if !slices.IsSortedFunc(gotWithin, store.ComparePrefix) {
+			t.Fatalf("walkWithin(%s) not in order: %v", q, gotWithin)
+		}
+		slices.SortFunc(wantWithin, store.ComparePrefix)
+		if !slices.Equal(gotWithin, wantWithin) {
+			t.Fatalf("walkWithin(%s) = %v, want %v", q, gotWithin, wantWithin)
+		}
+	}
+}
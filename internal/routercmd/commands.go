@@ -0,0 +1,127 @@
+// Package routercmd runs a fixed allow-list of read-only show commands on
+// routers over SSH. Commands are chosen by name and take a single address
+// or prefix argument, which is validated and re-rendered in canonical form
+// before it reaches a router, so callers cannot inject other commands.
+package routercmd
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+)
+
+// Platforms lists the supported router operating systems.
+var Platforms = []string{"iosxr", "junos", "eos"}
+
+// ErrUnknownCommand is returned for commands missing from the allow-list.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Command is an allow-listed command.
+type Command struct {
+	Name        string
+	Description string
+	// Prefixes reports whether the argument may be a prefix; otherwise
+	// it must be an address.
+	Prefixes bool
+	// render returns the command line for a platform; v6 is set for IPv6
+	// arguments.
+	render func(platform, arg string, v6 bool) string
+}
+
+// Commands is the allow-list.
+var Commands = []Command{
+	{
+		Name:        "bgp",
+		Description: "show bgp <prefix|address>",
+		Prefixes:    true,
+		render: func(platform, arg string, v6 bool) string {
+			switch platform {
+			case "iosxr":
+				return "show bgp " + afi(v6) + " unicast " + arg
+			case "junos":
+				return "show route " + arg + " protocol bgp detail"
+			default:
+				return "show " + ipOrIPv6(v6) + " bgp " + arg
+			}
+		},
+	},
+	{
+		Name:        "route",
+		Description: "show route <address>",
+		render: func(platform, arg string, v6 bool) string {
+			switch platform {
+			case "iosxr":
+				return "show route " + afi(v6) + " " + arg
+			case "junos":
+				return "show route " + arg + " best detail"
+			default:
+				return "show " + ipOrIPv6(v6) + " route " + arg
+			}
+		},
+	},
+}
+
+func afi(v6 bool) string {
+	if v6 {
+		return "ipv6"
+	}
+	return "ipv4"
+}
+
+func ipOrIPv6(v6 bool) string {
+	if v6 {
+		return "ipv6"
+	}
+	return "ip"
+}
+
+// Lookup returns the allow-listed command called name.
+func Lookup(name string) (Command, bool) {
+	i := slices.IndexFunc(Commands, func(c Command) bool { return c.Name == name })
+	if i < 0 {
+		return Command{}, false
+	}
+	return Commands[i], true
+}
+
+// CanonicalArg validates arg for c and returns it in canonical form:
+// addresses without zones or IPv4-mapped forms, and prefixes masked to
+// their length.
+func (c Command) CanonicalArg(arg string) (string, error) {
+	canonical, _, err := c.canonical(arg)
+	return canonical, err
+}
+
+func (c Command) canonical(arg string) (string, bool, error) {
+	if addr, err := netip.ParseAddr(arg); err == nil && addr.Zone() == "" {
+		addr = addr.Unmap()
+		return addr.String(), addr.Is6(), nil
+	}
+	if !c.Prefixes {
+		return "", false, fmt.Errorf("%q is not an IP address", arg)
+	}
+	p, err := netip.ParsePrefix(arg)
+	if err != nil {
+		return "", false, fmt.Errorf("%q is not an IP address or prefix", arg)
+	}
+	if p.Addr().Is4In6() && p.Bits() >= 96 {
+		p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+	}
+	p = p.Masked()
+	return p.String(), p.Addr().Is6(), nil
+}
+
+// Render returns the command line running command name with arg on
+// platform.
+func Render(platform, name, arg string) (string, error) {
+	c, ok := Lookup(name)
+	if !ok {
+		return "", ErrUnknownCommand
+	}
+	canonical, v6, err := c.canonical(arg)
+	if err != nil {
+		return "", err
+	}
+	return c.render(platform, canonical, v6), nil
+}
@@ -0,0 +1,155 @@
+package routercmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Errors returned by Proxy.Run.
+var (
+	ErrNoRouter = errors.New("router commands not available for router")
+	ErrBusy     = errors.New("router busy")
+)
+
+// Output is the output of a command.
+type Output struct {
+	Text string
+	// Truncated is set when output beyond MaxOutput was dropped.
+	Truncated bool
+}
+
+// Runner runs command lines on a router. Run must return promptly when ctx
+// is done.
+type Runner interface {
+	Run(ctx context.Context, line string) (*Output, error)
+}
+
+// Result is a command run through the proxy.
+type Result struct {
+	Output
+	// Line is the command line sent to the router.
+	Line       string
+	ExecutedAt time.Time
+	// Cached is set when the output was served from the cache.
+	Cached bool
+}
+
+// Options are the settings of a Proxy.
+type Options struct {
+	// Timeout bounds each command.
+	Timeout time.Duration
+	// CacheTTL is how long outputs are reused; 0 disables caching.
+	CacheTTL time.Duration
+	// MaxConcurrent is the most commands running on a router at a time.
+	MaxConcurrent int
+}
+
+// Proxy runs allow-listed commands on registered routers, bounding their
+// duration and concurrency and caching their output. It is safe for
+// concurrent use once populated.
+type Proxy struct {
+	opts    Options
+	routers map[string]*router
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[cacheKey]*Result
+}
+
+type router struct {
+	platform string
+	runner   Runner
+	sem      chan struct{}
+}
+
+type cacheKey struct {
+	routerID, line string
+}
+
+// New returns a proxy without routers.
+func New(opts Options) *Proxy {
+	return &Proxy{
+		opts:    opts,
+		routers: map[string]*router{},
+		now:     time.Now,
+		cache:   map[cacheKey]*Result{},
+	}
+}
+
+// Register adds a router running platform, reached through runner. It must
+// not be called concurrently with Run.
+func (p *Proxy) Register(routerID, platform string, runner Runner) {
+	p.routers[routerID] = &router{platform: platform, runner: runner, sem: make(chan struct{}, p.opts.MaxConcurrent)}
+}
+
+// Run runs the allow-listed command name with arg on routerID. Argument
+// errors are returned before the router is looked up.
+func (p *Proxy) Run(ctx context.Context, routerID, name, arg string) (*Result, error) {
+	c, ok := Lookup(name)
+	if !ok {
+		return nil, ErrUnknownCommand
+	}
+	canonical, v6, err := c.canonical(arg)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := p.routers[routerID]
+	if !ok {
+		return nil, ErrNoRouter
+	}
+	line := c.render(r.platform, canonical, v6)
+
+	key := cacheKey{routerID, line}
+	if res := p.cached(key); res != nil {
+		return res, nil
+	}
+
+	select {
+	case r.sem <- struct{}{}:
+		defer func() { <-r.sem }()
+	default:
+		return nil, ErrBusy
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+	executedAt := p.now()
+	out, err := r.runner.Run(ctx, line)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Output: *out, Line: line, ExecutedAt: executedAt}
+	p.store(key, res)
+	return res, nil
+}
+
+// cached returns a copy of the unexpired cache entry of key, or nil.
+func (p *Proxy) cached(key cacheKey) *Result {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	res, ok := p.cache[key]
+	if !ok || p.now().Sub(res.ExecutedAt) >= p.opts.CacheTTL {
+		return nil
+	}
+	c := *res
+	c.Cached = true
+	return &c
+}
+
+// store caches res, dropping expired entries.
+func (p *Proxy) store(key cacheKey, res *Result) {
+	if p.opts.CacheTTL <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for k, r := range p.cache {
+		if now.Sub(r.ExecutedAt) >= p.opts.CacheTTL {
+			delete(p.cache, k)
+		}
+	}
+	p.cache[key] = res
+}
@@ -0,0 +1,151 @@
+package routercmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		platform, command, arg string
+		want                   string
+	}{
+		{"iosxr", "bgp", "192.0.2.0/24", "show bgp ipv4 unicast 192.0.2.0/24"},
+		{"iosxr", "bgp", "2001:db8::1:0/112", "show bgp ipv6 unicast 2001:db8::1:0/112"},
+		{"iosxr", "route", "192.0.2.1", "show route ipv4 192.0.2.1"},
+		{"junos", "bgp", "192.0.2.1", "show route 192.0.2.1 protocol bgp detail"},
+		{"junos", "route", "2001:db8::1", "show route 2001:db8::1 best detail"},
+		{"eos", "bgp", "2001:db8::/32", "show ipv6 bgp 2001:db8::/32"},
+		{"eos", "route", "192.0.2.1", "show ip route 192.0.2.1"},
+		// Arguments are canonicalised.
+		{"iosxr", "bgp", "192.0.2.77/24", "show bgp ipv4 unicast 192.0.2.0/24"},
+		{"iosxr", "route", "::ffff:192.0.2.1", "show route ipv4 192.0.2.1"},
+		{"eos", "bgp", "::ffff:192.0.2.0/120", "show ip bgp 192.0.2.0/24"},
+		{"junos", "route", "2001:DB8:0:0::1", "show route 2001:db8::1 best detail"},
+	}
+	for _, tt := range tests {
+		got, err := Render(tt.platform, tt.command, tt.arg)
+		if err != nil || got != tt.want {
+			t.Errorf("Render(%q, %q, %q) = %q, %v; want %q", tt.platform, tt.command, tt.arg, got, err, tt.want)
+		}
+	}
+}
+
+func TestRenderRejectsArguments(t *testing.T) {
+	for _, tt := range []struct{ command, arg string }{
+		{"route", "192.0.2.0/24"},
+		{"route", ""},
+		{"route", "fe80::1%eth0"},
+		{"bgp", "192.0.2.1 | include"},
+		{"bgp", "192.0.2.1\nreload"},
+		{"bgp", "example.com"},
+		{"bgp", "192.0.2.0/33"},
+	} {
+		if line, err := Render("iosxr", tt.command, tt.arg); err == nil {
+			t.Errorf("Render(%q, %q) = %q, expected an error", tt.command, tt.arg, line)
+		}
+	}
+	if _, err := Render("iosxr", "reload", "192.0.2.1"); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("expected ErrUnknownCommand, got %v", err)
+	}
+}
+
+// blockingRunner counts the lines it runs and blocks until release is
+// closed or ctx is done.
+type blockingRunner struct {
+	mu      sync.Mutex
+	lines   []string
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingRunner) Run(ctx context.Context, line string) (*Output, error) {
+	b.mu.Lock()
+	b.lines = append(b.lines, line)
+	b.mu.Unlock()
+	if b.started != nil {
+		b.started <- struct{}{}
+	}
+	if b.release != nil {
+		select {
+		case <-b.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return &Output{Text: line}, nil
+}
+
+func TestProxyCaches(t *testing.T) {
+	runner := &blockingRunner{}
+	p := New(Options{Timeout: time.Second, CacheTTL: time.Minute, MaxConcurrent: 1})
+	p.Register("r1", "eos", runner)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	run := func() *Result {
+		t.Helper()
+		res, err := p.Run(context.Background(), "r1", "route", "192.0.2.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	if res := run(); res.Cached || res.Line != "show ip route 192.0.2.1" {
+		t.Fatalf("unexpected first result %+v", res)
+	}
+	// The same command in another spelling hits the cache.
+	res, err := p.Run(context.Background(), "r1", "route", "::ffff:192.0.2.1")
+	if err != nil || !res.Cached || !res.ExecutedAt.Equal(now) {
+		t.Fatalf("expected a cached result, got %+v, %v", res, err)
+	}
+	now = now.Add(time.Minute)
+	if res := run(); res.Cached {
+		t.Error("expected the entry to expire")
+	}
+	if len(runner.lines) != 2 {
+		t.Errorf("expected 2 runs, got %q", runner.lines)
+	}
+}
+
+func TestProxyLimitsConcurrency(t *testing.T) {
+	runner := &blockingRunner{started: make(chan struct{}), release: make(chan struct{})}
+	p := New(Options{Timeout: time.Minute, MaxConcurrent: 1})
+	p.Register("r1", "iosxr", runner)
+
+	done := make(chan error)
+	go func() {
+		_, err := p.Run(context.Background(), "r1", "route", "192.0.2.1")
+		done <- err
+	}()
+	<-runner.started
+	if _, err := p.Run(context.Background(), "r1", "route", "192.0.2.2"); !errors.Is(err, ErrBusy) {
+		t.Errorf("expected ErrBusy, got %v", err)
+	}
+	close(runner.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProxyTimeout(t *testing.T) {
+	p := New(Options{Timeout: 50 * time.Millisecond, MaxConcurrent: 1})
+	p.Register("r1", "iosxr", &blockingRunner{release: make(chan struct{})})
+	if _, err := p.Run(context.Background(), "r1", "bgp", "192.0.2.0/24"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestProxyUnknownRouter(t *testing.T) {
+	p := New(Options{Timeout: time.Second, MaxConcurrent: 1})
+	if _, err := p.Run(context.Background(), "r1", "route", "192.0.2.1"); !errors.Is(err, ErrNoRouter) {
+		t.Errorf("expected ErrNoRouter, got %v", err)
+	}
+	// Arguments are validated first.
+	if _, err := p.Run(context.Background(), "r1", "route", "bogus"); err == nil || errors.Is(err, ErrNoRouter) {
+		t.Errorf("expected an argument error, got %v", err)
+	}
+}
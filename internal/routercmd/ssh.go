@@ -0,0 +1,138 @@
+package routercmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// MaxOutput bounds the output kept of a command.
+const MaxOutput = 1 << 20
+
+// SSH runs commands on a router over SSH, one connection per command.
+type SSH struct {
+	// Address is the router's SSH endpoint, host:port.
+	Address string
+	User    string
+	Auth    []ssh.AuthMethod
+	// HostKey is the router's pinned host key; other keys are rejected.
+	HostKey ssh.PublicKey
+}
+
+// SSHOptions are the settings of NewSSH.
+type SSHOptions struct {
+	Address        string
+	User           string
+	Password       string
+	PrivateKeyFile string
+	// HostKey is the router's host key in authorized_keys format, such as
+	// "ssh-ed25519 AAAA...".
+	HostKey string
+}
+
+// NewSSH returns an SSH runner authenticating with the private key in
+// opts.PrivateKeyFile, the password, or both.
+func NewSSH(opts SSHOptions) (*SSH, error) {
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(opts.HostKey))
+	if err != nil {
+		return nil, fmt.Errorf("host key: %w", err)
+	}
+	s := &SSH{Address: opts.Address, User: opts.User, HostKey: hostKey}
+	if opts.PrivateKeyFile != "" {
+		pem, err := os.ReadFile(opts.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(pem)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opts.PrivateKeyFile, err)
+		}
+		s.Auth = append(s.Auth, ssh.PublicKeys(signer))
+	}
+	if opts.Password != "" {
+		s.Auth = append(s.Auth, ssh.Password(opts.Password))
+	}
+	if len(s.Auth) == 0 {
+		return nil, errors.New("no password or private key")
+	}
+	return s, nil
+}
+
+// Run implements Runner. Output beyond MaxOutput is dropped. A non-zero
+// exit status is not an error: routers report failures in the output.
+func (s *SSH) Run(ctx context.Context, line string) (*Output, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Address)
+	if err != nil {
+		return nil, s.err(ctx, err)
+	}
+	defer conn.Close()
+	// Closing the connection aborts the handshake and the session when
+	// the context is done.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, s.Address, &ssh.ClientConfig{
+		User:            s.User,
+		Auth:            s.Auth,
+		HostKeyCallback: ssh.FixedHostKey(s.HostKey),
+	})
+	if err != nil {
+		return nil, s.err(ctx, err)
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, s.err(ctx, err)
+	}
+	defer session.Close()
+
+	out := &limitedBuffer{limit: MaxOutput}
+	session.Stdout = out
+	session.Stderr = out
+	err = session.Run(line)
+	var exitErr *ssh.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, s.err(ctx, err)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return &Output{Text: string(out.buf), Truncated: out.truncated}, nil
+}
+
+// err prefers the context's error, since a cancelled command surfaces as
+// a closed connection.
+func (s *SSH) err(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("ssh %s: %w", s.Address, err)
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest. It is written by the stdout and stderr copiers concurrently.
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := min(len(p), b.limit-len(b.buf))
+	b.buf = append(b.buf, p[:n]...)
+	if n < len(p) {
+		b.truncated = true
+	}
+	return len(p), nil
+}
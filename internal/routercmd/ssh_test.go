@@ -0,0 +1,223 @@
+package routercmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testServer is a local SSH server standing in for a router. It accepts
+// the password "secret" and answers exec requests from commands; a command
+// named "sleep" blocks until the connection closes.
+type testServer struct {
+	addr     string
+	hostKey  ssh.PublicKey
+	commands map[string]string
+
+	mu    sync.Mutex
+	lines []string
+}
+
+func newTestServer(t *testing.T, commands map[string]string) *testServer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if c.User() == "lg" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	s := &testServer{addr: l.Addr().String(), hostKey: signer.PublicKey(), commands: commands}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, config)
+		}
+	}()
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+	sc, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	defer sc.Close()
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		ch, requests, err := nc.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(ch, requests)
+	}
+}
+
+func (s *testServer) session(ch ssh.Channel, requests <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range requests {
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+		var payload struct{ Command string }
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			req.Reply(false, nil)
+			return
+		}
+		req.Reply(true, nil)
+		s.mu.Lock()
+		s.lines = append(s.lines, payload.Command)
+		s.mu.Unlock()
+
+		status := uint32(0)
+		out, ok := s.commands[payload.Command]
+		switch {
+		case payload.Command == "sleep":
+			// Wait for the client to go away.
+			for range requests {
+			}
+			return
+		case !ok:
+			ch.Stderr().Write([]byte("% Invalid input detected\n"))
+			status = 1
+		default:
+			ch.Write([]byte(out))
+		}
+		ch.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, status))
+		return
+	}
+}
+
+func (s *testServer) runner(t *testing.T, password string) *SSH {
+	t.Helper()
+	r, err := NewSSH(SSHOptions{
+		Address:  s.addr,
+		User:     "lg",
+		Password: password,
+		HostKey:  string(ssh.MarshalAuthorizedKey(s.hostKey)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestSSHRun(t *testing.T) {
+	const line = "show route ipv4 192.0.2.1"
+	s := newTestServer(t, map[string]string{line: "Routing entry for 192.0.2.0/24\n"})
+	r := s.runner(t, "secret")
+
+	out, err := r.Run(context.Background(), line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Text != "Routing entry for 192.0.2.0/24\n" || out.Truncated {
+		t.Errorf("unexpected output %+v", out)
+	}
+
+	// A failing command is reported in the output, like on a router.
+	out, err = r.Run(context.Background(), "show bogus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Text, "Invalid input") {
+		t.Errorf("expected the error output, got %q", out.Text)
+	}
+}
+
+func TestSSHTruncatesOutput(t *testing.T) {
+	s := newTestServer(t, map[string]string{"show big": strings.Repeat("x", MaxOutput+100)})
+	out, err := s.runner(t, "secret").Run(context.Background(), "show big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Text) != MaxOutput || !out.Truncated {
+		t.Errorf("expected %d bytes truncated, got %d (truncated %v)", MaxOutput, len(out.Text), out.Truncated)
+	}
+}
+
+func TestSSHRejectsWrongCredentials(t *testing.T) {
+	s := newTestServer(t, nil)
+	if _, err := s.runner(t, "wrong").Run(context.Background(), "show version"); err == nil {
+		t.Fatal("expected authentication to fail")
+	}
+}
+
+func TestSSHRejectsUnknownHostKey(t *testing.T) {
+	s := newTestServer(t, map[string]string{"show version": "ok"})
+	r := s.runner(t, "secret")
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.HostKey, err = ssh.NewPublicKey(pub); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Run(context.Background(), "show version"); err == nil || !strings.Contains(err.Error(), "host key") {
+		t.Fatalf("expected a host key mismatch, got %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.lines) != 0 {
+		t.Errorf("command reached an unverified server: %q", s.lines)
+	}
+}
+
+func TestSSHTimeout(t *testing.T) {
+	s := newTestServer(t, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := s.runner(t, "secret").Run(ctx, "sleep")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Run returned after %v", d)
+	}
+}
+
+func TestNewSSHRequiresCredentials(t *testing.T) {
+	s := newTestServer(t, nil)
+	_, err := NewSSH(SSHOptions{Address: s.addr, User: "lg", HostKey: string(ssh.MarshalAuthorizedKey(s.hostKey))})
+	if err == nil {
+		t.Error("expected an error without password or key")
+	}
+	if _, err := NewSSH(SSHOptions{Address: s.addr, User: "lg", Password: "secret", HostKey: "ssh-ed25519 bogus"}); err == nil {
+		t.Error("expected an error for a malformed host key")
+	}
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// APIKey is an active row of the api_keys table.
+type APIKey struct {
+	Name   string
+	Scopes []string
+}
+
+// LookupAPIKey returns the active (unexpired, unrevoked) API key with the
+// given hex SHA-256 hash, or nil if there is none.
+func (db *DB) LookupAPIKey(ctx context.Context, hash string) (*APIKey, error) {
+	var key APIKey
+	err := db.Pool.QueryRow(ctx, `
+		SELECT name, scopes
+		FROM api_keys
+		WHERE key_hash = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > now())
+	`, hash).Scan(&key.Name, &key.Scopes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
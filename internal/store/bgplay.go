@@ -0,0 +1,205 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// GetBGPlay returns the paths of the routes matching q in the BGPlay data
+// format. The initial state at q.From is the RIB of every router just before
+// it, replaying events from q.Since on. q.Limit and q.After are ignored.
+func (db *DB) GetBGPlay(ctx context.Context, q HistoryQuery) (*model.BGPlayResponse, error) {
+	routers, err := db.ListRouters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]model.Router, len(routers))
+	for _, r := range routers {
+		byID[r.ID] = r
+	}
+
+	routerIDs := q.RouterIDs
+	if routerIDs == nil {
+		for _, r := range routers {
+			routerIDs = append(routerIDs, r.ID)
+		}
+	}
+	var initial []model.RouteEvent
+	for _, routerID := range routerIDs {
+		paths, err := db.bgplayInitial(ctx, routerID, q)
+		if err != nil {
+			return nil, err
+		}
+		initial = append(initial, paths...)
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+eventColumns+`
+		FROM route_events
+		WHERE ($1::text[] IS NULL OR router_id = ANY($1))
+		  AND (prefix = $2::cidr OR ($3 AND prefix << $2::cidr))
+		  AND ingest_time BETWEEN $4 AND $5
+		ORDER BY ingest_time, event_id
+		LIMIT $6
+	`, q.RouterIDs, q.Prefix, q.Subnets, q.From, q.To, maxIntervalEvents+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []intervalEvent
+	for rows.Next() {
+		var ingestTime time.Time
+		event, err := scanRouteEvent(rows, &ingestTime)
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == maxIntervalEvents {
+			return nil, ErrTooManyEvents
+		}
+		events = append(events, intervalEvent{time: ingestTime, event: event})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	data := buildBGPlay(q.Prefix, q.From, q.To, byID, initial, events)
+	return &model.BGPlayResponse{Data: data}, nil
+}
+
+// bgplayInitial returns the paths of the routes matching q announced on a
+// router just before q.From, as announcements timed at their last update.
+// ribAtCTE includes events at its point in time, so the RIB is read one
+// microsecond, the resolution of timestamptz, before q.From: events at
+// q.From are replayed as part of the window.
+func (db *DB) bgplayInitial(ctx context.Context, routerID string, q HistoryQuery) ([]model.RouteEvent, error) {
+	rows, err := db.Pool.Query(ctx, ribAtCTE(`(prefix = $4::cidr OR ($5 AND prefix << $4::cidr))`)+`
+		SELECT r.updated_at, $1::text, 'A', r.prefix::text, r.path_id, r.nexthop, r.as_path,
+		       r.origin, r.localpref, r.med, r.origin_asn,
+		       r.communities_std, r.communities_ext, r.communities_large
+		FROM rib r
+		ORDER BY r.prefix, r.path_id
+	`, routerID, q.From.Add(-time.Microsecond), q.Since, q.Prefix, q.Subnets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var initial []model.RouteEvent
+	for rows.Next() {
+		event, err := scanRouteEvent(rows, nil)
+		if err != nil {
+			return nil, err
+		}
+		initial = append(initial, event)
+	}
+	return initial, rows.Err()
+}
+
+// buildBGPlay converts route events into BGPlay data. initial holds the
+// announced paths at from and events the changes within the window in time
+// order. Each path is prefixed with the ASN of the router that observed it
+// so that the routers appear as the vantage points of the graph.
+func buildBGPlay(resource string, from, to time.Time, routers map[string]model.Router, initial []model.RouteEvent, events []intervalEvent) model.BGPlayData {
+	data := model.BGPlayData{
+		Resource:       resource,
+		QueryStartTime: from.UTC().Format(model.BGPlayTimeFormat),
+		QueryEndTime:   to.UTC().Format(model.BGPlayTimeFormat),
+		Nodes:          []model.BGPlayNode{},
+		Sources:        []model.BGPlaySource{},
+		Targets:        []model.BGPlayTarget{},
+		InitialState:   []model.BGPlayRoute{},
+		Events:         []model.BGPlayEvent{},
+	}
+
+	sources := map[string]bool{}
+	targets := map[string]bool{}
+	nodes := map[int64]bool{}
+
+	route := func(e model.RouteEvent) model.BGPlayRoute {
+		router := routers[e.RouterID]
+		var pathID int64
+		if e.PathID != nil {
+			pathID = *e.PathID
+		}
+		id := e.RouterID + "-" + strconv.FormatInt(pathID, 10)
+		if !sources[id] {
+			sources[id] = true
+			src := model.BGPlaySource{ID: id, ASNumber: router.ASNumber, RRC: e.RouterID}
+			if router.RouterIP != nil {
+				src.IP = *router.RouterIP
+			}
+			data.Sources = append(data.Sources, src)
+		}
+		if !targets[e.Prefix] {
+			targets[e.Prefix] = true
+			data.Targets = append(data.Targets, model.BGPlayTarget{Prefix: e.Prefix})
+		}
+
+		r := model.BGPlayRoute{TargetPrefix: e.Prefix, SourceID: id}
+		if e.Action == "announce" {
+			r.Path = bgplayPath(e.ASPath, router.ASNumber)
+			r.Community = []string{}
+			for _, c := range e.Communities {
+				r.Community = append(r.Community, c.Value)
+			}
+			for _, asn := range r.Path {
+				if !nodes[asn] {
+					nodes[asn] = true
+					data.Nodes = append(data.Nodes, model.BGPlayNode{ASNumber: asn})
+				}
+			}
+		}
+		return r
+	}
+
+	for _, e := range initial {
+		data.InitialState = append(data.InitialState, route(e))
+	}
+	for _, e := range events {
+		typ := "A"
+		if e.event.Action == "withdraw" {
+			typ = "W"
+		}
+		data.Events = append(data.Events, model.BGPlayEvent{
+			Attrs:     route(e.event),
+			Timestamp: e.time.UTC().Format(model.BGPlayTimeFormat),
+			Type:      typ,
+		})
+	}
+
+	slices.SortFunc(data.Nodes, func(a, b model.BGPlayNode) int { return cmp.Compare(a.ASNumber, b.ASNumber) })
+	slices.SortFunc(data.Sources, func(a, b model.BGPlaySource) int { return strings.Compare(a.ID, b.ID) })
+	return data
+}
+
+// bgplayPath flattens an AS path for BGPlay, which only understands plain
+// AS sequences: AS_SET members are inlined in order. The observing router's
+// ASN is prepended unless the path already starts with it.
+func bgplayPath(asPath []any, routerASN *int64) []int64 {
+	var path []int64
+	add := func(v any) {
+		if n, ok := v.(int); ok {
+			path = append(path, int64(n))
+		}
+	}
+	for _, seg := range asPath {
+		if set, ok := seg.([]any); ok {
+			for _, v := range set {
+				add(v)
+			}
+			continue
+		}
+		add(seg)
+	}
+	if routerASN != nil && (len(path) == 0 || path[0] != *routerASN) {
+		path = append([]int64{*routerASN}, path...)
+	}
+	return path
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+func TestBGPlayPath(t *testing.T) {
+	asn := int64(64500)
+	cases := []struct {
+		asPath    []any
+		routerASN *int64
+		want      []int64
+	}{
+		{[]any{65000, 65001}, &asn, []int64{64500, 65000, 65001}},
+		{[]any{64500, 64500, 65000}, &asn, []int64{64500, 64500, 65000}},
+		{[]any{65000, []any{65001, 65002}}, nil, []int64{65000, 65001, 65002}},
+		{[]any{}, &asn, []int64{64500}},
+	}
+	for _, c := range cases {
+		if got := bgplayPath(c.asPath, c.routerASN); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("bgplayPath(%v): expected %v, got %v", c.asPath, c.want, got)
+		}
+	}
+}
+
+func TestBuildBGPlay(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	asn := int64(64500)
+	routers := map[string]model.Router{"r1": {ID: "r1", ASNumber: &asn}}
+
+	initial := announceEvent("192.0.2.1")
+	initial.ASPath = []any{65000}
+	initial.Communities = parseCommunities([]string{"65000:1"}, "standard")
+	update := announceEvent("192.0.2.1")
+	update.ASPath = []any{65001, 65000}
+	events := []intervalEvent{
+		{time: from.Add(time.Minute), event: update},
+		{time: from.Add(2 * time.Minute), event: withdrawEvent()},
+	}
+
+	data := buildBGPlay("10.0.0.0/24", from, from.Add(time.Hour), routers, []model.RouteEvent{initial}, events)
+
+	if data.QueryStartTime != "2025-01-01T00:00:00" {
+		t.Fatalf("unexpected start time %q", data.QueryStartTime)
+	}
+	if len(data.Sources) != 1 || data.Sources[0].ID != "r1-0" || *data.Sources[0].ASNumber != 64500 {
+		t.Fatalf("unexpected sources: %+v", data.Sources)
+	}
+	if len(data.Targets) != 1 || data.Targets[0].Prefix != "10.0.0.0/24" {
+		t.Fatalf("unexpected targets: %+v", data.Targets)
+	}
+	if len(data.Nodes) != 3 || data.Nodes[0].ASNumber != 64500 || data.Nodes[2].ASNumber != 65001 {
+		t.Fatalf("unexpected nodes: %+v", data.Nodes)
+	}
+	if len(data.InitialState) != 1 || !reflect.DeepEqual(data.InitialState[0].Community, []string{"65000:1"}) {
+		t.Fatalf("unexpected initial state: %+v", data.InitialState)
+	}
+	if len(data.Events) != 2 || data.Events[0].Type != "A" || data.Events[1].Type != "W" {
+		t.Fatalf("unexpected events: %+v", data.Events)
+	}
+	if data.Events[1].Attrs.Path != nil || data.Events[1].Timestamp != "2025-01-01T00:02:00" {
+		t.Fatalf("unexpected withdrawal: %+v", data.Events[1])
+	}
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// Change types assigned to events by the history changes view.
+const (
+	ChangeInitial    = "initial"         // no earlier event of the path is known
+	ChangeUpdate     = "update"          // announcement with changed attributes
+	ChangeRefresh    = "refresh"         // implicit re-announcement without changes
+	ChangeReadvert   = "readvertisement" // announcement following a withdrawal
+	ChangeWithdrawal = "withdrawal"
+)
+
+// changeKey identifies the path an event belongs to.
+type changeKey struct {
+	routerID string
+	prefix   string
+	pathID   int64
+}
+
+func changeKeyOf(e model.RouteEvent) changeKey {
+	k := changeKey{routerID: e.RouterID, prefix: e.Prefix}
+	if e.PathID != nil {
+		k.pathID = *e.PathID
+	}
+	return k
+}
+
+// changeSeed is the state of a path just before the oldest event of a page:
+// its last event and its last announcement, either of which may be unknown.
+type changeSeed struct {
+	last         *model.RouteEvent
+	lastAnnounce *model.RouteEvent
+}
+
+// annotateChanges sets ChangeType and Changes on a page of events ordered
+// newest first. positions holds the cursor position of each event and is
+// used to look up the state preceding the page for every path. Events before
+// since are not read, so a path without an earlier event on or after since
+// is classified as new.
+func (db *DB) annotateChanges(ctx context.Context, events []model.RouteEvent, positions []HistoryCursor, since time.Time) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	// The oldest event of each path in the page bounds its seed lookup.
+	oldest := map[changeKey]int{}
+	for i, e := range events {
+		oldest[changeKeyOf(e)] = i
+	}
+	var (
+		routerIDs []string
+		prefixes  []string
+		pathIDs   []int64
+		times     []time.Time
+		eventIDs  [][]byte
+	)
+	for k, i := range oldest {
+		routerIDs = append(routerIDs, k.routerID)
+		prefixes = append(prefixes, k.prefix)
+		pathIDs = append(pathIDs, k.pathID)
+		times = append(times, positions[i].Time)
+		eventIDs = append(eventIDs, positions[i].EventID)
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		WITH k AS (
+			SELECT *
+			FROM unnest($1::text[], $2::cidr[], $3::bigint[], $4::timestamptz[], $5::bytea[])
+			     AS k(router_id, prefix, path_id, before_time, before_id)
+		)
+		SELECT false AS announce_only, l.*
+		FROM k CROSS JOIN LATERAL (
+			SELECT `+eventColumns+`
+			FROM route_events e
+			WHERE e.router_id = k.router_id
+			  AND e.prefix = k.prefix
+			  AND COALESCE(e.path_id, 0) = k.path_id
+			  AND (e.ingest_time, e.event_id) < (k.before_time, k.before_id)
+			  AND e.ingest_time >= $6
+			ORDER BY e.ingest_time DESC, e.event_id DESC
+			LIMIT 1
+		) l
+		UNION ALL
+		SELECT true AS announce_only, l.*
+		FROM k CROSS JOIN LATERAL (
+			SELECT `+eventColumns+`
+			FROM route_events e
+			WHERE e.router_id = k.router_id
+			  AND e.prefix = k.prefix
+			  AND COALESCE(e.path_id, 0) = k.path_id
+			  AND e.action = 'A'
+			  AND (e.ingest_time, e.event_id) < (k.before_time, k.before_id)
+			  AND e.ingest_time >= $6
+			ORDER BY e.ingest_time DESC, e.event_id DESC
+			LIMIT 1
+		) l
+	`, routerIDs, prefixes, pathIDs, times, eventIDs, since)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	seeds := map[changeKey]*changeSeed{}
+	for rows.Next() {
+		var announceOnly bool
+		event, err := scanRouteEvent(rows, nil, &announceOnly)
+		if err != nil {
+			return err
+		}
+		k := changeKeyOf(event)
+		if seeds[k] == nil {
+			seeds[k] = &changeSeed{}
+		}
+		if announceOnly {
+			seeds[k].lastAnnounce = &event
+		} else {
+			seeds[k].last = &event
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	classifyChanges(events, seeds)
+	return nil
+}
+
+// classifyChanges walks events (newest first) from oldest to newest per path
+// and records how each event differs from the one before it. Announcements
+// are compared with the latest earlier announcement of the path.
+func classifyChanges(events []model.RouteEvent, seeds map[changeKey]*changeSeed) {
+	state := map[changeKey]*changeSeed{}
+	for k, s := range seeds {
+		c := *s
+		state[k] = &c
+	}
+
+	for i := len(events) - 1; i >= 0; i-- {
+		e := &events[i]
+		k := changeKeyOf(*e)
+		s := state[k]
+		if s == nil {
+			s = &changeSeed{}
+			state[k] = s
+		}
+
+		var changeType string
+		switch {
+		case e.Action == "withdraw":
+			changeType = ChangeWithdrawal
+		case s.last == nil && s.lastAnnounce == nil:
+			changeType = ChangeInitial
+		case s.last != nil && s.last.Action == "withdraw":
+			changeType = ChangeReadvert
+		default:
+			changeType = ChangeRefresh
+		}
+
+		if e.Action == "announce" && s.lastAnnounce != nil {
+			e.Changes = diffRouteAttrs(attrsOfEvent(*s.lastAnnounce), attrsOfEvent(*e))
+			if changeType == ChangeRefresh && len(e.Changes) > 0 {
+				changeType = ChangeUpdate
+			}
+		}
+		e.ChangeType = &changeType
+
+		s.last = e
+		if e.Action == "announce" {
+			s.lastAnnounce = e
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+func changeEvent(action, nextHop string, communities ...string) model.RouteEvent {
+	e := model.RouteEvent{RouterID: "r1", Action: action, Prefix: "10.0.0.0/24"}
+	if action == "announce" {
+		e.NextHop = &nextHop
+		e.Communities = parseCommunities(communities, "standard")
+	}
+	return e
+}
+
+func TestClassifyChanges_Sequence(t *testing.T) {
+	// Newest first, as returned by GetRouteHistory.
+	events := []model.RouteEvent{
+		changeEvent("announce", "192.0.2.2", "65000:1"),
+		changeEvent("withdraw", ""),
+		changeEvent("announce", "192.0.2.2", "65000:1"),
+		changeEvent("announce", "192.0.2.2", "65000:1", "65000:2"),
+		changeEvent("announce", "192.0.2.1", "65000:1", "65000:2"),
+		changeEvent("announce", "192.0.2.1", "65000:1", "65000:2"),
+	}
+
+	classifyChanges(events, nil)
+
+	want := []string{ChangeReadvert, ChangeWithdrawal, ChangeUpdate, ChangeUpdate, ChangeRefresh, ChangeInitial}
+	for i, w := range want {
+		if events[i].ChangeType == nil || *events[i].ChangeType != w {
+			t.Fatalf("event %d: expected %q, got %v", i, w, events[i].ChangeType)
+		}
+	}
+
+	// The community removal is reported as a set difference.
+	if len(events[2].Changes) != 1 || events[2].Changes[0].Attribute != "communities" ||
+		len(events[2].Changes[0].Removed) != 1 {
+		t.Fatalf("expected one removed community, got %+v", events[2].Changes)
+	}
+	if len(events[3].Changes) != 1 || events[3].Changes[0].Attribute != "next_hop" {
+		t.Fatalf("expected next_hop change, got %+v", events[3].Changes)
+	}
+	// A re-advertisement is compared with the announcement before the withdrawal.
+	if len(events[0].Changes) != 0 {
+		t.Fatalf("expected no changes on re-advertisement, got %+v", events[0].Changes)
+	}
+}
+
+func TestClassifyChanges_UsesSeed(t *testing.T) {
+	prev := changeEvent("announce", "192.0.2.1")
+	seeds := map[changeKey]*changeSeed{
+		changeKeyOf(prev): {last: &prev, lastAnnounce: &prev},
+	}
+	events := []model.RouteEvent{changeEvent("announce", "192.0.2.1")}
+
+	classifyChanges(events, seeds)
+
+	if *events[0].ChangeType != ChangeRefresh {
+		t.Fatalf("expected refresh, got %q", *events[0].ChangeType)
+	}
+	if seeds[changeKeyOf(prev)].last != &prev {
+		t.Fatal("seeds must not be modified")
+	}
+}
+
+func TestClassifyChanges_SeparatesPaths(t *testing.T) {
+	one, two := int64(1), int64(2)
+	a := changeEvent("announce", "192.0.2.1")
+	a.PathID = &one
+	b := changeEvent("announce", "192.0.2.2")
+	b.PathID = &two
+	events := []model.RouteEvent{b, a}
+
+	classifyChanges(events, nil)
+
+	for i := range events {
+		if *events[i].ChangeType != ChangeInitial {
+			t.Fatalf("event %d: expected initial, got %q", i, *events[i].ChangeType)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// ChurnQuery selects and groups route_events for a churn time series.
+type ChurnQuery struct {
+	RouterID       string
+	From           time.Time
+	To             time.Time
+	Interval       string // date_trunc field: "minute" or "hour"
+	GroupAFI       bool
+	GroupOriginASN bool
+	AFI            *int
+	OriginASN      *int
+}
+
+// GetChurn returns announcement and withdrawal counts per time bucket, split
+// into one series per AFI and/or origin ASN when grouping is requested.
+// Buckets without events are omitted.
+func (db *DB) GetChurn(ctx context.Context, q ChurnQuery) ([]model.ChurnSeries, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT date_trunc($4, ingest_time, 'UTC') AS bucket,
+		       CASE WHEN $5 THEN afi::int END AS afi,
+		       CASE WHEN $6 THEN origin_asn END AS origin_asn,
+		       COUNT(*) FILTER (WHERE action = 'A') AS announcements,
+		       COUNT(*) FILTER (WHERE action = 'D') AS withdrawals
+		FROM route_events
+		WHERE router_id = $1
+		  AND ingest_time BETWEEN $2 AND $3
+		  AND ($7::smallint IS NULL OR afi = $7)
+		  AND ($8::bigint IS NULL OR origin_asn::bigint = $8)
+		GROUP BY 1, 2, 3
+		ORDER BY 2 NULLS FIRST, 3 NULLS FIRST, 1
+	`, q.RouterID, q.From, q.To, q.Interval, q.GroupAFI, q.GroupOriginASN, q.AFI, q.OriginASN)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var series []model.ChurnSeries
+	for rows.Next() {
+		var (
+			bucket    time.Time
+			afi       *int
+			originASN *int
+			announce  int64
+			withdraw  int64
+		)
+		if err := rows.Scan(&bucket, &afi, &originASN, &announce, &withdraw); err != nil {
+			return nil, err
+		}
+
+		if n := len(series); n == 0 || !equalIntPtr(series[n-1].AFI, afi) || !equalIntPtr(series[n-1].OriginASN, originASN) {
+			series = append(series, model.ChurnSeries{AFI: afi, OriginASN: originASN})
+		}
+		s := &series[len(series)-1]
+		s.Points = append(s.Points, model.ChurnPoint{
+			Time:          model.FormatTime(bucket),
+			Announcements: announce,
+			Withdrawals:   withdraw,
+		})
+	}
+	if series == nil {
+		series = []model.ChurnSeries{}
+	}
+	return series, rows.Err()
+}
+
+// equalIntPtr reports whether two nullable ints hold the same value.
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,194 @@
+package store
+
+import (
+	"context"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// CompareAttributes are the attribute names accepted by CompareQuery.Ignore.
+var CompareAttributes = []string{
+	"next_hop", "as_path", "origin", "local_pref", "med", "origin_asn",
+	"communities", "extended_communities", "large_communities",
+}
+
+// CompareQuery selects the tables and attributes compared between two routers
+// and the page of differing prefixes to return.
+type CompareQuery struct {
+	RouterA  string
+	RouterB  string
+	Table    *string
+	AFI      *int
+	Ignore   []string
+	Statuses []string
+	Limit    int
+	Offset   int
+}
+
+// compareCTE joins the best path (lowest path_id) of every prefix on both
+// routers and classifies each prefix as only_a, only_b, different or
+// identical. Communities are compared as sets. Attributes listed in $5 are
+// ignored.
+const compareCTE = `
+		WITH a AS (
+			SELECT DISTINCT ON (table_name, afi, prefix) *
+			FROM current_routes
+			WHERE router_id = $1
+			  AND ($3::text IS NULL OR table_name = $3)
+			  AND ($4::smallint IS NULL OR afi = $4)
+			ORDER BY table_name, afi, prefix, path_id
+		), b AS (
+			SELECT DISTINCT ON (table_name, afi, prefix) *
+			FROM current_routes
+			WHERE router_id = $2
+			  AND ($3::text IS NULL OR table_name = $3)
+			  AND ($4::smallint IS NULL OR afi = $4)
+			ORDER BY table_name, afi, prefix, path_id
+		), j AS (
+			SELECT table_name, afi, prefix,
+			       CASE
+			         WHEN b.router_id IS NULL THEN 'only_a'
+			         WHEN a.router_id IS NULL THEN 'only_b'
+			         WHEN (NOT 'next_hop' = ANY($5) AND a.nexthop IS DISTINCT FROM b.nexthop)
+			           OR (NOT 'as_path' = ANY($5) AND a.as_path IS DISTINCT FROM b.as_path)
+			           OR (NOT 'origin' = ANY($5) AND a.origin IS DISTINCT FROM b.origin)
+			           OR (NOT 'local_pref' = ANY($5) AND a.localpref IS DISTINCT FROM b.localpref)
+			           OR (NOT 'med' = ANY($5) AND a.med IS DISTINCT FROM b.med)
+			           OR (NOT 'origin_asn' = ANY($5) AND a.origin_asn IS DISTINCT FROM b.origin_asn)
+			           OR (NOT 'communities' = ANY($5)
+			               AND ARRAY(SELECT unnest(a.communities_std) ORDER BY 1)
+			                   <> ARRAY(SELECT unnest(b.communities_std) ORDER BY 1))
+			           OR (NOT 'extended_communities' = ANY($5)
+			               AND ARRAY(SELECT unnest(a.communities_ext) ORDER BY 1)
+			                   <> ARRAY(SELECT unnest(b.communities_ext) ORDER BY 1))
+			           OR (NOT 'large_communities' = ANY($5)
+			               AND ARRAY(SELECT unnest(a.communities_large) ORDER BY 1)
+			                   <> ARRAY(SELECT unnest(b.communities_large) ORDER BY 1))
+			           THEN 'different'
+			         ELSE 'identical'
+			       END AS status
+			FROM a FULL JOIN b USING (table_name, afi, prefix)
+		)`
+
+// GetCompareSummary counts the prefixes of two routers by comparison status.
+func (db *DB) GetCompareSummary(ctx context.Context, q CompareQuery) (*model.RouterCompareSummary, error) {
+	rows, err := db.Pool.Query(ctx, compareCTE+`
+		SELECT status, COUNT(*) FROM j GROUP BY status
+	`, q.RouterA, q.RouterB, q.Table, q.AFI, ignoreList(q.Ignore))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var s model.RouterCompareSummary
+	for rows.Next() {
+		var (
+			status string
+			count  int64
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		switch status {
+		case "only_a":
+			s.OnlyA = count
+		case "only_b":
+			s.OnlyB = count
+		case "different":
+			s.Different = count
+		case "identical":
+			s.Identical = count
+		}
+	}
+	return &s, rows.Err()
+}
+
+// CompareRouters returns a page of prefixes whose status is in q.Statuses,
+// ordered by prefix, with the best path of each router and the attribute
+// differences. One entry more than q.Limit is requested so callers can
+// detect further pages.
+func (db *DB) CompareRouters(ctx context.Context, q CompareQuery) ([]model.PrefixComparison, error) {
+	rows, err := db.Pool.Query(ctx, compareCTE+`,
+		page AS (
+			SELECT table_name, afi, prefix, status
+			FROM j
+			WHERE status = ANY($6)
+			ORDER BY prefix, table_name, afi
+			LIMIT $7 OFFSET $8
+		)
+		SELECT p.status, table_name, afi, s.side,
+		       `+ribColumns+`
+		FROM page p
+		JOIN (
+			SELECT 'a' AS side, * FROM a
+			UNION ALL
+			SELECT 'b' AS side, * FROM b
+		) s USING (table_name, afi, prefix)
+		ORDER BY p.prefix, p.table_name, p.afi, s.side
+	`, q.RouterA, q.RouterB, q.Table, q.AFI, ignoreList(q.Ignore), q.Statuses, q.Limit+1, q.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ignored := make(map[string]bool, len(q.Ignore))
+	for _, a := range q.Ignore {
+		ignored[a] = true
+	}
+
+	entries := []model.PrefixComparison{}
+	for rows.Next() {
+		var (
+			status string
+			table  string
+			afi    int
+			side   string
+		)
+		route, err := scanRoute(rows, &status, &table, &afi, &side)
+		if err != nil {
+			return nil, err
+		}
+
+		n := len(entries)
+		if n == 0 || entries[n-1].Prefix != route.Prefix || entries[n-1].Table != table || entries[n-1].AFI != afi {
+			entries = append(entries, model.PrefixComparison{
+				Prefix:  route.Prefix,
+				Table:   table,
+				AFI:     afi,
+				Status:  status,
+				Changes: []model.AttributeChange{},
+			})
+		}
+		e := &entries[len(entries)-1]
+		if side == "a" {
+			e.A = &route
+		} else {
+			e.B = &route
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		if e.A == nil || e.B == nil {
+			continue
+		}
+		for _, c := range diffRouteAttrs(attrsOfRoute(*e.A), attrsOfRoute(*e.B)) {
+			if !ignored[c.Attribute] {
+				e.Changes = append(e.Changes, c)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// ignoreList returns a non-nil slice so the query parameter is an empty
+// array rather than NULL.
+func ignoreList(ignore []string) []string {
+	if ignore == nil {
+		return []string{}
+	}
+	return ignore
+}
@@ -3,6 +3,8 @@ package store
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -14,13 +16,46 @@ type DB struct {
 	Pool *pgxpool.Pool
 }
 
-// NewDB creates a new database connection pool. If databaseURL is empty, the
-// default local development URL is used.
-func NewDB(ctx context.Context, databaseURL string) (*DB, error) {
+// PoolConfig configures the connection pool. Zero values keep the pgxpool
+// defaults; a zero StatementTimeout leaves queries unbounded.
+type PoolConfig struct {
+	URL              string
+	MaxConns         int32
+	MinConns         int32
+	MaxConnLifetime  time.Duration
+	MaxConnIdleTime  time.Duration
+	StatementTimeout time.Duration
+}
+
+// NewDB creates a new database connection pool. If the URL is empty, the
+// default local development URL is used. Every query is traced and timed.
+func NewDB(ctx context.Context, pc PoolConfig) (*DB, error) {
+	databaseURL := pc.URL
 	if databaseURL == "" {
 		databaseURL = defaultDatabaseURL
 	}
-	pool, err := pgxpool.New(ctx, databaseURL)
+	cfg, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse database URL: %w", err)
+	}
+	cfg.ConnConfig.Tracer = queryTracer{}
+	if pc.MaxConns > 0 {
+		cfg.MaxConns = pc.MaxConns
+	}
+	if pc.MinConns > 0 {
+		cfg.MinConns = pc.MinConns
+	}
+	if pc.MaxConnLifetime > 0 {
+		cfg.MaxConnLifetime = pc.MaxConnLifetime
+	}
+	if pc.MaxConnIdleTime > 0 {
+		cfg.MaxConnIdleTime = pc.MaxConnIdleTime
+	}
+	if pc.StatementTimeout > 0 {
+		cfg.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(pc.StatementTimeout.Milliseconds(), 10)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("create pool: %w", err)
 	}
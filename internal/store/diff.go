@@ -0,0 +1,260 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// RIBDiff holds the paths that differ between two snapshots of a RIB.
+type RIBDiff struct {
+	Added   []model.DiffRoute
+	Removed []model.DiffRoute
+	Changed []model.RouteChange
+}
+
+// GetRIBDiffOverTime compares a router's RIB at from and at to. Only paths
+// with route_events in (from, to] are considered, and their state on each
+// side is the last event at or before that time. Events before since are
+// not read, so a path whose last earlier event is older counts as absent
+// at from. Reconstructed routes carry the time of that event in both
+// first_seen and updated_at.
+func (db *DB) GetRIBDiffOverTime(ctx context.Context, routerID string, table *string, from, to, since time.Time) (*RIBDiff, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH keys AS (
+			SELECT DISTINCT table_name, afi, prefix, COALESCE(path_id, 0) AS path_id
+			FROM route_events
+			WHERE router_id = $1
+			  AND ingest_time > $2
+			  AND ingest_time <= $3
+			  AND ($4::text IS NULL OR table_name = $4)
+		), states AS (
+			SELECT DISTINCT ON (s.side, k.table_name, k.afi, k.prefix, k.path_id)
+			       s.side, k.table_name, k.afi, k.prefix, k.path_id, e.action,
+			       e.nexthop, e.as_path, e.origin, e.localpref, e.med, e.origin_asn,
+			       e.communities_std, e.communities_ext, e.communities_large,
+			       e.attrs, e.ingest_time
+			FROM (VALUES ('from', $2::timestamptz), ('to', $3::timestamptz)) AS s(side, at)
+			CROSS JOIN keys k
+			JOIN route_events e
+			  ON e.router_id = $1
+			 AND e.table_name = k.table_name
+			 AND e.afi = k.afi
+			 AND e.prefix = k.prefix
+			 AND COALESCE(e.path_id, 0) = k.path_id
+			 AND e.ingest_time >= $5
+			 AND e.ingest_time <= s.at
+			ORDER BY s.side, k.table_name, k.afi, k.prefix, k.path_id,
+			         e.ingest_time DESC, e.event_id DESC
+		)
+		SELECT side, table_name, afi,
+		       prefix::text, path_id, nexthop, as_path, origin,
+		       localpref, med, origin_asn,
+		       communities_std, communities_ext, communities_large,
+		       attrs, ingest_time, ingest_time
+		FROM states s
+		WHERE action = 'A'
+		ORDER BY s.prefix, s.path_id, s.table_name, s.afi, s.side
+	`, routerID, from, to, table, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type pathKey struct {
+		table  string
+		afi    int
+		prefix string
+		pathID int64
+	}
+	var (
+		keys   []pathKey
+		before = map[pathKey]model.Route{}
+		after  = map[pathKey]model.Route{}
+	)
+	for rows.Next() {
+		var (
+			side string
+			k    pathKey
+		)
+		route, err := scanRoute(rows, &side, &k.table, &k.afi)
+		if err != nil {
+			return nil, err
+		}
+		k.prefix, k.pathID = route.Prefix, route.PathID
+
+		_, seenBefore := before[k]
+		_, seenAfter := after[k]
+		if !seenBefore && !seenAfter {
+			keys = append(keys, k)
+		}
+		if side == "from" {
+			before[k] = route
+		} else {
+			after[k] = route
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	diff := &RIBDiff{
+		Added:   []model.DiffRoute{},
+		Removed: []model.DiffRoute{},
+		Changed: []model.RouteChange{},
+	}
+	for _, k := range keys {
+		old, hadOld := before[k]
+		cur, hasNew := after[k]
+		switch {
+		case !hadOld:
+			diff.Added = append(diff.Added, model.DiffRoute{Table: k.table, AFI: k.afi, Route: cur})
+		case !hasNew:
+			diff.Removed = append(diff.Removed, model.DiffRoute{Table: k.table, AFI: k.afi, Route: old})
+		default:
+			if changes := diffRouteAttrs(attrsOfRoute(old), attrsOfRoute(cur)); len(changes) > 0 {
+				diff.Changed = append(diff.Changed, model.RouteChange{
+					Prefix:  cur.Prefix,
+					Table:   k.table,
+					AFI:     k.afi,
+					PathID:  cur.PathID,
+					Changes: changes,
+					Old:     old,
+					New:     cur,
+				})
+			}
+		}
+	}
+	return diff, nil
+}
+
+// pathAttrs are the BGP path attributes compared by diffs. Routes and route
+// events both reduce to this shape.
+type pathAttrs struct {
+	NextHop             *string
+	ASPath              []any
+	Origin              *string
+	LocalPref           *int
+	MED                 *int
+	OriginASN           *int
+	Communities         []model.Community
+	ExtendedCommunities []model.Community
+	LargeCommunities    []model.Community
+}
+
+// attrsOfRoute extracts the comparable path attributes of a route.
+func attrsOfRoute(r model.Route) pathAttrs {
+	return pathAttrs{
+		NextHop:             r.NextHop,
+		ASPath:              r.ASPath,
+		Origin:              r.Origin,
+		LocalPref:           r.LocalPref,
+		MED:                 r.MED,
+		OriginASN:           r.OriginASN,
+		Communities:         r.Communities,
+		ExtendedCommunities: r.ExtendedCommunities,
+		LargeCommunities:    r.LargeCommunities,
+	}
+}
+
+// attrsOfEvent extracts the comparable path attributes of a route event.
+func attrsOfEvent(e model.RouteEvent) pathAttrs {
+	return pathAttrs{
+		NextHop:             e.NextHop,
+		ASPath:              e.ASPath,
+		Origin:              e.Origin,
+		LocalPref:           e.LocalPref,
+		MED:                 e.MED,
+		OriginASN:           e.OriginASN,
+		Communities:         e.Communities,
+		ExtendedCommunities: e.ExtendedCommunities,
+		LargeCommunities:    e.LargeCommunities,
+	}
+}
+
+// diffRouteAttrs lists the attributes that differ between old and new, in a
+// fixed order. Community lists are compared as sets.
+func diffRouteAttrs(old, new pathAttrs) []model.AttributeChange {
+	var changes []model.AttributeChange
+
+	if !equalStringPtr(old.NextHop, new.NextHop) {
+		changes = append(changes, model.AttributeChange{Attribute: "next_hop", Old: old.NextHop, New: new.NextHop})
+	}
+	if !reflect.DeepEqual(old.ASPath, new.ASPath) {
+		changes = append(changes, model.AttributeChange{Attribute: "as_path", Old: old.ASPath, New: new.ASPath})
+	}
+	if !equalStringPtr(old.Origin, new.Origin) {
+		changes = append(changes, model.AttributeChange{Attribute: "origin", Old: old.Origin, New: new.Origin})
+	}
+	if !equalIntPtr(old.LocalPref, new.LocalPref) {
+		changes = append(changes, model.AttributeChange{Attribute: "local_pref", Old: old.LocalPref, New: new.LocalPref})
+	}
+	if !equalIntPtr(old.MED, new.MED) {
+		changes = append(changes, model.AttributeChange{Attribute: "med", Old: old.MED, New: new.MED})
+	}
+	if !equalIntPtr(old.OriginASN, new.OriginASN) {
+		changes = append(changes, model.AttributeChange{Attribute: "origin_asn", Old: old.OriginASN, New: new.OriginASN})
+	}
+	for _, c := range []struct {
+		name     string
+		old, new []model.Community
+	}{
+		{"communities", old.Communities, new.Communities},
+		{"extended_communities", old.ExtendedCommunities, new.ExtendedCommunities},
+		{"large_communities", old.LargeCommunities, new.LargeCommunities},
+	} {
+		oldVals, newVals := communityValues(c.old), communityValues(c.new)
+		added, removed := diffSets(oldVals, newVals)
+		if len(added) > 0 || len(removed) > 0 {
+			changes = append(changes, model.AttributeChange{
+				Attribute: c.name,
+				Old:       oldVals,
+				New:       newVals,
+				Added:     added,
+				Removed:   removed,
+			})
+		}
+	}
+	return changes
+}
+
+// communityValues returns the string values of a community list.
+func communityValues(cs []model.Community) []string {
+	vals := make([]string, len(cs))
+	for i, c := range cs {
+		vals[i] = c.Value
+	}
+	return vals
+}
+
+// diffSets returns the values only in new (added) and only in old (removed),
+// each in their original order.
+func diffSets(old, new []string) (added, removed []string) {
+	inOld := make(map[string]bool, len(old))
+	for _, v := range old {
+		inOld[v] = true
+	}
+	inNew := make(map[string]bool, len(new))
+	for _, v := range new {
+		inNew[v] = true
+		if !inOld[v] {
+			added = append(added, v)
+		}
+	}
+	for _, v := range old {
+		if !inNew[v] {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
+
+// equalStringPtr reports whether two nullable strings hold the same value.
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+func TestDiffRouteAttrs_Identical(t *testing.T) {
+	nh := "192.0.2.1"
+	a := pathAttrs{
+		NextHop:     &nh,
+		ASPath:      []any{64500, 65000},
+		Communities: parseCommunities([]string{"65000:1"}, "standard"),
+	}
+	if changes := diffRouteAttrs(a, a); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %+v", changes)
+	}
+}
+
+func TestDiffRouteAttrs_ScalarChanges(t *testing.T) {
+	nh1, nh2 := "192.0.2.1", "192.0.2.2"
+	lp := 100
+	old := pathAttrs{NextHop: &nh1, ASPath: []any{64500}, LocalPref: &lp}
+	new := pathAttrs{NextHop: &nh2, ASPath: []any{64500, []any{64501, 64502}}}
+
+	changes := diffRouteAttrs(old, new)
+	var names []string
+	for _, c := range changes {
+		names = append(names, c.Attribute)
+	}
+	want := []string{"next_hop", "as_path", "local_pref"}
+	if len(names) != len(want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, names)
+		}
+	}
+}
+
+func TestDiffRouteAttrs_CommunitiesAddedRemoved(t *testing.T) {
+	old := pathAttrs{Communities: parseCommunities([]string{"65000:1", "65000:2"}, "standard")}
+	new := pathAttrs{Communities: parseCommunities([]string{"65000:2", "65000:3"}, "standard")}
+
+	changes := diffRouteAttrs(old, new)
+	if len(changes) != 1 || changes[0].Attribute != "communities" {
+		t.Fatalf("expected a single communities change, got %+v", changes)
+	}
+	c := changes[0]
+	if len(c.Added) != 1 || c.Added[0] != "65000:3" {
+		t.Fatalf("expected 65000:3 added, got %v", c.Added)
+	}
+	if len(c.Removed) != 1 || c.Removed[0] != "65000:1" {
+		t.Fatalf("expected 65000:1 removed, got %v", c.Removed)
+	}
+}
+
+func TestDiffRouteAttrs_CommunityOrderIgnored(t *testing.T) {
+	old := pathAttrs{LargeCommunities: []model.Community{{Type: "large", Value: "1:2:3"}, {Type: "large", Value: "4:5:6"}}}
+	new := pathAttrs{LargeCommunities: []model.Community{{Type: "large", Value: "4:5:6"}, {Type: "large", Value: "1:2:3"}}}
+
+	if changes := diffRouteAttrs(old, new); len(changes) != 0 {
+		t.Fatalf("expected reordering to be ignored, got %+v", changes)
+	}
+}
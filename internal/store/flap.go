@@ -0,0 +1,238 @@
+package store
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// Penalties added per event in the dampening simulation. Re-advertisements
+// after a withdrawal carry no penalty, matching common vendor defaults.
+const (
+	withdrawPenalty   = 1000
+	attrChangePenalty = 500
+)
+
+// flapEvent is a withdrawal or attribute change of one path, the events
+// that add to its dampening penalty.
+type flapEvent struct {
+	time        time.Time
+	withdraw    bool
+	attrChanged bool
+}
+
+// flapPath identifies a path within a prefix.
+type flapPath struct {
+	table  string
+	pathID int64
+}
+
+// FlapStats are the flap statistics of a router over a time range.
+// Summary covers every prefix. Prefixes holds every prefix with a
+// withdrawal or attribute change, which are the only ones that can carry a
+// penalty, and the busiest of the others up to the requested limit.
+type FlapStats struct {
+	Summary  model.RouterFlapSummary
+	Prefixes []model.PrefixFlapStats
+}
+
+// GetFlapStats returns flap counters and simulated dampening state for a
+// router over a time range. When prefix is non-nil only that exact prefix
+// is analysed. Counting happens in the database; only the times of
+// withdrawals and attribute changes are read to run the simulation.
+func (db *DB) GetFlapStats(ctx context.Context, routerID string, prefix *string, from, to time.Time, params model.DampeningParams, limit int) (*FlapStats, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH ev AS (
+			SELECT prefix, table_name, COALESCE(path_id, 0) AS path_id,
+			       ingest_time, event_id, action,
+			       action = 'A'
+			       AND LAG(action) OVER w = 'A'
+			       AND (nexthop, as_path, origin, localpref, med,
+			            communities_std, communities_ext, communities_large)
+			           IS DISTINCT FROM
+			           (LAG(nexthop) OVER w, LAG(as_path) OVER w, LAG(origin) OVER w,
+			            LAG(localpref) OVER w, LAG(med) OVER w,
+			            LAG(communities_std) OVER w, LAG(communities_ext) OVER w,
+			            LAG(communities_large) OVER w) AS attr_changed
+			FROM route_events
+			WHERE router_id = $1
+			  AND ingest_time BETWEEN $2 AND $3
+			  AND ($4::cidr IS NULL OR prefix = $4::cidr)
+			WINDOW w AS (PARTITION BY table_name, prefix, COALESCE(path_id, 0) ORDER BY ingest_time, event_id)
+		), prefixes AS (
+			SELECT prefix,
+			       COUNT(*) FILTER (WHERE action = 'A') AS announcements,
+			       COUNT(*) FILTER (WHERE action = 'D') AS withdrawals,
+			       COUNT(*) FILTER (WHERE attr_changed) AS attribute_changes,
+			       MIN(ingest_time) AS first_event,
+			       MAX(ingest_time) AS last_event,
+			       array_agg(table_name ORDER BY table_name, path_id, ingest_time, event_id)
+			           FILTER (WHERE action = 'D' OR attr_changed) AS flap_tables,
+			       array_agg(path_id ORDER BY table_name, path_id, ingest_time, event_id)
+			           FILTER (WHERE action = 'D' OR attr_changed) AS flap_paths,
+			       array_agg(ingest_time ORDER BY table_name, path_id, ingest_time, event_id)
+			           FILTER (WHERE action = 'D' OR attr_changed) AS flap_times,
+			       array_agg(action = 'D' ORDER BY table_name, path_id, ingest_time, event_id)
+			           FILTER (WHERE action = 'D' OR attr_changed) AS flap_withdrawals
+			FROM ev
+			GROUP BY prefix
+		), totals AS (
+			SELECT COUNT(*) AS prefixes,
+			       COALESCE(SUM(announcements), 0)::bigint AS announcements,
+			       COALESCE(SUM(withdrawals), 0)::bigint AS withdrawals,
+			       COALESCE(SUM(attribute_changes), 0)::bigint AS attribute_changes
+			FROM prefixes
+		), page AS (
+			SELECT * FROM prefixes WHERE withdrawals > 0 OR attribute_changes > 0
+			UNION ALL
+			(SELECT * FROM prefixes
+			 WHERE withdrawals = 0 AND attribute_changes = 0
+			 ORDER BY announcements DESC, prefix
+			 LIMIT $5)
+		)
+		SELECT t.prefixes, t.announcements, t.withdrawals, t.attribute_changes,
+		       p.prefix::text, p.announcements, p.withdrawals, p.attribute_changes,
+		       p.first_event, p.last_event,
+		       p.flap_tables, p.flap_paths, p.flap_times, p.flap_withdrawals
+		FROM totals t
+		LEFT JOIN page p ON true
+		ORDER BY p.prefix
+	`, routerID, from, to, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := &FlapStats{Prefixes: []model.PrefixFlapStats{}}
+	for rows.Next() {
+		var (
+			pfx                   *string
+			announce, withdraw    *int64
+			attrChanges           *int64
+			firstEvent, lastEvent *time.Time
+			tables                []string
+			pathIDs               []int64
+			times                 []time.Time
+			withdrawals           []bool
+		)
+		if err := rows.Scan(&res.Summary.Prefixes, &res.Summary.Announcements, &res.Summary.Withdrawals, &res.Summary.AttributeChanges,
+			&pfx, &announce, &withdraw, &attrChanges, &firstEvent, &lastEvent,
+			&tables, &pathIDs, &times, &withdrawals); err != nil {
+			return nil, err
+		}
+		if pfx == nil {
+			// No events in range: totals only.
+			continue
+		}
+
+		flaps := map[flapPath][]flapEvent{}
+		for i := range times {
+			k := flapPath{tables[i], pathIDs[i]}
+			flaps[k] = append(flaps[k], flapEvent{time: times[i], withdraw: withdrawals[i], attrChanged: !withdrawals[i]})
+		}
+		s := buildFlapStats(*pfx, flaps, params, to)
+		s.Announcements, s.Withdrawals, s.AttributeChanges = *announce, *withdraw, *attrChanges
+		s.FirstEvent, s.LastEvent = model.FormatTime(*firstEvent), model.FormatTime(*lastEvent)
+		if s.Suppressed {
+			res.Summary.SuppressedPrefixes++
+		}
+		if s.SuppressCount > 0 {
+			res.Summary.EverSuppressed++
+		}
+		res.Prefixes = append(res.Prefixes, s)
+	}
+	return res, rows.Err()
+}
+
+// buildFlapStats runs the dampening simulation for each path of a prefix,
+// given the withdrawals and attribute changes of each path in time order.
+// The prefix reports the highest penalties of its paths, whether any of
+// them is suppressed, their total suppressions and the longest time any of
+// them was suppressed. Counters and event times are left to the caller.
+func buildFlapStats(prefix string, flaps map[flapPath][]flapEvent, params model.DampeningParams, end time.Time) model.PrefixFlapStats {
+	s := model.PrefixFlapStats{Prefix: prefix}
+	var suppressedFor time.Duration
+	for _, events := range flaps {
+		d := simulateDampening(events, params, end)
+		s.Penalty = math.Max(s.Penalty, math.Round(d.penalty))
+		s.MaxPenalty = math.Max(s.MaxPenalty, math.Round(d.maxPenalty))
+		s.Suppressed = s.Suppressed || d.suppressed
+		s.SuppressCount += d.suppressCount
+		suppressedFor = max(suppressedFor, d.suppressedFor)
+	}
+	s.SuppressedSeconds = int64(suppressedFor.Seconds())
+	return s
+}
+
+// dampeningResult is the outcome of an RFC 2439 penalty simulation.
+type dampeningResult struct {
+	penalty       float64
+	maxPenalty    float64
+	suppressed    bool
+	suppressCount int
+	suppressedFor time.Duration
+}
+
+// simulateDampening replays events through the RFC 2439 figure-of-merit
+// algorithm: the penalty decays exponentially with the configured half-life,
+// each withdrawal or attribute change adds a fixed penalty, the route is
+// suppressed once the penalty reaches the suppress threshold and reused once
+// it decays below the reuse threshold. The penalty is capped so that no route
+// stays suppressed longer than the maximum suppress time. The state is
+// evaluated at end.
+func simulateDampening(events []flapEvent, p model.DampeningParams, end time.Time) dampeningResult {
+	halfLife := time.Duration(p.HalfLifeMinutes) * time.Minute
+	maxSuppress := time.Duration(p.MaxSuppressMinutes) * time.Minute
+	reuse := float64(p.ReuseThreshold)
+	ceiling := reuse * math.Exp2(maxSuppress.Seconds()/halfLife.Seconds())
+
+	var (
+		res             dampeningResult
+		last            time.Time
+		suppressedSince time.Time
+	)
+
+	// decayTo advances the penalty to t, releasing the route if the penalty
+	// crosses the reuse threshold on the way.
+	decayTo := func(t time.Time) {
+		if last.IsZero() || !t.After(last) {
+			return
+		}
+		before := res.penalty
+		res.penalty = before * math.Exp2(-t.Sub(last).Seconds()/halfLife.Seconds())
+		if res.suppressed && res.penalty < reuse {
+			reusedAt := last.Add(time.Duration(math.Log2(before/reuse) * float64(halfLife)))
+			res.suppressedFor += reusedAt.Sub(suppressedSince)
+			res.suppressed = false
+		}
+		last = t
+	}
+
+	for _, e := range events {
+		decayTo(e.time)
+		last = e.time
+
+		switch {
+		case e.withdraw:
+			res.penalty += withdrawPenalty
+		case e.attrChanged:
+			res.penalty += attrChangePenalty
+		}
+		res.penalty = math.Min(res.penalty, ceiling)
+		res.maxPenalty = math.Max(res.maxPenalty, res.penalty)
+
+		if !res.suppressed && res.penalty >= float64(p.SuppressThreshold) {
+			res.suppressed = true
+			res.suppressCount++
+			suppressedSince = e.time
+		}
+	}
+
+	decayTo(end)
+	if res.suppressed && end.After(suppressedSince) {
+		res.suppressedFor += end.Sub(suppressedSince)
+	}
+	return res
+}
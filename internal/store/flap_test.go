@@ -0,0 +1,108 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+var defaultDampening = model.DampeningParams{
+	HalfLifeMinutes:    15,
+	ReuseThreshold:     750,
+	SuppressThreshold:  2000,
+	MaxSuppressMinutes: 60,
+}
+
+func TestSimulateDampening_NoFlaps(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []flapEvent{{time: t0}, {time: t0.Add(time.Minute)}}
+
+	res := simulateDampening(events, defaultDampening, t0.Add(time.Hour))
+	if res.penalty != 0 || res.suppressed || res.suppressCount != 0 {
+		t.Fatalf("expected no penalty, got %+v", res)
+	}
+}
+
+func TestSimulateDampening_HalfLifeDecay(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []flapEvent{{time: t0, withdraw: true}}
+
+	res := simulateDampening(events, defaultDampening, t0.Add(15*time.Minute))
+	if res.penalty < 499.9 || res.penalty > 500.1 {
+		t.Fatalf("expected penalty 500 after one half-life, got %f", res.penalty)
+	}
+	if res.maxPenalty != 1000 {
+		t.Fatalf("expected max penalty 1000, got %f", res.maxPenalty)
+	}
+}
+
+func TestSimulateDampening_SuppressAndReuse(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []flapEvent{
+		{time: t0, withdraw: true},
+		{time: t0.Add(10 * time.Second)},
+		{time: t0.Add(20 * time.Second), withdraw: true},
+		{time: t0.Add(30 * time.Second)},
+		{time: t0.Add(40 * time.Second), withdraw: true},
+	}
+
+	// Shortly after the third withdrawal the route must be suppressed.
+	res := simulateDampening(events, defaultDampening, t0.Add(time.Minute))
+	if !res.suppressed || res.suppressCount != 1 {
+		t.Fatalf("expected suppressed route, got %+v", res)
+	}
+
+	// ~2970 decays below 750 after roughly two half-lives.
+	res = simulateDampening(events, defaultDampening, t0.Add(2*time.Hour))
+	if res.suppressed {
+		t.Fatalf("expected route to be reused, got %+v", res)
+	}
+	if res.suppressedFor < 25*time.Minute || res.suppressedFor > 35*time.Minute {
+		t.Fatalf("expected ~30m of suppression, got %s", res.suppressedFor)
+	}
+}
+
+func TestSimulateDampening_MaxSuppressCeiling(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var events []flapEvent
+	for i := 0; i < 50; i++ {
+		events = append(events, flapEvent{time: t0.Add(time.Duration(i) * time.Second), withdraw: true})
+	}
+
+	res := simulateDampening(events, defaultDampening, t0.Add(time.Minute))
+	// reuse * 2^(max_suppress/half_life) = 750 * 16
+	if res.maxPenalty != 12000 {
+		t.Fatalf("expected penalty capped at 12000, got %f", res.maxPenalty)
+	}
+}
+
+func TestBuildFlapStats_PenaltyPerPath(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	flapping := []flapEvent{
+		{time: t0, withdraw: true},
+		{time: t0.Add(10 * time.Second), withdraw: true},
+	}
+
+	// Two paths flapping at once stay below the suppress threshold each,
+	// although their penalties would add up to more than it.
+	s := buildFlapStats("10.0.0.0/24", map[flapPath][]flapEvent{
+		{"global", 0}: flapping,
+		{"global", 1}: flapping,
+	}, defaultDampening, t0.Add(time.Minute))
+	if s.Suppressed || s.SuppressCount != 0 {
+		t.Fatalf("expected no suppression across paths, got %+v", s)
+	}
+	if s.MaxPenalty < 1990 || s.MaxPenalty >= 2000 {
+		t.Fatalf("expected the max penalty of one path, got %f", s.MaxPenalty)
+	}
+
+	// A third withdrawal on one path suppresses the prefix.
+	s = buildFlapStats("10.0.0.0/24", map[flapPath][]flapEvent{
+		{"global", 0}: append(flapping, flapEvent{time: t0.Add(20 * time.Second), withdraw: true}),
+		{"global", 1}: flapping,
+	}, defaultDampening, t0.Add(time.Minute))
+	if !s.Suppressed || s.SuppressCount != 1 || s.SuppressedSeconds != 40 {
+		t.Fatalf("expected the flapping path to suppress the prefix, got %+v", s)
+	}
+}
@@ -2,6 +2,9 @@ package store
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
 	"net"
 	"strings"
 	"time"
@@ -9,82 +12,261 @@ import (
 	"github.com/pobradovic08/route-beacon/internal/model"
 )
 
-// GetRouteHistory returns historical route events for a prefix on a router.
-func (db *DB) GetRouteHistory(ctx context.Context, routerID, prefix string, from, to time.Time, limit int) ([]model.RouteEvent, error) {
+// HistoryQuery selects route events for a prefix. A nil RouterIDs matches all
+// routers; Subnets also matches every more-specific prefix. When After is set
+// only events strictly older than that position are returned. Changes
+// annotates each event with its difference to the previous event of the same
+// path, and HideUnchanged drops re-announcements without changes. Events
+// before Since are never read, so the state of a path preceding From is only
+// known if it changed on or after Since.
+type HistoryQuery struct {
+	RouterIDs     []string
+	Prefix        string
+	Subnets       bool
+	From          time.Time
+	To            time.Time
+	Since         time.Time
+	Limit         int
+	After         *HistoryCursor
+	Changes       bool
+	HideUnchanged bool
+}
+
+// HistoryCursor is a position in the (ingest_time, event_id) ordering of
+// route_events. It is unique per event, so pages never overlap or skip
+// events that share a timestamp.
+type HistoryCursor struct {
+	Time    time.Time
+	EventID []byte
+}
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Encode returns the opaque string form of the cursor: the ingest time in
+// Unix microseconds followed by the event ID, base64url encoded.
+func (c HistoryCursor) Encode() string {
+	buf := make([]byte, 8, 8+len(c.EventID))
+	binary.BigEndian.PutUint64(buf, uint64(c.Time.UnixMicro()))
+	buf = append(buf, c.EventID...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// DecodeHistoryCursor parses a cursor produced by HistoryCursor.Encode.
+func DecodeHistoryCursor(s string) (*HistoryCursor, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(buf) <= 8 {
+		return nil, ErrInvalidCursor
+	}
+	return &HistoryCursor{
+		Time:    time.UnixMicro(int64(binary.BigEndian.Uint64(buf[:8]))).UTC(),
+		EventID: buf[8:],
+	}, nil
+}
+
+// Partition-aware scanning of route_events. The table is partitioned by UTC
+// day on ingest_time, so history is read newest first in windows aligned to
+// partition boundaries. A window starts at one day and doubles (up to
+// maxHistoryWindow) each time it comes back empty, so sparse months cost a
+// handful of queries while a busy day is answered from a single partition.
+const (
+	historyPartition = 24 * time.Hour
+	maxHistoryWindow = 32 * historyPartition
+)
+
+// GetRouteHistory returns up to q.Limit historical route events for a prefix,
+// newest first, and the cursor of the next page (nil on the last page).
+func (db *DB) GetRouteHistory(ctx context.Context, q HistoryQuery) ([]model.RouteEvent, *HistoryCursor, error) {
+	// hi is the exclusive upper bound of the next window.
+	hi := q.To.Add(time.Microsecond)
+	if q.After != nil && q.After.Time.Before(q.To) {
+		hi = q.After.Time.Add(time.Microsecond)
+	}
+
+	var (
+		events    []model.RouteEvent
+		positions []HistoryCursor
+		window    = historyPartition
+		after     = q.After
+	)
+	for hi.After(q.From) && len(events) <= q.Limit {
+		lo := historyWindowStart(hi, q.From, window)
+
+		want := q.Limit + 1 - len(events)
+		chunk, pos, err := db.queryHistoryWindow(ctx, q, lo, hi, after, want)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(chunk) == want {
+			// Refreshes may be dropped below, so the rest of the window
+			// may still be needed.
+			after = &pos[len(pos)-1]
+		} else {
+			if len(chunk) == 0 && window < maxHistoryWindow {
+				window *= 2
+			}
+			hi = lo
+		}
+
+		if q.Changes {
+			if err := db.annotateChanges(ctx, chunk, pos, q.Since); err != nil {
+				return nil, nil, err
+			}
+			if q.HideUnchanged {
+				chunk, pos = dropRefreshes(chunk, pos)
+			}
+		}
+		events = append(events, chunk...)
+		positions = append(positions, pos...)
+	}
+
+	var next *HistoryCursor
+	if len(events) > q.Limit {
+		events, positions = events[:q.Limit], positions[:q.Limit]
+		next = &positions[q.Limit-1]
+	}
+
+	if events == nil {
+		events = []model.RouteEvent{}
+	}
+	return events, next, nil
+}
+
+// dropRefreshes removes re-announcements without changes from events and
+// their positions.
+func dropRefreshes(events []model.RouteEvent, positions []HistoryCursor) ([]model.RouteEvent, []HistoryCursor) {
+	n := 0
+	for i, e := range events {
+		if e.ChangeType != nil && *e.ChangeType == ChangeRefresh {
+			continue
+		}
+		events[n], positions[n] = e, positions[i]
+		n++
+	}
+	return events[:n], positions[:n]
+}
+
+// historyWindowStart returns the inclusive lower bound of a window of the
+// given size ending at the exclusive bound hi. Windows start on a partition
+// boundary and never before from.
+func historyWindowStart(hi, from time.Time, window time.Duration) time.Time {
+	lo := hi.Add(-time.Microsecond).Truncate(historyPartition).Add(historyPartition - window)
+	if lo.Before(from) {
+		return from
+	}
+	return lo
+}
+
+// queryHistoryWindow returns up to limit events with lo <= ingest_time < hi
+// older than after, if set, newest first, along with the cursor position of
+// each event.
+func (db *DB) queryHistoryWindow(ctx context.Context, q HistoryQuery, lo, hi time.Time, after *HistoryCursor, limit int) ([]model.RouteEvent, []HistoryCursor, error) {
+	var afterTime *time.Time
+	var afterID []byte
+	if after != nil {
+		afterTime, afterID = &after.Time, after.EventID
+	}
+
 	rows, err := db.Pool.Query(ctx, `
-		SELECT ingest_time, action, prefix::text, path_id, nexthop, as_path,
-		       origin, localpref, med, origin_asn,
-		       communities_std, communities_ext, communities_large
+		SELECT event_id, `+eventColumns+`
 		FROM route_events
-		WHERE router_id = $1
-		  AND prefix = $2::cidr
-		  AND ingest_time BETWEEN $3 AND $4
-		ORDER BY ingest_time DESC
-		LIMIT $5
-	`, routerID, prefix, from, to, limit+1)
+		WHERE ($1::text[] IS NULL OR router_id = ANY($1))
+		  AND (prefix = $2::cidr OR ($3 AND prefix << $2::cidr))
+		  AND ingest_time >= $4 AND ingest_time < $5
+		  AND ($7::timestamptz IS NULL OR (ingest_time, event_id) < ($7, $8::bytea))
+		ORDER BY ingest_time DESC, event_id DESC
+		LIMIT $6
+	`, q.RouterIDs, q.Prefix, q.Subnets, lo, hi, limit, afterTime, afterID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var events []model.RouteEvent
+	var (
+		events    []model.RouteEvent
+		positions []HistoryCursor
+	)
 	for rows.Next() {
 		var (
+			eventID    []byte
 			ingestTime time.Time
-			action     string
-			pfx        string
-			pathID     *int64
-			nexthop    *net.IP
-			asPathStr  *string
-			origin     *string
-			localpref  *int
-			med        *int
-			originASN  *int
-			commStd    []string
-			commExt    []string
-			commLarge  []string
 		)
-		if err := rows.Scan(&ingestTime, &action, &pfx, &pathID, &nexthop, &asPathStr,
-			&origin, &localpref, &med, &originASN,
-			&commStd, &commExt, &commLarge); err != nil {
-			return nil, err
+		event, err := scanRouteEvent(rows, &ingestTime, &eventID)
+		if err != nil {
+			return nil, nil, err
 		}
+		events = append(events, event)
+		positions = append(positions, HistoryCursor{Time: ingestTime, EventID: eventID})
+	}
+	return events, positions, rows.Err()
+}
 
-		actionStr := "announce"
-		if action == "D" {
-			actionStr = "withdraw"
-		}
+// eventColumns is the route_events column list expected by scanRouteEvent.
+const eventColumns = `ingest_time, router_id, action, prefix::text, path_id, nexthop, as_path,
+		       origin, localpref, med, origin_asn,
+		       communities_std, communities_ext, communities_large`
 
-		var nhStr *string
-		if nexthop != nil {
-			s := nexthop.String()
-			nhStr = &s
-		}
+// scanRouteEvent reads one route_events row selected with eventColumns. Any
+// extra destinations are scanned from the columns preceding them. When
+// ingestTime is non-nil it receives the raw event time.
+func scanRouteEvent(row rowScanner, ingestTime *time.Time, extra ...any) (model.RouteEvent, error) {
+	var (
+		ts        time.Time
+		routerID  string
+		action    string
+		pfx       string
+		pathID    *int64
+		nexthop   *net.IP
+		asPathStr *string
+		origin    *string
+		localpref *int
+		med       *int
+		originASN *int
+		commStd   []string
+		commExt   []string
+		commLarge []string
+	)
+	dest := append(extra, &ts, &routerID, &action, &pfx, &pathID, &nexthop, &asPathStr,
+		&origin, &localpref, &med, &originASN,
+		&commStd, &commExt, &commLarge)
+	if err := row.Scan(dest...); err != nil {
+		return model.RouteEvent{}, err
+	}
+	if ingestTime != nil {
+		*ingestTime = ts
+	}
 
-		var originLower *string
-		if origin != nil {
-			l := strings.ToLower(*origin)
-			originLower = &l
-		}
+	actionStr := "announce"
+	if action == "D" {
+		actionStr = "withdraw"
+	}
 
-		events = append(events, model.RouteEvent{
-			Timestamp:           model.FormatTime(ingestTime),
-			Action:              actionStr,
-			Prefix:              pfx,
-			PathID:              pathID,
-			NextHop:             nhStr,
-			ASPath:              parseASPath(asPathStr),
-			Origin:              originLower,
-			LocalPref:           localpref,
-			MED:                 med,
-			OriginASN:           originASN,
-			Communities:         parseCommunities(commStd, "standard"),
-			ExtendedCommunities: parseCommunities(commExt, "extended"),
-			LargeCommunities:    parseCommunities(commLarge, "large"),
-		})
+	var nhStr *string
+	if nexthop != nil {
+		s := nexthop.String()
+		nhStr = &s
 	}
-	if events == nil {
-		events = []model.RouteEvent{}
+
+	var originLower *string
+	if origin != nil {
+		l := strings.ToLower(*origin)
+		originLower = &l
 	}
-	return events, rows.Err()
+
+	return model.RouteEvent{
+		Timestamp:           model.FormatTime(ts),
+		RouterID:            routerID,
+		Action:              actionStr,
+		Prefix:              pfx,
+		PathID:              pathID,
+		NextHop:             nhStr,
+		ASPath:              parseASPath(asPathStr),
+		Origin:              originLower,
+		LocalPref:           localpref,
+		MED:                 med,
+		OriginASN:           originASN,
+		Communities:         parseCommunities(commStd, "standard"),
+		ExtendedCommunities: parseCommunities(commExt, "extended"),
+		LargeCommunities:    parseCommunities(commLarge, "large"),
+	}, nil
 }
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestHistoryCursor_RoundTrip(t *testing.T) {
+	c := HistoryCursor{
+		Time:    time.Date(2025, 1, 1, 12, 0, 0, 123456000, time.UTC),
+		EventID: []byte{0xde, 0xad, 0xbe, 0xef},
+	}
+
+	got, err := DecodeHistoryCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if !got.Time.Equal(c.Time) {
+		t.Fatalf("expected time %s, got %s", c.Time, got.Time)
+	}
+	if !bytes.Equal(got.EventID, c.EventID) {
+		t.Fatalf("expected event ID %x, got %x", c.EventID, got.EventID)
+	}
+}
+
+func TestDecodeHistoryCursor_Invalid(t *testing.T) {
+	for _, s := range []string{"", "!!", "AAAA"} {
+		if _, err := DecodeHistoryCursor(s); err != ErrInvalidCursor {
+			t.Fatalf("%q: expected ErrInvalidCursor, got %v", s, err)
+		}
+	}
+}
+
+func TestHistoryWindowStart_AlignsToPartition(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	hi := time.Date(2025, 1, 10, 15, 30, 0, 0, time.UTC)
+
+	got := historyWindowStart(hi, from, historyPartition)
+	want := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	// A window ending exactly at midnight covers the previous day.
+	got = historyWindowStart(want, from, historyPartition)
+	want = time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestHistoryWindowStart_GrowsAndClamps(t *testing.T) {
+	from := time.Date(2025, 1, 5, 12, 0, 0, 0, time.UTC)
+	hi := time.Date(2025, 1, 10, 15, 30, 0, 0, time.UTC)
+
+	got := historyWindowStart(hi, from, 4*historyPartition)
+	want := time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	got = historyWindowStart(hi, from, 8*historyPartition)
+	if !got.Equal(from) {
+		t.Fatalf("expected window clamped to %s, got %s", from, got)
+	}
+}
@@ -0,0 +1,246 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// maxIntervalEvents bounds the number of route_events replayed to build
+// state intervals in a single request.
+const maxIntervalEvents = 100000
+
+// ErrTooManyEvents is returned when a query would replay more than
+// maxIntervalEvents events.
+var ErrTooManyEvents = errors.New("too many events in range")
+
+// GetRouteIntervals converts the route_events matching q into state intervals
+// per (router, prefix, path_id), ordered by key and start time. The state at
+// q.From is taken from the last event before it and on or after q.Since, so
+// paths announced before the window start with an interval clipped to
+// q.From. Events sharing a timestamp are replayed in event_id order like
+// the history. q.Limit and q.After are ignored.
+func (db *DB) GetRouteIntervals(ctx context.Context, q HistoryQuery) ([]model.RouteInterval, error) {
+	intervals, err := db.routeIntervals(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return intervalsOf(intervals), nil
+}
+
+// routeIntervals is GetRouteIntervals with the raw bounds of every interval.
+func (db *DB) routeIntervals(ctx context.Context, q HistoryQuery) ([]timedInterval, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT * FROM (
+			(SELECT DISTINCT ON (router_id, prefix, COALESCE(path_id, 0))
+			        true AS initial, `+eventColumns+`
+			 FROM route_events
+			 WHERE ($1::text[] IS NULL OR router_id = ANY($1))
+			   AND (prefix = $2::cidr OR ($3 AND prefix << $2::cidr))
+			   AND ingest_time < $4
+			   AND ingest_time >= $7
+			 ORDER BY router_id, prefix, COALESCE(path_id, 0), ingest_time DESC, event_id DESC)
+			UNION ALL
+			(SELECT false AS initial, `+eventColumns+`
+			 FROM route_events
+			 WHERE ($1::text[] IS NULL OR router_id = ANY($1))
+			   AND (prefix = $2::cidr OR ($3 AND prefix << $2::cidr))
+			   AND ingest_time BETWEEN $4 AND $5
+			 ORDER BY ingest_time, event_id
+			 LIMIT $6)
+		) ev
+		ORDER BY router_id, prefix::cidr, COALESCE(path_id, 0), initial DESC, ingest_time, event_id
+	`, q.RouterIDs, q.Prefix, q.Subnets, q.From, q.To, maxIntervalEvents+1, q.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type pathKey struct {
+		routerID string
+		prefix   string
+		pathID   int64
+	}
+	var (
+		intervals []timedInterval
+		current   pathKey
+		initial   *model.RouteEvent
+		events    []intervalEvent
+		count     int
+	)
+	flush := func() {
+		intervals = append(intervals, buildIntervals(initial, events, q.From, q.To)...)
+		initial, events = nil, events[:0]
+	}
+	for rows.Next() {
+		var (
+			isInitial  bool
+			ingestTime time.Time
+		)
+		event, err := scanRouteEvent(rows, &ingestTime, &isInitial)
+		if err != nil {
+			return nil, err
+		}
+		if !isInitial {
+			if count++; count > maxIntervalEvents {
+				return nil, ErrTooManyEvents
+			}
+		}
+
+		k := pathKey{routerID: event.RouterID, prefix: event.Prefix}
+		if event.PathID != nil {
+			k.pathID = *event.PathID
+		}
+		if k != current {
+			flush()
+			current = k
+		}
+		if isInitial {
+			initial = &event
+		} else {
+			events = append(events, intervalEvent{time: ingestTime, event: event})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return intervals, nil
+}
+
+// intervalEvent is a route event with its raw timestamp.
+type intervalEvent struct {
+	time  time.Time
+	event model.RouteEvent
+}
+
+// timedInterval is a state interval with its raw start and end time.
+type timedInterval struct {
+	start, end time.Time
+	model.RouteInterval
+}
+
+// intervalsOf returns the state intervals of timed, never nil.
+func intervalsOf(timed []timedInterval) []model.RouteInterval {
+	intervals := make([]model.RouteInterval, 0, len(timed))
+	for _, iv := range timed {
+		intervals = append(intervals, iv.RouteInterval)
+	}
+	return intervals
+}
+
+// buildIntervals replays the events of one path, in time order, into state
+// intervals within [from, to]. initial is the last event before from, if any.
+// Re-announcements without attribute changes extend the current interval.
+func buildIntervals(initial *model.RouteEvent, events []intervalEvent, from, to time.Time) []timedInterval {
+	var (
+		intervals []timedInterval
+		cur       *model.RouteEvent
+		start     time.Time
+	)
+	closeAt := func(end time.Time, ongoing bool) {
+		if cur == nil {
+			return
+		}
+		intervals = append(intervals, timedInterval{start, end, newRouteInterval(*cur, start, end, ongoing)})
+		cur = nil
+	}
+
+	if initial != nil && initial.Action == "announce" {
+		cur, start = initial, from
+	}
+	for i := range events {
+		e := &events[i]
+		if e.event.Action == "withdraw" {
+			closeAt(e.time, false)
+			continue
+		}
+		if cur != nil && len(diffRouteAttrs(attrsOfEvent(*cur), attrsOfEvent(e.event))) == 0 {
+			continue
+		}
+		closeAt(e.time, false)
+		cur, start = &e.event, e.time
+	}
+	closeAt(to, true)
+	return intervals
+}
+
+// newRouteInterval builds an interval carrying the attributes of the
+// announcement that opened it.
+func newRouteInterval(e model.RouteEvent, start, end time.Time, ongoing bool) model.RouteInterval {
+	return model.RouteInterval{
+		RouterID:            e.RouterID,
+		Prefix:              e.Prefix,
+		PathID:              e.PathID,
+		Start:               model.FormatTime(start),
+		End:                 model.FormatTime(end),
+		Ongoing:             ongoing,
+		DurationSeconds:     end.Sub(start).Seconds(),
+		NextHop:             e.NextHop,
+		ASPath:              e.ASPath,
+		Origin:              e.Origin,
+		LocalPref:           e.LocalPref,
+		MED:                 e.MED,
+		OriginASN:           e.OriginASN,
+		Communities:         e.Communities,
+		ExtendedCommunities: e.ExtendedCommunities,
+		LargeCommunities:    e.LargeCommunities,
+	}
+}
+
+// GetHistoryBuckets summarises the route_events matching q per time bucket
+// ("hour" or "day", UTC), router and prefix, newest bucket first. It returns
+// up to q.Limit buckets and whether more exist. q.After is ignored.
+func (db *DB) GetHistoryBuckets(ctx context.Context, q HistoryQuery, resolution string) ([]model.HistoryBucket, bool, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT date_trunc($6, ingest_time, 'UTC') AS bucket, router_id, prefix::text,
+		       COUNT(*) FILTER (WHERE action = 'A') AS announcements,
+		       COUNT(*) FILTER (WHERE action = 'D') AS withdrawals,
+		       COUNT(DISTINCT COALESCE(path_id, 0)) AS paths,
+		       (array_agg(action ORDER BY ingest_time DESC, event_id DESC))[1] AS last_action
+		FROM route_events
+		WHERE ($1::text[] IS NULL OR router_id = ANY($1))
+		  AND (prefix = $2::cidr OR ($3 AND prefix << $2::cidr))
+		  AND ingest_time BETWEEN $4 AND $5
+		GROUP BY 1, 2, 3
+		ORDER BY 1 DESC, 2, 3
+		LIMIT $7
+	`, q.RouterIDs, q.Prefix, q.Subnets, q.From, q.To, resolution, q.Limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var buckets []model.HistoryBucket
+	for rows.Next() {
+		var (
+			bucket     time.Time
+			b          model.HistoryBucket
+			lastAction string
+		)
+		if err := rows.Scan(&bucket, &b.RouterID, &b.Prefix,
+			&b.Announcements, &b.Withdrawals, &b.Paths, &lastAction); err != nil {
+			return nil, false, err
+		}
+		b.Time = model.FormatTime(bucket)
+		b.LastAction = "announce"
+		if lastAction == "D" {
+			b.LastAction = "withdraw"
+		}
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	truncated := len(buckets) > q.Limit
+	if truncated {
+		buckets = buckets[:q.Limit]
+	}
+	if buckets == nil {
+		buckets = []model.HistoryBucket{}
+	}
+	return buckets, truncated, nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+func announceEvent(nh string) model.RouteEvent {
+	return model.RouteEvent{RouterID: "r1", Prefix: "10.0.0.0/24", Action: "announce", NextHop: &nh}
+}
+
+func withdrawEvent() model.RouteEvent {
+	return model.RouteEvent{RouterID: "r1", Prefix: "10.0.0.0/24", Action: "withdraw"}
+}
+
+func TestBuildIntervals_InitialStateClippedToFrom(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	initial := announceEvent("192.0.2.1")
+
+	intervals := buildIntervals(&initial, nil, from, to)
+	if len(intervals) != 1 {
+		t.Fatalf("expected 1 interval, got %d", len(intervals))
+	}
+	iv := intervals[0]
+	if iv.Start != "2025-01-01T00:00:00Z" || iv.End != "2025-01-01T01:00:00Z" || !iv.Ongoing {
+		t.Fatalf("unexpected interval: %+v", iv)
+	}
+	if iv.DurationSeconds != 3600 {
+		t.Fatalf("expected 3600s, got %f", iv.DurationSeconds)
+	}
+}
+
+func TestBuildIntervals_WithdrawnInitialState(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	initial := withdrawEvent()
+
+	if intervals := buildIntervals(&initial, nil, from, from.Add(time.Hour)); len(intervals) != 0 {
+		t.Fatalf("expected no intervals, got %+v", intervals)
+	}
+}
+
+func TestBuildIntervals_ChangesAndWithdrawals(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	events := []intervalEvent{
+		{time: from.Add(5 * time.Minute), event: announceEvent("192.0.2.1")},
+		// Implicit re-announcement without changes extends the interval.
+		{time: from.Add(10 * time.Minute), event: announceEvent("192.0.2.1")},
+		{time: from.Add(20 * time.Minute), event: announceEvent("192.0.2.2")},
+		{time: from.Add(30 * time.Minute), event: withdrawEvent()},
+		{time: from.Add(40 * time.Minute), event: announceEvent("192.0.2.2")},
+	}
+
+	intervals := buildIntervals(nil, events, from, to)
+	if len(intervals) != 3 {
+		t.Fatalf("expected 3 intervals, got %d: %+v", len(intervals), intervals)
+	}
+	want := []struct {
+		start, end string
+		nh         string
+		ongoing    bool
+	}{
+		{"2025-01-01T00:05:00Z", "2025-01-01T00:20:00Z", "192.0.2.1", false},
+		{"2025-01-01T00:20:00Z", "2025-01-01T00:30:00Z", "192.0.2.2", false},
+		{"2025-01-01T00:40:00Z", "2025-01-01T01:00:00Z", "192.0.2.2", true},
+	}
+	for i, w := range want {
+		iv := intervals[i]
+		if iv.Start != w.start || iv.End != w.end || *iv.NextHop != w.nh || iv.Ongoing != w.ongoing {
+			t.Fatalf("interval %d: unexpected %+v", i, iv)
+		}
+	}
+}
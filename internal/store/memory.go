@@ -0,0 +1,746 @@
+package store
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// Memory is a read-only store serving the router, route lookup, history and
+// health queries of DB from fixtures held in memory, for demo deployments
+// without PostgreSQL and for tests. It is safe for concurrent use.
+type Memory struct {
+	routers     []FixtureRouter // ordered by ID
+	routeCounts map[string]int64
+	routes      []memRoute // ordered by router, prefix and path ID
+	events      []memEvent // ordered by time, then file order
+}
+
+// memRoute is a current route of a router.
+type memRoute struct {
+	routerID  string
+	table     string
+	prefix    netip.Prefix
+	asPath    string
+	updatedAt time.Time
+	route     model.Route
+}
+
+// memEvent is a route event with its position in the history ordering.
+type memEvent struct {
+	table  string
+	prefix netip.Prefix
+	time   time.Time
+	id     []byte
+	event  model.RouteEvent
+}
+
+// position returns the history cursor of e.
+func (e *memEvent) position() HistoryCursor {
+	return HistoryCursor{Time: e.time, EventID: e.id}
+}
+
+// before reports whether e precedes position c in (time, event ID) order.
+func (e *memEvent) before(c HistoryCursor) bool {
+	return e.time.Before(c.Time) || e.time.Equal(c.Time) && bytes.Compare(e.id, c.EventID) < 0
+}
+
+// NewMemory validates fixtures and indexes them for querying.
+func NewMemory(f Fixtures) (*Memory, error) {
+	m := &Memory{routeCounts: map[string]int64{}}
+
+	known := map[string]bool{}
+	for i, r := range f.Routers {
+		if r.ID == "" {
+			return nil, fmt.Errorf("routers[%d]: id must not be empty", i)
+		}
+		if known[r.ID] {
+			return nil, fmt.Errorf("routers[%d]: duplicate id %q", i, r.ID)
+		}
+		known[r.ID] = true
+		ip, err := normalizeNextHop(r.RouterIP)
+		if err != nil {
+			return nil, fmt.Errorf("routers[%d]: invalid router_ip %q", i, *r.RouterIP)
+		}
+		r.RouterIP = ip
+		m.routers = append(m.routers, r)
+	}
+	slices.SortFunc(m.routers, func(a, b FixtureRouter) int { return cmp.Compare(a.ID, b.ID) })
+
+	for i, r := range f.Routes {
+		if !known[r.RouterID] {
+			return nil, fmt.Errorf("routes[%d]: unknown router %q", i, r.RouterID)
+		}
+		p, err := parseFixturePrefix(r.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("routes[%d]: %w", i, err)
+		}
+		if r.NextHop, err = normalizeNextHop(r.NextHop); err != nil {
+			return nil, fmt.Errorf("routes[%d]: %w", i, err)
+		}
+		route := fixtureRoute(p, r.PathID, r.FixtureAttrs, r.FirstSeen, r.UpdatedAt)
+		m.routes = append(m.routes, memRoute{
+			routerID:  r.RouterID,
+			table:     cmp.Or(r.Table, "global"),
+			prefix:    p,
+			asPath:    deref(r.ASPath),
+			updatedAt: r.UpdatedAt,
+			route:     route,
+		})
+		m.routeCounts[r.RouterID]++
+	}
+	slices.SortStableFunc(m.routes, func(a, b memRoute) int {
+		return cmp.Or(cmp.Compare(a.routerID, b.routerID),
+			ComparePrefix(a.prefix, b.prefix),
+			cmp.Compare(a.route.PathID, b.route.PathID))
+	})
+
+	for i, e := range f.Events {
+		if !known[e.RouterID] {
+			return nil, fmt.Errorf("events[%d]: unknown router %q", i, e.RouterID)
+		}
+		if e.Action != "announce" && e.Action != "withdraw" {
+			return nil, fmt.Errorf("events[%d]: action must be 'announce' or 'withdraw'", i)
+		}
+		if e.Time.IsZero() {
+			return nil, fmt.Errorf("events[%d]: time is required", i)
+		}
+		p, err := parseFixturePrefix(e.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("events[%d]: %w", i, err)
+		}
+		if e.NextHop, err = normalizeNextHop(e.NextHop); err != nil {
+			return nil, fmt.Errorf("events[%d]: %w", i, err)
+		}
+		// route_events stores microseconds, the precision of cursors.
+		t := e.Time.Truncate(time.Microsecond)
+		m.events = append(m.events, memEvent{
+			table:  cmp.Or(e.Table, "global"),
+			prefix: p,
+			time:   t,
+			event:  fixtureEvent(t, e),
+		})
+	}
+	slices.SortStableFunc(m.events, func(a, b memEvent) int { return a.time.Compare(b.time) })
+	for i := range m.events {
+		m.events[i].id = binary.BigEndian.AppendUint64(nil, uint64(i+1))
+	}
+	return m, nil
+}
+
+func fixtureRoute(p netip.Prefix, pathID int64, a FixtureAttrs, firstSeen, updatedAt time.Time) model.Route {
+	return model.Route{
+		Prefix:              p.String(),
+		PathID:              pathID,
+		NextHop:             a.NextHop,
+		ASPath:              parseASPath(a.ASPath),
+		Origin:              lower(a.Origin),
+		LocalPref:           a.LocalPref,
+		MED:                 a.MED,
+		OriginASN:           a.OriginASN,
+		Communities:         parseCommunities(a.Communities, "standard"),
+		ExtendedCommunities: parseCommunities(a.ExtendedCommunities, "extended"),
+		LargeCommunities:    parseCommunities(a.LargeCommunities, "large"),
+		FirstSeen:           model.FormatTime(firstSeen),
+		UpdatedAt:           model.FormatTime(updatedAt),
+	}
+}
+
+func fixtureEvent(t time.Time, e FixtureEvent) model.RouteEvent {
+	p, _ := netip.ParsePrefix(e.Prefix)
+	return model.RouteEvent{
+		Timestamp:           model.FormatTime(t),
+		RouterID:            e.RouterID,
+		Action:              e.Action,
+		Prefix:              p.String(),
+		PathID:              e.PathID,
+		NextHop:             e.NextHop,
+		ASPath:              parseASPath(e.ASPath),
+		Origin:              lower(e.Origin),
+		LocalPref:           e.LocalPref,
+		MED:                 e.MED,
+		OriginASN:           e.OriginASN,
+		Communities:         parseCommunities(e.Communities, "standard"),
+		ExtendedCommunities: parseCommunities(e.ExtendedCommunities, "extended"),
+		LargeCommunities:    parseCommunities(e.LargeCommunities, "large"),
+	}
+}
+
+func lower(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	l := strings.ToLower(*s)
+	return &l
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// ComparePrefix orders prefixes like PostgreSQL orders cidr values: IPv4
+// first, then by the bits both prefixes cover, then covering prefixes first.
+// The RIB cache shares it so both stores return routes in the same order.
+func ComparePrefix(a, b netip.Prefix) int {
+	if c := cmp.Compare(a.Addr().BitLen(), b.Addr().BitLen()); c != 0 {
+		return c
+	}
+	bits := min(a.Bits(), b.Bits())
+	na := netip.PrefixFrom(a.Addr(), bits).Masked().Addr()
+	nb := netip.PrefixFrom(b.Addr(), bits).Masked().Addr()
+	return cmp.Or(na.Compare(nb), cmp.Compare(a.Bits(), b.Bits()))
+}
+
+// within reports whether p is equal to or more specific than q.
+func within(p, q netip.Prefix) bool {
+	return p.Addr().BitLen() == q.Addr().BitLen() && p.Bits() >= q.Bits() && q.Contains(p.Addr())
+}
+
+func (m *Memory) router(id string) (FixtureRouter, bool) {
+	i, found := slices.BinarySearchFunc(m.routers, id, func(r FixtureRouter, id string) int {
+		return cmp.Compare(r.ID, id)
+	})
+	if !found {
+		return FixtureRouter{}, false
+	}
+	return m.routers[i], true
+}
+
+// online mirrors the routers_overview definition of an online router.
+func (m *Memory) online(r FixtureRouter) bool {
+	return m.routeCounts[r.ID] > 0 || r.SessionStart != nil
+}
+
+func (m *Memory) modelRouter(r FixtureRouter) model.Router {
+	name := r.ID
+	if r.DisplayName != nil {
+		name = *r.DisplayName
+	} else if r.Hostname != nil {
+		name = *r.Hostname
+	}
+	status := "down"
+	if m.online(r) {
+		status = "up"
+	}
+	return model.Router{
+		ID:          r.ID,
+		RouterIP:    r.RouterIP,
+		Hostname:    r.Hostname,
+		DisplayName: name,
+		ASNumber:    r.ASNumber,
+		Description: r.Description,
+		Location:    r.Location,
+		Status:      status,
+		EORReceived: r.EORReceived != nil && *r.EORReceived,
+		FirstSeen:   formatOptionalTime(r.FirstSeen),
+		LastSeen:    formatOptionalTime(r.LastSeen),
+	}
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return model.FormatTime(t)
+}
+
+func formatTimePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	v := model.FormatTime(*t)
+	return &v
+}
+
+// ListRouters returns all routers ordered by ID.
+func (m *Memory) ListRouters(ctx context.Context) ([]model.Router, error) {
+	routers := make([]model.Router, len(m.routers))
+	for i, r := range m.routers {
+		routers[i] = m.modelRouter(r)
+	}
+	return routers, nil
+}
+
+// GetRouterDetail returns a router with routing table statistics, or nil if
+// it does not exist.
+func (m *Memory) GetRouterDetail(ctx context.Context, routerID string) (*model.RouterDetail, error) {
+	r, ok := m.router(routerID)
+	if !ok {
+		return nil, nil
+	}
+	d := &model.RouterDetail{
+		Router:        m.modelRouter(r),
+		SessionStart:  formatTimePtr(r.SessionStart),
+		SyncUpdatedAt: formatTimePtr(r.SyncUpdatedAt),
+	}
+
+	prefixes := map[netip.Prefix]bool{}
+	nextHops := map[string]bool{}
+	var pathLen, withPath int
+	for _, mr := range m.routes {
+		if mr.routerID != routerID {
+			continue
+		}
+		d.RouteCount++
+		prefixes[mr.prefix] = true
+		if mr.route.NextHop != nil {
+			nextHops[*mr.route.NextHop] = true
+		}
+		if mr.prefix.Addr().Is4() {
+			d.IPv4Routes++
+		} else {
+			d.IPv6Routes++
+		}
+		if mr.asPath != "" {
+			pathLen += strings.Count(mr.asPath, " ") + 1
+			withPath++
+		}
+	}
+	d.UniquePrefixes = int64(len(prefixes))
+	d.PeerCount = int64(len(nextHops))
+	if withPath > 0 {
+		avg := float64(pathLen) / float64(withPath)
+		d.AvgASPathLen = &avg
+	}
+	return d, nil
+}
+
+// GetRouterSummary returns minimal router info and its status, or nil if
+// the router does not exist.
+func (m *Memory) GetRouterSummary(ctx context.Context, routerID string) (*model.RouterSummary, string, error) {
+	r, ok := m.router(routerID)
+	if !ok {
+		return nil, "", nil
+	}
+	mr := m.modelRouter(r)
+	return &model.RouterSummary{ID: mr.ID, DisplayName: mr.DisplayName, ASNumber: mr.ASNumber}, mr.Status, nil
+}
+
+// GetHealthSummary returns aggregate health stats.
+func (m *Memory) GetHealthSummary(ctx context.Context) (*HealthSummary, error) {
+	s := &HealthSummary{RouterCount: len(m.routers)}
+	for _, r := range m.routers {
+		if m.online(r) {
+			s.OnlineRouters++
+		}
+		s.TotalRoutes += m.routeCounts[r.ID]
+		if r.EORReceived != nil {
+			all := *r.EORReceived && (s.AllEOR == nil || *s.AllEOR)
+			s.AllEOR = &all
+		}
+	}
+	return s, nil
+}
+
+// ExactLookup returns the current routes of exactly prefix on a router.
+func (m *Memory) ExactLookup(ctx context.Context, routerID, prefix string) ([]model.Route, error) {
+	p, err := parseFixturePrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	routes := []model.Route{}
+	for _, mr := range m.routes {
+		if mr.routerID == routerID && mr.prefix == p {
+			routes = append(routes, mr.route)
+		}
+	}
+	return routes, nil
+}
+
+// LPMLookup returns the current routes of the longest prefix containing ip
+// on a router.
+func (m *Memory) LPMLookup(ctx context.Context, routerID, ip string) ([]model.Route, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q", ip)
+	}
+	var best netip.Prefix
+	for _, mr := range m.routes {
+		if mr.routerID == routerID && mr.prefix.Contains(addr) &&
+			(!best.IsValid() || mr.prefix.Bits() > best.Bits()) {
+			best = mr.prefix
+		}
+	}
+	routes := []model.Route{}
+	if !best.IsValid() {
+		return routes, nil
+	}
+	return m.ExactLookup(ctx, routerID, best.String())
+}
+
+// SubnetLookup returns the current routes of prefix and its more-specifics
+// on a router, ordered by prefix and path ID, up to limit routes.
+func (m *Memory) SubnetLookup(ctx context.Context, routerID, prefix string, limit int) ([]model.Route, bool, error) {
+	p, err := parseFixturePrefix(prefix)
+	if err != nil {
+		return nil, false, err
+	}
+	routes := []model.Route{}
+	for _, mr := range m.routes {
+		if mr.routerID != routerID || !within(mr.prefix, p) {
+			continue
+		}
+		if len(routes) == limit {
+			return routes, true, nil
+		}
+		routes = append(routes, mr.route)
+	}
+	return routes, false, nil
+}
+
+// ribAt reconstructs the routes of a router at a point in time like
+// ribAtCTE, keeping the prefixes accepted by match: paths without events
+// after at are current routes, the others are replayed from their events
+// between from and at.
+func (m *Memory) ribAt(routerID string, at, from time.Time, match func(netip.Prefix) bool) []memRoute {
+	type pathKey struct {
+		table  string
+		prefix netip.Prefix
+		pathID int64
+	}
+	type pathState struct {
+		last     *memEvent
+		runStart time.Time
+	}
+	changed := map[pathKey]bool{}
+	for i := range m.events {
+		e := &m.events[i]
+		if e.time.After(at) && e.event.RouterID == routerID && match(e.prefix) {
+			changed[pathKey{e.table, e.prefix, deref64(e.event.PathID)}] = true
+		}
+	}
+
+	var rib []memRoute
+	for _, mr := range m.routes {
+		if mr.routerID == routerID && match(mr.prefix) && !mr.updatedAt.After(at) &&
+			!changed[pathKey{mr.table, mr.prefix, mr.route.PathID}] {
+			rib = append(rib, mr)
+		}
+	}
+
+	state := map[pathKey]*pathState{}
+	for i := range m.events {
+		e := &m.events[i]
+		if e.time.After(at) {
+			break
+		}
+		k := pathKey{e.table, e.prefix, deref64(e.event.PathID)}
+		if e.time.Before(from) || e.event.RouterID != routerID || !changed[k] {
+			continue
+		}
+		s := state[k]
+		if s == nil {
+			s = &pathState{}
+			state[k] = s
+		}
+		s.last = e
+		switch {
+		case e.event.Action == "withdraw":
+			s.runStart = time.Time{}
+		case s.runStart.IsZero():
+			s.runStart = e.time
+		}
+	}
+	for k, s := range state {
+		if s.last.event.Action != "announce" {
+			continue
+		}
+		ev := s.last.event
+		route := model.Route{
+			Prefix:              ev.Prefix,
+			PathID:              k.pathID,
+			NextHop:             ev.NextHop,
+			ASPath:              ev.ASPath,
+			Origin:              ev.Origin,
+			LocalPref:           ev.LocalPref,
+			MED:                 ev.MED,
+			OriginASN:           ev.OriginASN,
+			Communities:         ev.Communities,
+			ExtendedCommunities: ev.ExtendedCommunities,
+			LargeCommunities:    ev.LargeCommunities,
+			FirstSeen:           model.FormatTime(s.runStart),
+			UpdatedAt:           model.FormatTime(s.last.time),
+		}
+		rib = append(rib, memRoute{routerID: routerID, table: k.table, prefix: k.prefix, updatedAt: s.last.time, route: route})
+	}
+	slices.SortFunc(rib, func(a, b memRoute) int {
+		return cmp.Or(ComparePrefix(a.prefix, b.prefix), cmp.Compare(a.route.PathID, b.route.PathID))
+	})
+	return rib
+}
+
+func deref64(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// ExactLookupAt returns the routes of exactly prefix on a router as they
+// were at the given time, replaying events from the time from on.
+func (m *Memory) ExactLookupAt(ctx context.Context, routerID, prefix string, at, from time.Time) ([]model.Route, error) {
+	p, err := parseFixturePrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	routes := []model.Route{}
+	for _, mr := range m.ribAt(routerID, at, from, func(q netip.Prefix) bool { return q == p }) {
+		routes = append(routes, mr.route)
+	}
+	return routes, nil
+}
+
+// LPMLookupAt returns the routes of the longest prefix containing ip on a
+// router as they were at the given time, replaying events from the time
+// from on.
+func (m *Memory) LPMLookupAt(ctx context.Context, routerID, ip string, at, from time.Time) ([]model.Route, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q", ip)
+	}
+	rib := m.ribAt(routerID, at, from, func(q netip.Prefix) bool { return q.Contains(addr) })
+	routes := []model.Route{}
+	if len(rib) == 0 {
+		return routes, nil
+	}
+	// The RIB is ordered by prefix, so the longest match comes last.
+	best := rib[len(rib)-1].prefix
+	for _, mr := range rib {
+		if mr.prefix == best {
+			routes = append(routes, mr.route)
+		}
+	}
+	return routes, nil
+}
+
+// historyFilter returns the event filter of q.
+func historyFilter(q HistoryQuery) (func(*memEvent) bool, error) {
+	p, err := parseFixturePrefix(q.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	return func(e *memEvent) bool {
+		if q.RouterIDs != nil && !slices.Contains(q.RouterIDs, e.event.RouterID) {
+			return false
+		}
+		return e.prefix == p || q.Subnets && within(e.prefix, p)
+	}, nil
+}
+
+// GetRouteHistory returns up to q.Limit route events for a prefix, newest
+// first, and the cursor of the next page (nil on the last page).
+func (m *Memory) GetRouteHistory(ctx context.Context, q HistoryQuery) ([]model.RouteEvent, *HistoryCursor, error) {
+	match, err := historyFilter(q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		events    []model.RouteEvent
+		positions []HistoryCursor
+	)
+	// Refreshes are dropped before paginating, so every match is read
+	// when they are hidden.
+	hide := q.Changes && q.HideUnchanged
+	for i := len(m.events) - 1; i >= 0 && (hide || len(events) <= q.Limit); i-- {
+		e := &m.events[i]
+		if e.time.Before(q.From) || e.time.After(q.To) || !match(e) ||
+			q.After != nil && !e.before(*q.After) {
+			continue
+		}
+		events = append(events, e.event)
+		positions = append(positions, e.position())
+	}
+
+	if q.Changes {
+		classifyChanges(events, m.changeSeeds(events, positions, q.Since))
+		if hide {
+			events, positions = dropRefreshes(events, positions)
+		}
+	}
+
+	var next *HistoryCursor
+	if len(events) > q.Limit {
+		events, positions = events[:q.Limit], positions[:q.Limit]
+		next = &positions[q.Limit-1]
+	}
+
+	if events == nil {
+		events = []model.RouteEvent{}
+	}
+	return events, next, nil
+}
+
+// changeSeeds returns the state of every path of a page just before its
+// oldest event in the page, reading events from since on like
+// annotateChanges.
+func (m *Memory) changeSeeds(events []model.RouteEvent, positions []HistoryCursor, since time.Time) map[changeKey]*changeSeed {
+	oldest := map[changeKey]HistoryCursor{}
+	for i, e := range events {
+		oldest[changeKeyOf(e)] = positions[i]
+	}
+	seeds := map[changeKey]*changeSeed{}
+	for i := range m.events {
+		e := &m.events[i]
+		k := changeKeyOf(e.event)
+		pos, ok := oldest[k]
+		if !ok || !e.before(pos) || e.time.Before(since) {
+			continue
+		}
+		s := seeds[k]
+		if s == nil {
+			s = &changeSeed{}
+			seeds[k] = s
+		}
+		s.last = &e.event
+		if e.event.Action == "announce" {
+			s.lastAnnounce = &e.event
+		}
+	}
+	return seeds
+}
+
+// GetRouteIntervals converts the events matching q into state intervals per
+// (router, prefix, path_id), ordered by key and start time. Events before
+// q.Since are not read. q.Limit and q.After are ignored.
+func (m *Memory) GetRouteIntervals(ctx context.Context, q HistoryQuery) ([]model.RouteInterval, error) {
+	match, err := historyFilter(q)
+	if err != nil {
+		return nil, err
+	}
+
+	type pathKey struct {
+		routerID string
+		prefix   netip.Prefix
+		pathID   int64
+	}
+	type pathEvents struct {
+		initial *model.RouteEvent
+		events  []intervalEvent
+	}
+	paths := map[pathKey]*pathEvents{}
+	count := 0
+	for i := range m.events {
+		e := &m.events[i]
+		if e.time.After(q.To) {
+			break
+		}
+		if e.time.Before(q.Since) || !match(e) {
+			continue
+		}
+		k := pathKey{e.event.RouterID, e.prefix, deref64(e.event.PathID)}
+		p := paths[k]
+		if p == nil {
+			p = &pathEvents{}
+			paths[k] = p
+		}
+		if e.time.Before(q.From) {
+			p.initial = &e.event
+			continue
+		}
+		if count++; count > maxIntervalEvents {
+			return nil, ErrTooManyEvents
+		}
+		p.events = append(p.events, intervalEvent{time: e.time, event: e.event})
+	}
+
+	keys := make([]pathKey, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b pathKey) int {
+		return cmp.Or(cmp.Compare(a.routerID, b.routerID),
+			ComparePrefix(a.prefix, b.prefix),
+			cmp.Compare(a.pathID, b.pathID))
+	})
+
+	intervals := []model.RouteInterval{}
+	for _, k := range keys {
+		p := paths[k]
+		intervals = append(intervals, intervalsOf(buildIntervals(p.initial, p.events, q.From, q.To))...)
+	}
+	return intervals, nil
+}
+
+// GetHistoryBuckets summarises the events matching q per time bucket ("hour"
+// or "day", UTC), router and prefix, newest bucket first. It returns up to
+// q.Limit buckets and whether more exist. q.After is ignored.
+func (m *Memory) GetHistoryBuckets(ctx context.Context, q HistoryQuery, resolution string) ([]model.HistoryBucket, bool, error) {
+	var size time.Duration
+	switch resolution {
+	case "hour":
+		size = time.Hour
+	case "day":
+		size = 24 * time.Hour
+	default:
+		return nil, false, fmt.Errorf("unknown resolution %q", resolution)
+	}
+	match, err := historyFilter(q)
+	if err != nil {
+		return nil, false, err
+	}
+
+	type bucketKey struct {
+		time     time.Time
+		routerID string
+		prefix   string
+	}
+	type bucketStats struct {
+		model.HistoryBucket
+		paths map[int64]bool
+	}
+	stats := map[bucketKey]*bucketStats{}
+	for i := range m.events {
+		e := &m.events[i]
+		if e.time.Before(q.From) || e.time.After(q.To) || !match(e) {
+			continue
+		}
+		// Truncation is relative to the zero time, which is UTC midnight.
+		k := bucketKey{e.time.UTC().Truncate(size), e.event.RouterID, e.event.Prefix}
+		s := stats[k]
+		if s == nil {
+			s = &bucketStats{paths: map[int64]bool{}}
+			s.Time = model.FormatTime(k.time)
+			s.RouterID, s.Prefix = k.routerID, k.prefix
+			stats[k] = s
+		}
+		if e.event.Action == "announce" {
+			s.Announcements++
+		} else {
+			s.Withdrawals++
+		}
+		s.paths[deref64(e.event.PathID)] = true
+		s.LastAction = e.event.Action
+	}
+
+	keys := make([]bucketKey, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b bucketKey) int {
+		return cmp.Or(b.time.Compare(a.time), cmp.Compare(a.routerID, b.routerID), cmp.Compare(a.prefix, b.prefix))
+	})
+
+	truncated := len(keys) > q.Limit
+	if truncated {
+		keys = keys[:q.Limit]
+	}
+	buckets := make([]model.HistoryBucket, len(keys))
+	for i, k := range keys {
+		s := stats[k]
+		s.Paths = int64(len(s.paths))
+		buckets[i] = s.HistoryBucket
+	}
+	return buckets, truncated, nil
+}
@@ -0,0 +1,244 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testFixtures = `
+routers:
+  - id: r1
+    display_name: Router One
+    as_number: 65001
+    first_seen: 2026-01-01T00:00:00Z
+    last_seen: 2026-01-02T00:00:00Z
+    eor_received: true
+  - id: r2
+    hostname: router-two
+    session_start: 2026-01-01T00:00:00Z
+    eor_received: false
+  - id: r0
+routes:
+  - {router_id: r1, prefix: 10.0.0.0/8, next_hop: 192.0.2.1, as_path: "65001"}
+  - {router_id: r1, prefix: 10.1.0.0/16, path_id: 1, next_hop: 192.0.2.2, as_path: 65001 64500 64501}
+  - {router_id: r1, prefix: 10.1.0.0/16, path_id: 0, next_hop: 192.0.2.1, as_path: 65001 64500}
+  - {router_id: r1, table: vrf-a, prefix: 10.1.2.0/24, next_hop: 192.0.2.1}
+  - {router_id: r1, prefix: "2001:db8::/32", next_hop: "2001:db8::1", as_path: 65001 64502}
+events:
+  - {time: 2026-01-01T10:00:00Z, router_id: r1, action: withdraw, prefix: 10.1.0.0/16, path_id: 0}
+  - {time: 2026-01-01T08:00:00Z, router_id: r1, action: announce, prefix: 10.1.0.0/16, path_id: 0, as_path: 65001 64500, local_pref: 100}
+  - {time: 2026-01-01T12:00:00Z, router_id: r1, action: announce, prefix: 10.1.0.0/16, path_id: 0, as_path: 65001 64500, local_pref: 200}
+  - {time: 2026-01-01T13:00:00Z, router_id: r1, action: announce, prefix: 10.1.0.0/16, path_id: 0, as_path: 65001 64500, local_pref: 200}
+  - {time: 2026-01-01T12:30:00Z, router_id: r1, action: announce, prefix: 10.1.5.0/24, path_id: 0}
+`
+
+func loadTestMemory(t *testing.T) *Memory {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	if err := os.WriteFile(path, []byte(testFixtures), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadMemory(path)
+	if err != nil {
+		t.Fatalf("LoadMemory: %v", err)
+	}
+	return m
+}
+
+func TestMemoryRouters(t *testing.T) {
+	m := loadTestMemory(t)
+	ctx := context.Background()
+
+	routers, _ := m.ListRouters(ctx)
+	if len(routers) != 3 || routers[0].ID != "r0" || routers[1].ID != "r1" {
+		t.Fatalf("expected routers ordered by ID, got %+v", routers)
+	}
+	if routers[0].Status != "down" || routers[1].Status != "up" || routers[2].Status != "up" {
+		t.Errorf("unexpected statuses: %s %s %s", routers[0].Status, routers[1].Status, routers[2].Status)
+	}
+	if routers[1].DisplayName != "Router One" || routers[2].DisplayName != "router-two" || routers[0].DisplayName != "r0" {
+		t.Errorf("unexpected display names: %+v", routers)
+	}
+
+	d, _ := m.GetRouterDetail(ctx, "r1")
+	if d == nil || d.RouteCount != 5 || d.UniquePrefixes != 4 || d.PeerCount != 3 ||
+		d.IPv4Routes != 4 || d.IPv6Routes != 1 || d.AvgASPathLen == nil || *d.AvgASPathLen != 2 {
+		t.Errorf("unexpected detail: %+v", d)
+	}
+	if d, _ := m.GetRouterDetail(ctx, "nope"); d != nil {
+		t.Errorf("expected nil for unknown router, got %+v", d)
+	}
+
+	h, _ := m.GetHealthSummary(ctx)
+	if h.RouterCount != 3 || h.OnlineRouters != 2 || h.TotalRoutes != 5 || h.AllEOR == nil || *h.AllEOR {
+		t.Errorf("unexpected health summary: %+v", h)
+	}
+}
+
+func TestMemoryLookups(t *testing.T) {
+	m := loadTestMemory(t)
+	ctx := context.Background()
+
+	routes, err := m.ExactLookup(ctx, "r1", "10.1.0.0/16")
+	if err != nil || len(routes) != 2 || routes[0].PathID != 0 || routes[1].PathID != 1 {
+		t.Errorf("ExactLookup: %+v %v", routes, err)
+	}
+	routes, err = m.LPMLookup(ctx, "r1", "10.1.2.3")
+	if err != nil || len(routes) != 1 || routes[0].Prefix != "10.1.2.0/24" {
+		t.Errorf("LPMLookup: %+v %v", routes, err)
+	}
+	routes, err = m.LPMLookup(ctx, "r1", "172.16.0.1")
+	if err != nil || len(routes) != 0 {
+		t.Errorf("LPMLookup without match: %+v %v", routes, err)
+	}
+
+	routes, truncated, err := m.SubnetLookup(ctx, "r1", "10.0.0.0/8", 3)
+	if err != nil || !truncated || len(routes) != 3 || routes[0].Prefix != "10.0.0.0/8" || routes[2].PathID != 1 {
+		t.Errorf("SubnetLookup: %+v %v %v", routes, truncated, err)
+	}
+
+	// Path 1 has no events and is read from the current routes; path 0 is
+	// replayed from its events.
+	at := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	from := at.Add(-24 * time.Hour)
+	routes, err = m.LPMLookupAt(ctx, "r1", "10.1.0.1", at, from)
+	if err != nil || len(routes) != 2 || routes[0].PathID != 0 || *routes[0].LocalPref != 100 || routes[1].PathID != 1 {
+		t.Errorf("LPMLookupAt before withdrawal: %+v %v", routes, err)
+	}
+	routes, _ = m.ExactLookupAt(ctx, "r1", "10.1.0.0/16", at.Add(2*time.Hour), from)
+	if len(routes) != 1 || routes[0].PathID != 1 {
+		t.Errorf("ExactLookupAt after withdrawal: %+v", routes)
+	}
+	routes, _ = m.ExactLookupAt(ctx, "r1", "10.1.0.0/16", at.Add(3*time.Hour+45*time.Minute), from)
+	if len(routes) != 2 || routes[0].FirstSeen != "2026-01-01T12:00:00Z" || routes[0].UpdatedAt != "2026-01-01T12:00:00Z" {
+		t.Errorf("ExactLookupAt after readvertisement: %+v", routes)
+	}
+	// Prefixes first announced after at are not there yet.
+	routes, _ = m.ExactLookupAt(ctx, "r1", "10.1.5.0/24", at, from)
+	if len(routes) != 0 {
+		t.Errorf("ExactLookupAt before announcement: %+v", routes)
+	}
+	// Events before from are not replayed.
+	routes, _ = m.ExactLookupAt(ctx, "r1", "10.1.0.0/16", at, at.Add(-30*time.Minute))
+	if len(routes) != 1 || routes[0].PathID != 1 {
+		t.Errorf("ExactLookupAt with events before from: %+v", routes)
+	}
+}
+
+func TestMemoryHistory(t *testing.T) {
+	m := loadTestMemory(t)
+	ctx := context.Background()
+	q := HistoryQuery{
+		RouterIDs: []string{"r1"},
+		Prefix:    "10.1.0.0/16",
+		From:      time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:        time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		Limit:     2,
+		Changes:   true,
+	}
+
+	events, next, err := m.GetRouteHistory(ctx, q)
+	if err != nil || len(events) != 2 || next == nil {
+		t.Fatalf("first page: %+v %v %v", events, next, err)
+	}
+	if *events[0].ChangeType != ChangeRefresh || *events[1].ChangeType != ChangeReadvert {
+		t.Errorf("unexpected change types: %s %s", *events[0].ChangeType, *events[1].ChangeType)
+	}
+
+	q.After = next
+	events, next, _ = m.GetRouteHistory(ctx, q)
+	if len(events) != 2 || next != nil || events[0].Action != "withdraw" || *events[1].ChangeType != ChangeInitial {
+		t.Errorf("second page: %+v %v", events, next)
+	}
+
+	// Hidden refreshes do not shorten pages.
+	q.After, q.Limit, q.HideUnchanged = nil, 1, true
+	var times []string
+	for page := 0; page < 5; page++ {
+		events, next, err = m.GetRouteHistory(ctx, q)
+		if err != nil || len(events) != 1 {
+			t.Fatalf("page %d without refreshes: %+v %v", page, events, err)
+		}
+		times = append(times, events[0].Timestamp)
+		if next == nil {
+			break
+		}
+		if next.Time.Format(time.RFC3339) != events[0].Timestamp {
+			t.Errorf("page %d: cursor %v does not follow the last event %s", page, next.Time, events[0].Timestamp)
+		}
+		q.After = next
+	}
+	if want := "2026-01-01T12:00:00Z 2026-01-01T10:00:00Z 2026-01-01T08:00:00Z"; strings.Join(times, " ") != want {
+		t.Errorf("expected events at %s, got %v", want, times)
+	}
+
+	// Paths without an event between Since and the page are new.
+	bounded := q
+	bounded.After, bounded.HideUnchanged, bounded.Limit = nil, false, 2
+	bounded.From = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	bounded.Since = time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)
+	events, _, err = m.GetRouteHistory(ctx, bounded)
+	if err != nil || len(events) != 2 || *events[1].ChangeType != ChangeInitial {
+		t.Errorf("bounded seed: %+v %v", events, err)
+	}
+
+	q.After, q.Changes, q.HideUnchanged, q.Subnets, q.Limit = nil, false, false, true, 2
+	intervals, err := m.GetRouteIntervals(ctx, q)
+	if err != nil || len(intervals) != 3 || intervals[2].Prefix != "10.1.5.0/24" || !intervals[1].Ongoing {
+		t.Errorf("intervals: %+v %v", intervals, err)
+	}
+
+	// The state at From is only known from events on or after Since.
+	bounded.Subnets = false
+	bounded.From = time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		since time.Time
+		start string
+	}{
+		{time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC), "2026-01-01T09:00:00Z"},
+		{time.Date(2026, 1, 1, 8, 30, 0, 0, time.UTC), "2026-01-01T12:00:00Z"},
+	} {
+		bounded.Since = tt.since
+		intervals, err = m.GetRouteIntervals(ctx, bounded)
+		if err != nil || len(intervals) == 0 || intervals[0].Start != tt.start {
+			t.Errorf("intervals since %v: %+v %v", tt.since, intervals, err)
+		}
+	}
+
+	buckets, truncated, err := m.GetHistoryBuckets(ctx, q, "day")
+	if err != nil || truncated || len(buckets) != 2 || buckets[0].Prefix != "10.1.0.0/16" ||
+		buckets[0].Announcements != 3 || buckets[0].Withdrawals != 1 || buckets[0].LastAction != "announce" {
+		t.Errorf("buckets: %+v %v %v", buckets, truncated, err)
+	}
+}
+
+func TestNewMemoryRejectsInvalidFixtures(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixtures Fixtures
+		want     string
+	}{
+		{"duplicate router", Fixtures{Routers: []FixtureRouter{{ID: "r1"}, {ID: "r1"}}}, "duplicate id"},
+		{"unknown router", Fixtures{Routes: []FixtureRoute{{RouterID: "r1", Prefix: "10.0.0.0/8"}}}, "unknown router"},
+		{"host bits", Fixtures{
+			Routers: []FixtureRouter{{ID: "r1"}},
+			Routes:  []FixtureRoute{{RouterID: "r1", Prefix: "10.0.0.1/8"}},
+		}, "bits set"},
+		{"bad action", Fixtures{
+			Routers: []FixtureRouter{{ID: "r1"}},
+			Events:  []FixtureEvent{{Time: time.Now(), RouterID: "r1", Action: "update", Prefix: "10.0.0.0/8"}},
+		}, "action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMemory(tt.fixtures)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
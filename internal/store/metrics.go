@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// RIBTableStats holds the size and sync state of one (router, table, AFI)
+// RIB as exported by the metrics collector. Tables present only in
+// current_routes or only in rib_sync_status are included.
+type RIBTableStats struct {
+	RouterID       string
+	Table          string
+	AFI            int
+	Routes         int64
+	UniquePrefixes int64
+	EORSeen        bool
+	SessionStart   *time.Time
+	LastParsed     *time.Time
+}
+
+// GetRIBTableStats returns route counts and sync state per router, table
+// and AFI.
+func (db *DB) GetRIBTableStats(ctx context.Context) ([]RIBTableStats, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH counts AS (
+			SELECT router_id, table_name, afi,
+			       COUNT(*) AS routes,
+			       COUNT(DISTINCT prefix) AS unique_prefixes
+			FROM current_routes
+			GROUP BY router_id, table_name, afi
+		)
+		SELECT COALESCE(c.router_id, s.router_id),
+		       COALESCE(c.table_name, s.table_name),
+		       COALESCE(c.afi, s.afi),
+		       COALESCE(c.routes, 0), COALESCE(c.unique_prefixes, 0),
+		       COALESCE(s.eor_seen, false), s.session_start_time, s.last_parsed_msg_time
+		FROM counts c
+		FULL OUTER JOIN rib_sync_status s
+		  ON s.router_id = c.router_id AND s.table_name = c.table_name AND s.afi = c.afi
+		ORDER BY 1, 2, 3
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []RIBTableStats
+	for rows.Next() {
+		var s RIBTableStats
+		if err := rows.Scan(&s.RouterID, &s.Table, &s.AFI, &s.Routes, &s.UniquePrefixes,
+			&s.EORSeen, &s.SessionStart, &s.LastParsed); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// EventCount is the number of route events of one action ingested for a
+// (router, table, AFI) within a time window.
+type EventCount struct {
+	RouterID string
+	Table    string
+	AFI      int
+	Action   string // "announce" or "withdraw"
+	Count    int64
+}
+
+// CountRouteEvents counts route events ingested in (from, to].
+func (db *DB) CountRouteEvents(ctx context.Context, from, to time.Time) ([]EventCount, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT router_id, table_name, afi, action, COUNT(*)
+		FROM route_events
+		WHERE ingest_time > $1 AND ingest_time <= $2
+		GROUP BY router_id, table_name, afi, action
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []EventCount
+	for rows.Next() {
+		var (
+			c      EventCount
+			action string
+		)
+		if err := rows.Scan(&c.RouterID, &c.Table, &c.AFI, &action, &c.Count); err != nil {
+			return nil, err
+		}
+		c.Action = "announce"
+		if action == "D" {
+			c.Action = "withdraw"
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
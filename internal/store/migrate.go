@@ -0,0 +1,219 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Migration is a versioned schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	// Down reverts Up; empty when the migration cannot be reverted.
+	Down string
+}
+
+// MigrationStatus is a migration and when it was applied.
+type MigrationStatus struct {
+	Migration
+	// AppliedAt is nil for pending migrations.
+	AppliedAt *time.Time
+	// Unknown marks an applied version that has no migration file, e.g.
+	// after a downgrade of the binary.
+	Unknown bool
+}
+
+// migrateDownMarker separates the up and down statements of a file.
+var migrateDownMarker = regexp.MustCompile(`(?m)^-- migrate:down[ \t]*$`)
+
+// LoadMigrations reads the NNNN_description.sql files of fsys, ordered by
+// version.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	var ms []Migration
+	for _, name := range names {
+		num, desc, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		v, err := strconv.Atoi(num)
+		if !ok || err != nil || v <= 0 || desc == "" {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_description.sql", name)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		m := Migration{Version: v, Name: desc, Up: string(data)}
+		if loc := migrateDownMarker.FindStringIndex(m.Up); loc != nil {
+			m.Up, m.Down = m.Up[:loc[0]], strings.TrimSpace(m.Up[loc[1]:])
+		}
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %s: no up statements", name)
+		}
+		ms = append(ms, m)
+	}
+	slices.SortFunc(ms, func(a, b Migration) int { return a.Version - b.Version })
+	for i := 1; i < len(ms); i++ {
+		if ms[i].Version == ms[i-1].Version {
+			return nil, fmt.Errorf("migration version %d is not unique", ms[i].Version)
+		}
+	}
+	return ms, nil
+}
+
+// Every change runs in its own transaction holding this advisory lock, so
+// concurrent migrators apply each migration once.
+const (
+	migrationLock        = `SELECT pg_advisory_xact_lock(hashtext('route-beacon schema_migrations'))`
+	createMigrationTable = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT        NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`
+)
+
+// MigrationStatus reports every known or applied migration, ordered by
+// version. It does not modify the database.
+func (db *DB) MigrationStatus(ctx context.Context, ms []Migration) ([]MigrationStatus, error) {
+	var exists bool
+	if err := db.Pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, err
+	}
+	applied := map[int]MigrationStatus{}
+	if exists {
+		var err error
+		if applied, err = appliedMigrations(ctx, db.Pool); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(ms))
+	for _, m := range ms {
+		s := MigrationStatus{Migration: m}
+		if a, ok := applied[m.Version]; ok {
+			s.AppliedAt = a.AppliedAt
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, a := range applied {
+		statuses = append(statuses, a)
+	}
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int { return a.Version - b.Version })
+	return statuses, nil
+}
+
+// MigrateUp applies the pending migrations in version order and returns
+// them. Migrations applied before a failure stay applied.
+func (db *DB) MigrateUp(ctx context.Context, ms []Migration) ([]Migration, error) {
+	var done []Migration
+	for {
+		var next *Migration
+		err := db.inMigrationTx(ctx, func(tx pgx.Tx, applied map[int]MigrationStatus) error {
+			for i := range ms {
+				if _, ok := applied[ms[i].Version]; !ok {
+					next = &ms[i]
+					break
+				}
+			}
+			if next == nil {
+				return nil
+			}
+			if _, err := tx.Exec(ctx, next.Up); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", next.Version, next.Name, err)
+			}
+			_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, next.Version, next.Name)
+			return err
+		})
+		if err != nil || next == nil {
+			return done, err
+		}
+		done = append(done, *next)
+	}
+}
+
+// MigrateDown reverts the most recently applied migration and returns it,
+// or nil when none is applied.
+func (db *DB) MigrateDown(ctx context.Context, ms []Migration) (*Migration, error) {
+	var reverted *Migration
+	err := db.inMigrationTx(ctx, func(tx pgx.Tx, applied map[int]MigrationStatus) error {
+		latest := 0
+		for v := range applied {
+			latest = max(latest, v)
+		}
+		if latest == 0 {
+			return nil
+		}
+		i := slices.IndexFunc(ms, func(m Migration) bool { return m.Version == latest })
+		if i < 0 {
+			return fmt.Errorf("applied migration %04d_%s has no migration file", latest, applied[latest].Name)
+		}
+		m := ms[i]
+		if m.Down == "" {
+			return fmt.Errorf("migration %04d_%s cannot be reverted", m.Version, m.Name)
+		}
+		if _, err := tx.Exec(ctx, m.Down); err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version); err != nil {
+			return err
+		}
+		reverted = &m
+		return nil
+	})
+	return reverted, err
+}
+
+// inMigrationTx runs fn in a transaction holding the migration lock, with
+// the migrations applied at that point.
+func (db *DB) inMigrationTx(ctx context.Context, fn func(pgx.Tx, map[int]MigrationStatus) error) error {
+	return pgx.BeginFunc(ctx, db.Pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migrationLock); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, createMigrationTable); err != nil {
+			return err
+		}
+		applied, err := appliedMigrations(ctx, tx)
+		if err != nil {
+			return err
+		}
+		return fn(tx, applied)
+	})
+}
+
+// appliedMigrations returns the rows of schema_migrations by version.
+func appliedMigrations(ctx context.Context, q interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}) (map[int]MigrationStatus, error) {
+	rows, err := q.Query(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]MigrationStatus{}
+	for rows.Next() {
+		var (
+			s  = MigrationStatus{Unknown: true}
+			at time.Time
+		)
+		if err := rows.Scan(&s.Version, &s.Name, &at); err != nil {
+			return nil, err
+		}
+		s.AppliedAt = &at
+		applied[s.Version] = s
+	}
+	return applied, rows.Err()
+}
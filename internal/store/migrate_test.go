@@ -0,0 +1,74 @@
+package store
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pobradovic08/route-beacon/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_second.sql": {Data: []byte("CREATE TABLE b ();\n\n-- migrate:down\n\nDROP TABLE b;\n")},
+		"0001_first.sql":  {Data: []byte("CREATE TABLE a ();\n")},
+		"README.md":       {Data: []byte("not a migration")},
+	}
+	ms, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	if len(ms) != 2 || ms[0].Version != 1 || ms[0].Name != "first" || ms[1].Version != 2 {
+		t.Fatalf("unexpected migrations: %+v", ms)
+	}
+	if ms[0].Down != "" {
+		t.Errorf("expected no down statements, got %q", ms[0].Down)
+	}
+	if strings.Contains(ms[1].Up, "DROP") || ms[1].Down != "DROP TABLE b;" {
+		t.Errorf("unexpected split: up %q, down %q", ms[1].Up, ms[1].Down)
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"bad name", fstest.MapFS{"init.sql": {Data: []byte("SELECT 1;")}}, "NNNN_description"},
+		{"duplicate version", fstest.MapFS{
+			"0001_a.sql": {Data: []byte("SELECT 1;")},
+			"001_b.sql":  {Data: []byte("SELECT 1;")},
+		}, "not unique"},
+		{"empty up", fstest.MapFS{"0001_a.sql": {Data: []byte("-- migrate:down\nSELECT 1;")}}, "no up statements"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMigrations(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	ms, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	for i, m := range ms {
+		if m.Version != i+1 {
+			t.Errorf("expected version %d, got %04d_%s", i+1, m.Version, m.Name)
+		}
+		// 0001 adopts the ingester's tables and must never drop them.
+		if reversible := m.Down != ""; reversible != (m.Version > 1) {
+			t.Errorf("migration %04d_%s: expected reversible %v", m.Version, m.Name, m.Version > 1)
+		}
+	}
+	// The view the router queries read from must be part of the schema.
+	if !slices.ContainsFunc(ms, func(m Migration) bool { return strings.Contains(m.Up, "VIEW routers_overview") }) {
+		t.Errorf("no migration creates routers_overview")
+	}
+}
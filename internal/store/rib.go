@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// ribAtCTE reconstructs a router's RIB at a point in time $2. Paths without
+// events after $2 are read from current_routes as they are. Paths changed
+// since then are rebuilt by replaying their route_events from $3 up to $2:
+// the last event at or before $2 wins, and the path is present if that
+// event is an announcement. first_seen is the first announcement since the
+// most recent withdrawal and updated_at is the time of the winning event.
+// filter is an extra condition on both tables and may reference parameters
+// from $4 on.
+//
+// Events before $3 are not read, so that the query only touches the
+// partitions within the history range. A path that changed after $2 but
+// whose last event before it is older than $3 is therefore missing, and
+// first_seen is clamped to $3 for runs starting before it.
+//
+// The result is exposed as the CTE "rib" with the same columns as the
+// current_routes lookup queries, so callers only append the final SELECT.
+func ribAtCTE(filter string) string {
+	return `
+		WITH changed AS (
+			SELECT DISTINCT table_name, afi, prefix, COALESCE(path_id, 0) AS path_id
+			FROM route_events
+			WHERE router_id = $1
+			  AND ingest_time > $2
+			  AND ` + filter + `
+		), ev AS (
+			SELECT table_name, afi, prefix, path_id, action, ingest_time, event_id,
+			       nexthop, as_path, origin, localpref, med, origin_asn,
+			       communities_std, communities_ext, communities_large, attrs,
+			       COUNT(*) FILTER (WHERE action = 'D') OVER k AS run
+			FROM route_events
+			WHERE router_id = $1
+			  AND ingest_time <= $2
+			  AND ingest_time >= $3
+			  AND ` + filter + `
+			  AND (table_name, afi, prefix, COALESCE(path_id, 0)) IN (SELECT * FROM changed)
+			WINDOW k AS (PARTITION BY table_name, afi, prefix, path_id ORDER BY ingest_time, event_id)
+		), runs AS (
+			SELECT ev.*,
+			       MIN(ingest_time) FILTER (WHERE action = 'A')
+			           OVER (PARTITION BY table_name, afi, prefix, path_id, run) AS run_start,
+			       ROW_NUMBER()
+			           OVER (PARTITION BY table_name, afi, prefix, path_id
+			                 ORDER BY ingest_time DESC, event_id DESC) AS rn
+			FROM ev
+		), rib AS (
+			SELECT table_name, afi, prefix, path_id,
+			       nexthop, as_path, origin, localpref, med, origin_asn,
+			       communities_std, communities_ext, communities_large,
+			       attrs, first_seen, updated_at
+			FROM current_routes c
+			WHERE router_id = $1
+			  AND updated_at <= $2
+			  AND ` + filter + `
+			  AND NOT EXISTS (
+			      SELECT 1 FROM changed ch
+			      WHERE (ch.table_name, ch.afi, ch.prefix, ch.path_id) = (c.table_name, c.afi, c.prefix, c.path_id))
+			UNION ALL
+			SELECT table_name, afi, prefix, COALESCE(path_id, 0) AS path_id,
+			       nexthop, as_path, origin, localpref, med, origin_asn,
+			       communities_std, communities_ext, communities_large,
+			       attrs, run_start AS first_seen, ingest_time AS updated_at
+			FROM runs
+			WHERE rn = 1 AND action = 'A'
+		)`
+}
+
+// ribColumns is the column list shared by current_routes and rib queries,
+// in the order expected by scanRoutes. Its prefix column is text, so
+// queries selecting it must order by the table's cidr column through an
+// alias: a bare ORDER BY prefix sorts as text.
+const ribColumns = `prefix::text, path_id, nexthop, as_path, origin,
+		       localpref, med, origin_asn,
+		       communities_std, communities_ext, communities_large,
+		       attrs, first_seen, updated_at`
+
+// ExactLookupAt returns routes matching the exact prefix as they were on the
+// router at the given time, replaying events from the time from on.
+func (db *DB) ExactLookupAt(ctx context.Context, routerID, prefix string, at, from time.Time) ([]model.Route, error) {
+	rows, err := db.Pool.Query(ctx, ribAtCTE(`prefix = $4::cidr`)+`
+		SELECT `+ribColumns+`
+		FROM rib
+		ORDER BY path_id
+	`, routerID, at, from, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRoutes(rows)
+}
+
+// LPMLookupAt returns routes matching the longest prefix for a bare IP
+// address as they were on the router at the given time, replaying events
+// from the time from on.
+func (db *DB) LPMLookupAt(ctx context.Context, routerID, ip string, at, from time.Time) ([]model.Route, error) {
+	rows, err := db.Pool.Query(ctx, ribAtCTE(`prefix >>= $4::inet`)+`
+		SELECT `+ribColumns+`
+		FROM rib
+		WHERE masklen(prefix) = (SELECT MAX(masklen(prefix)) FROM rib)
+		ORDER BY path_id
+	`, routerID, at, from, ip)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRoutes(rows)
+}
+
+// RouteListQuery selects a page of a router's RIB. When At is set the RIB is
+// reconstructed as it was then, replaying route_events from From on.
+type RouteListQuery struct {
+	RouterID string
+	Table    *string
+	AFI      *int
+	At       *time.Time
+	From     time.Time
+	Limit    int
+	Offset   int
+}
+
+// ListRoutes returns a page of routes ordered by prefix and path ID. One row
+// more than q.Limit is requested so callers can detect further pages.
+func (db *DB) ListRoutes(ctx context.Context, q RouteListQuery) ([]model.Route, error) {
+	sql, args := listRoutesQuery(q)
+	rows, err := db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRoutes(rows)
+}
+
+// listRoutesQuery returns the statement and arguments of ListRoutes. Pages
+// are ordered by the cidr column, qualified so that it does not resolve to
+// the text prefix of ribColumns.
+func listRoutesQuery(q RouteListQuery) (sql string, args []any) {
+	if q.At != nil {
+		sql = ribAtCTE(`($4::text IS NULL OR table_name = $4)
+			  AND ($5::smallint IS NULL OR afi = $5)`) + `
+		SELECT ` + ribColumns + `
+		FROM rib r
+		ORDER BY r.prefix, r.path_id
+		LIMIT $6 OFFSET $7
+	`
+		args = []any{q.RouterID, *q.At, q.From, q.Table, q.AFI, q.Limit + 1, q.Offset}
+	} else {
+		sql = `
+		SELECT ` + ribColumns + `
+		FROM current_routes c
+		WHERE router_id = $1
+		  AND ($2::text IS NULL OR table_name = $2)
+		  AND ($3::smallint IS NULL OR afi = $3)
+		ORDER BY c.prefix, c.path_id
+		LIMIT $4 OFFSET $5
+	`
+		args = []any{q.RouterID, q.Table, q.AFI, q.Limit + 1, q.Offset}
+	}
+	return sql, args
+}
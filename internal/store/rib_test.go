@@ -0,0 +1,27 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListRoutesQuery_OrdersByCIDR(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, q := range []RouteListQuery{
+		{RouterID: "r1", Limit: 10},
+		{RouterID: "r1", At: &at, From: at.Add(-time.Hour), Limit: 10},
+	} {
+		sql, _ := listRoutesQuery(q)
+		i := strings.LastIndex(sql, "ORDER BY")
+		if i < 0 {
+			t.Fatalf("no ORDER BY in %s", sql)
+		}
+		// ribColumns selects prefix::text; a bare "prefix" would sort
+		// 100.64.0.0/10 between 10.0.0.0/8 and 11.0.0.0/8.
+		order := strings.Fields(sql[i+len("ORDER BY"):])
+		if len(order) < 2 || !strings.HasSuffix(order[0], ".prefix,") || !strings.HasSuffix(order[1], ".path_id") {
+			t.Errorf("at %v: pages must be ordered by the cidr column, got %q", q.At != nil, order)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// TableRoute is a current_routes row with the table it belongs to, as
+// loaded by the in-memory RIB cache.
+type TableRoute struct {
+	RouterID  string
+	TableName string
+	AFI       int
+	Prefix    netip.Prefix
+	UpdatedAt time.Time
+	Route     model.Route
+}
+
+// Withdrawal is a withdraw event of a path.
+type Withdrawal struct {
+	RouterID   string
+	TableName  string
+	AFI        int
+	Prefix     netip.Prefix
+	PathID     int64
+	IngestTime time.Time
+}
+
+// Now returns the database clock, so that incremental readers compare
+// updated_at and ingest_time against the clock that wrote them.
+func (db *DB) Now(ctx context.Context) (time.Time, error) {
+	var now time.Time
+	err := db.Pool.QueryRow(ctx, `SELECT now()`).Scan(&now)
+	return now, err
+}
+
+// StreamCurrentRoutes calls fn for every current_routes row updated after
+// since, or for every row when since is zero, without holding the whole
+// table in memory.
+func (db *DB) StreamCurrentRoutes(ctx context.Context, since time.Time, fn func(TableRoute) error) error {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT router_id, table_name, afi, updated_at, `+ribColumns+`
+		FROM current_routes
+		WHERE $1::timestamptz IS NULL OR updated_at > $1
+	`, nullTime(since))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tr TableRoute
+		var afi int16
+		route, err := scanRoute(rows, &tr.RouterID, &tr.TableName, &afi, &tr.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		tr.AFI = int(afi)
+		tr.Route = route
+		if tr.Prefix, err = netip.ParsePrefix(route.Prefix); err != nil {
+			return err
+		}
+		if err := fn(tr); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListWithdrawals returns the withdraw events ingested after since, oldest
+// first.
+func (db *DB) ListWithdrawals(ctx context.Context, since time.Time) ([]Withdrawal, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT router_id, table_name, afi, prefix::text, COALESCE(path_id, 0), ingest_time
+		FROM route_events
+		WHERE action = 'D'
+		  AND ingest_time > $1
+		ORDER BY ingest_time
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var withdrawals []Withdrawal
+	for rows.Next() {
+		var (
+			w      Withdrawal
+			afi    int16
+			prefix string
+		)
+		if err := rows.Scan(&w.RouterID, &w.TableName, &afi, &prefix, &w.PathID, &w.IngestTime); err != nil {
+			return nil, err
+		}
+		w.AFI = int(afi)
+		if w.Prefix, err = netip.ParsePrefix(prefix); err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	return withdrawals, rows.Err()
+}
+
+// nullTime maps the zero time to NULL.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
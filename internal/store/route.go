@@ -3,7 +3,6 @@ package store
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net"
 	"strconv"
 	"strings"
@@ -58,6 +57,38 @@ func (db *DB) LPMLookup(ctx context.Context, routerID, ip string) ([]model.Route
 	return scanRoutes(rows)
 }
 
+// SubnetLookup returns the routes of a router for the given prefix and
+// every more-specific prefix, ordered by address and path ID like the RIB
+// cache. At most limit routes are returned; truncated reports whether
+// more exist.
+func (db *DB) SubnetLookup(ctx context.Context, routerID, prefix string, limit int) ([]model.Route, bool, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+ribColumns+`
+		FROM current_routes c
+		WHERE router_id = $1
+		  AND prefix <<= $2::cidr
+		ORDER BY c.prefix, c.path_id
+		LIMIT $3
+	`, routerID, prefix, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	routes, err := scanRoutes(rows)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(routes) > limit {
+		return routes[:limit], true, nil
+	}
+	return routes, false, nil
+}
+
+// rowScanner is the subset of pgx.Rows used to scan a single row.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
 // scanRoutes reads route rows into model.Route slices.
 func scanRoutes(rows interface {
 	Next() bool
@@ -66,61 +97,11 @@ func scanRoutes(rows interface {
 }) ([]model.Route, error) {
 	var routes []model.Route
 	for rows.Next() {
-		var (
-			prefix         string
-			pathID         int64
-			nexthop        *net.IP
-			asPathStr      *string
-			origin         *string
-			localpref      *int
-			med            *int
-			originASN      *int
-			commStd        []string
-			commExt        []string
-			commLarge      []string
-			attrs          json.RawMessage
-			firstSeen      time.Time
-			updatedAt      time.Time
-		)
-		if err := rows.Scan(&prefix, &pathID, &nexthop, &asPathStr, &origin,
-			&localpref, &med, &originASN,
-			&commStd, &commExt, &commLarge,
-			&attrs, &firstSeen, &updatedAt); err != nil {
+		route, err := scanRoute(rows)
+		if err != nil {
 			return nil, err
 		}
-
-		var nhStr *string
-		if nexthop != nil {
-			s := nexthop.String()
-			nhStr = &s
-		}
-
-		var originLower *string
-		if origin != nil {
-			l := strings.ToLower(*origin)
-			originLower = &l
-		}
-
-		if attrs != nil && string(attrs) == "null" {
-			attrs = nil
-		}
-
-		routes = append(routes, model.Route{
-			Prefix:              prefix,
-			PathID:              pathID,
-			NextHop:             nhStr,
-			ASPath:              parseASPath(asPathStr),
-			Origin:              originLower,
-			LocalPref:           localpref,
-			MED:                 med,
-			OriginASN:           originASN,
-			Communities:         parseCommunities(commStd, "standard"),
-			ExtendedCommunities: parseCommunities(commExt, "extended"),
-			LargeCommunities:    parseCommunities(commLarge, "large"),
-			Attrs:               attrs,
-			FirstSeen:           model.FormatTime(firstSeen),
-			UpdatedAt:           model.FormatTime(updatedAt),
-		})
+		routes = append(routes, route)
 	}
 	if routes == nil {
 		routes = []model.Route{}
@@ -128,6 +109,67 @@ func scanRoutes(rows interface {
 	return routes, rows.Err()
 }
 
+// scanRoute reads one route row. Any extra destinations are scanned from the
+// columns preceding the standard route columns.
+func scanRoute(row rowScanner, extra ...any) (model.Route, error) {
+	var (
+		prefix    string
+		pathID    int64
+		nexthop   *net.IP
+		asPathStr *string
+		origin    *string
+		localpref *int
+		med       *int
+		originASN *int
+		commStd   []string
+		commExt   []string
+		commLarge []string
+		attrs     json.RawMessage
+		firstSeen time.Time
+		updatedAt time.Time
+	)
+	dest := append(extra, &prefix, &pathID, &nexthop, &asPathStr, &origin,
+		&localpref, &med, &originASN,
+		&commStd, &commExt, &commLarge,
+		&attrs, &firstSeen, &updatedAt)
+	if err := row.Scan(dest...); err != nil {
+		return model.Route{}, err
+	}
+
+	var nhStr *string
+	if nexthop != nil {
+		s := nexthop.String()
+		nhStr = &s
+	}
+
+	var originLower *string
+	if origin != nil {
+		l := strings.ToLower(*origin)
+		originLower = &l
+	}
+
+	if attrs != nil && string(attrs) == "null" {
+		attrs = nil
+	}
+
+	return model.Route{
+		Prefix:              prefix,
+		PathID:              pathID,
+		NextHop:             nhStr,
+		ASPath:              parseASPath(asPathStr),
+		Origin:              originLower,
+		LocalPref:           localpref,
+		MED:                 med,
+		OriginASN:           originASN,
+		Communities:         parseCommunities(commStd, "standard"),
+		ExtendedCommunities: parseCommunities(commExt, "extended"),
+		LargeCommunities:    parseCommunities(commLarge, "large"),
+		Attrs:               attrs,
+		FirstSeen:           model.FormatTime(firstSeen),
+		UpdatedAt:           model.FormatTime(updatedAt),
+	}, nil
+}
+
 // parseASPath converts a space-delimited AS path string into []any.
 // AS_SET segments like {64496,65001} are represented as []any containing ints.
 func parseASPath(s *string) []any {
@@ -185,71 +227,3 @@ func parseCommunities(values []string, commType string) []model.Community {
 	}
 	return communities
 }
-
-// GeneratePlainText produces a text rendering of route lookup results.
-func GeneratePlainText(prefix string, routerID string, routes []model.Route) string {
-	if len(routes) == 0 {
-		return fmt.Sprintf("No routes found for %s on %s\n", prefix, routerID)
-	}
-
-	var b strings.Builder
-	fmt.Fprintf(&b, "BGP routing table entry for %s on %s\n", prefix, routerID)
-	fmt.Fprintf(&b, "%d path(s) available\n\n", len(routes))
-
-	for i, r := range routes {
-		fmt.Fprintf(&b, "Path #%d", i+1)
-		if r.PathID == 0 {
-			b.WriteString(" (best)")
-		}
-		b.WriteString("\n")
-
-		if r.NextHop != nil {
-			fmt.Fprintf(&b, "  Next Hop: %s\n", *r.NextHop)
-		}
-
-		asPathParts := make([]string, len(r.ASPath))
-		for j, a := range r.ASPath {
-			switch v := a.(type) {
-			case int:
-				asPathParts[j] = strconv.Itoa(v)
-			case float64:
-				asPathParts[j] = strconv.Itoa(int(v))
-			case []any:
-				nums := make([]string, len(v))
-				for k, n := range v {
-					switch nn := n.(type) {
-					case int:
-						nums[k] = strconv.Itoa(nn)
-					case float64:
-						nums[k] = strconv.Itoa(int(nn))
-					default:
-						nums[k] = fmt.Sprintf("%v", nn)
-					}
-				}
-				asPathParts[j] = "{" + strings.Join(nums, ",") + "}"
-			default:
-				asPathParts[j] = fmt.Sprintf("%v", v)
-			}
-		}
-		fmt.Fprintf(&b, "  AS Path: %s\n", strings.Join(asPathParts, " "))
-
-		if r.Origin != nil {
-			fmt.Fprintf(&b, "  Origin: %s\n", *r.Origin)
-		}
-		if r.LocalPref != nil {
-			fmt.Fprintf(&b, "  Local Pref: %d\n", *r.LocalPref)
-		}
-		if r.MED != nil {
-			fmt.Fprintf(&b, "  MED: %d\n", *r.MED)
-		}
-		if len(r.Communities) > 0 {
-			vals := make([]string, len(r.Communities))
-			for j, c := range r.Communities {
-				vals[j] = c.Value
-			}
-			fmt.Fprintf(&b, "  Communities: %s\n", strings.Join(vals, " "))
-		}
-		b.WriteString("\n")
-	}
-	return b.String()
-}
@@ -1,10 +1,6 @@
 package store
 
-import (
-	"testing"
-
-	"github.com/pobradovic08/route-beacon/internal/model"
-)
+import "testing"
 
 func TestParseASPath_Empty(t *testing.T) {
 	result := parseASPath(nil)
@@ -114,55 +110,3 @@ func TestParseCommunities_Values(t *testing.T) {
 		t.Fatalf("unexpected community 1: %+v", result[1])
 	}
 }
-
-func TestGeneratePlainText_NoRoutes(t *testing.T) {
-	result := GeneratePlainText("10.0.0.0/24", "router1", nil)
-	expected := "No routes found for 10.0.0.0/24 on router1\n"
-	if result != expected {
-		t.Fatalf("expected %q, got %q", expected, result)
-	}
-}
-
-func TestGeneratePlainText_BestPath(t *testing.T) {
-	nh := "192.0.2.1"
-	origin := "igp"
-	routes := []model.Route{
-		{
-			Prefix:  "10.0.0.0/24",
-			PathID:  0,
-			NextHop: &nh,
-			ASPath:  []any{64500, 65000},
-			Origin:  &origin,
-		},
-		{
-			Prefix:  "10.0.0.0/24",
-			PathID:  1,
-			NextHop: &nh,
-			ASPath:  []any{64501, 65000},
-			Origin:  &origin,
-		},
-	}
-	result := GeneratePlainText("10.0.0.0/24", "router1", routes)
-
-	// Path #1 with path_id=0 should be marked best even with multiple paths
-	if !contains(result, "Path #1 (best)") {
-		t.Fatalf("expected Path #1 to be marked as best, got:\n%s", result)
-	}
-	// Path #2 should NOT be marked best
-	if contains(result, "Path #2 (best)") {
-		t.Fatalf("Path #2 should not be marked as best, got:\n%s", result)
-	}
-}
-
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && searchString(s, substr)
-}
-
-func searchString(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/pobradovic08/route-beacon/internal/model"
+)
+
+// GetPrefixTimeline returns the state intervals of every path of an exact
+// prefix on a router, together with the outages and availability over
+// [from, to]. The state at from is looked up in the events since since.
+func (db *DB) GetPrefixTimeline(ctx context.Context, routerID, prefix string, from, to, since time.Time) (*model.PrefixTimelineResponse, error) {
+	intervals, err := db.routeIntervals(ctx, HistoryQuery{
+		RouterIDs: []string{routerID},
+		Prefix:    prefix,
+		From:      from,
+		To:        to,
+		Since:     since,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buildTimeline(intervals, from, to), nil
+}
+
+// buildTimeline groups intervals, ordered by path and start time as returned
+// by routeIntervals, per path and derives the outages of the prefix as the
+// gaps in their union. A missing path ID is path 0, as in current_routes.
+func buildTimeline(intervals []timedInterval, from, to time.Time) *model.PrefixTimelineResponse {
+	type span struct{ start, end time.Time }
+	var (
+		paths []model.PathTimeline
+		spans []span
+	)
+	for _, iv := range intervals {
+		spans = append(spans, span{iv.start, iv.end})
+
+		if n := len(paths); n == 0 || deref64(paths[n-1].PathID) != deref64(iv.PathID) {
+			paths = append(paths, model.PathTimeline{PathID: iv.PathID})
+		}
+		p := &paths[len(paths)-1]
+		p.AnnouncedSeconds += iv.DurationSeconds
+		p.Intervals = append(p.Intervals, iv.RouteInterval)
+	}
+
+	// Sweep the spans in start order; every gap between the covered extent
+	// and the next start is an outage.
+	slices.SortFunc(spans, func(a, b span) int { return a.start.Compare(b.start) })
+	var outages []model.Outage
+	covered := from
+	addOutage := func(end time.Time, ongoing bool) {
+		if !end.After(covered) {
+			return
+		}
+		outages = append(outages, model.Outage{
+			Start:           model.FormatTime(covered),
+			End:             model.FormatTime(end),
+			Ongoing:         ongoing,
+			DurationSeconds: end.Sub(covered).Seconds(),
+		})
+	}
+	for _, s := range spans {
+		addOutage(s.start, false)
+		if s.end.After(covered) {
+			covered = s.end
+		}
+	}
+	addOutage(to, true)
+
+	window := to.Sub(from).Seconds()
+	summary := model.TimelineSummary{
+		WindowSeconds: window,
+		Outages:       len(outages),
+		Intervals:     len(intervals),
+	}
+	for _, o := range outages {
+		summary.OutageSeconds += o.DurationSeconds
+	}
+	summary.AvailableSeconds = window - summary.OutageSeconds
+	if window > 0 {
+		summary.AvailabilityPercent = math.Round(summary.AvailableSeconds/window*100000) / 1000
+	}
+
+	if paths == nil {
+		paths = []model.PathTimeline{}
+	}
+	if outages == nil {
+		outages = []model.Outage{}
+	}
+	return &model.PrefixTimelineResponse{
+		From:    model.FormatTime(from),
+		To:      model.FormatTime(to),
+		Summary: summary,
+		Paths:   paths,
+		Outages: outages,
+	}
+}
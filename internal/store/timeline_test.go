@@ -0,0 +1,79 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildTimeline_OverlappingPaths(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	one, two := int64(1), int64(2)
+
+	// Path 1 is up for the first 20 minutes, path 2 from 10 to 30 and again
+	// from 50 minutes on, leaving a 20 minute outage.
+	var intervals []timedInterval
+	for _, iv := range []struct {
+		pathID     *int64
+		start, end time.Duration
+		ongoing    bool
+	}{
+		{&one, 0, 20 * time.Minute, false},
+		{&two, 10 * time.Minute, 30 * time.Minute, false},
+		{&two, 50 * time.Minute, time.Hour, true},
+	} {
+		e := announceEvent("192.0.2.1")
+		e.PathID = iv.pathID
+		start, end := from.Add(iv.start), from.Add(iv.end)
+		intervals = append(intervals, timedInterval{start, end, newRouteInterval(e, start, end, iv.ongoing)})
+	}
+
+	tl := buildTimeline(intervals, from, to)
+	if len(tl.Paths) != 2 || len(tl.Paths[1].Intervals) != 2 || tl.Paths[1].AnnouncedSeconds != 1800 {
+		t.Fatalf("unexpected paths: %+v", tl.Paths)
+	}
+	if len(tl.Outages) != 1 {
+		t.Fatalf("expected 1 outage, got %+v", tl.Outages)
+	}
+	o := tl.Outages[0]
+	if o.Start != "2025-01-01T00:30:00Z" || o.End != "2025-01-01T00:50:00Z" || o.Ongoing {
+		t.Fatalf("unexpected outage: %+v", o)
+	}
+	s := tl.Summary
+	if s.WindowSeconds != 3600 || s.OutageSeconds != 1200 || s.AvailableSeconds != 2400 {
+		t.Fatalf("unexpected summary: %+v", s)
+	}
+	if s.AvailabilityPercent != 66.667 {
+		t.Fatalf("expected 66.667%%, got %f", s.AvailabilityPercent)
+	}
+}
+
+func TestBuildTimeline_NeverAnnounced(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	tl := buildTimeline(nil, from, to)
+	if len(tl.Outages) != 1 || !tl.Outages[0].Ongoing || tl.Summary.AvailabilityPercent != 0 {
+		t.Fatalf("expected one ongoing outage, got %+v", tl)
+	}
+}
+
+func TestBuildTimeline_MissingPathIDIsPathZero(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	zero := int64(0)
+
+	// Events of path 0 may carry no path ID at all.
+	var intervals []timedInterval
+	for i, pathID := range []*int64{nil, &zero} {
+		e := announceEvent("192.0.2.1")
+		e.PathID = pathID
+		start, end := from.Add(time.Duration(i)*30*time.Minute), from.Add(time.Duration(i+1)*30*time.Minute)
+		intervals = append(intervals, timedInterval{start, end, newRouteInterval(e, start, end, i == 1)})
+	}
+
+	tl := buildTimeline(intervals, from, to)
+	if len(tl.Paths) != 1 || len(tl.Paths[0].Intervals) != 2 || tl.Paths[0].AnnouncedSeconds != 3600 {
+		t.Fatalf("expected a single path, got %+v", tl.Paths)
+	}
+}
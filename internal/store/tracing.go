@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pobradovic08/route-beacon/internal/telemetry"
+)
+
+// dbMethodPrefix is the qualified name prefix of the *DB methods, used to
+// attribute queries to the store operation that issued them.
+const dbMethodPrefix = "github.com/pobradovic08/route-beacon/internal/store.(*DB)."
+
+// queryTracer times every query, records it in the DB latency histogram and
+// wraps it in a span that is a child of the request span.
+type queryTracer struct{}
+
+type queryTraceKey struct{}
+
+type queryTrace struct {
+	operation string
+	start     time.Time
+	span      trace.Span
+}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	op := callerOperation()
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBOperationName(op),
+		semconv.DBQueryText(data.SQL),
+	}
+	if id := telemetry.RequestID(ctx); id != "" {
+		attrs = append(attrs, attribute.String("request_id", id))
+	}
+	ctx, span := telemetry.Tracer.Start(ctx, "db "+op,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return context.WithValue(ctx, queryTraceKey{}, &queryTrace{operation: op, start: time.Now(), span: span})
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	t, ok := ctx.Value(queryTraceKey{}).(*queryTrace)
+	if !ok {
+		return
+	}
+	outcome := "success"
+	if data.Err != nil {
+		outcome = "error"
+		t.span.RecordError(data.Err)
+		t.span.SetStatus(codes.Error, data.Err.Error())
+	}
+	telemetry.DBQueryDuration.WithLabelValues(t.operation, outcome).Observe(time.Since(t.start).Seconds())
+	t.span.End()
+}
+
+// callerOperation returns the name of the *DB method on the call stack, or
+// "query" when the query was not issued from one.
+func callerOperation() string {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if op, ok := operationName(f.Function); ok {
+			return op
+		}
+		if !more {
+			return "query"
+		}
+	}
+}
+
+// operationName extracts the method name from a qualified *DB method name,
+// ignoring closures within it.
+func operationName(function string) (string, bool) {
+	name, ok := strings.CutPrefix(function, dbMethodPrefix)
+	if !ok {
+		return "", false
+	}
+	name, _, _ = strings.Cut(name, ".")
+	return name, true
+}
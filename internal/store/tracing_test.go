@@ -0,0 +1,22 @@
+package store
+
+import "testing"
+
+func TestOperationName(t *testing.T) {
+	cases := []struct {
+		function string
+		want     string
+		ok       bool
+	}{
+		{dbMethodPrefix + "GetRouteHistory", "GetRouteHistory", true},
+		{dbMethodPrefix + "GetRouteHistory.func1", "GetRouteHistory", true},
+		{"github.com/pobradovic08/route-beacon/internal/store.scanRoutes", "", false},
+		{"github.com/jackc/pgx/v5.(*Conn).Query", "", false},
+	}
+	for _, c := range cases {
+		got, ok := operationName(c.function)
+		if got != c.want || ok != c.ok {
+			t.Errorf("operationName(%q) = %q, %v; want %q, %v", c.function, got, ok, c.want, c.ok)
+		}
+	}
+}
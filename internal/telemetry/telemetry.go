@@ -0,0 +1,139 @@
+// Package telemetry holds the request ID context, request metrics and
+// OpenTelemetry tracing setup shared by the HTTP handlers and the store.
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies the API in traces.
+const ServiceName = "route-beacon-api"
+
+// Tracer creates the spans of the API. It delegates to the global tracer
+// provider, so spans are dropped until SetupTracing installs an exporter.
+var Tracer trace.Tracer = otel.Tracer("github.com/pobradovic08/route-beacon")
+
+// HTTPRequestDuration observes request latency per route pattern.
+var HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "routebeacon",
+	Name:      "http_request_duration_seconds",
+	Help:      "HTTP request latency by method, route pattern and status code.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// DBQueryDuration observes database query latency per store operation.
+var DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "routebeacon",
+	Name:      "db_query_duration_seconds",
+	Help:      "Database query latency by store operation and outcome.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"operation", "outcome"})
+
+// RateLimitedRequests counts requests rejected by rate limiting per cost
+// class.
+var RateLimitedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "routebeacon",
+	Name:      "http_rate_limited_requests_total",
+	Help:      "Requests rejected by rate limiting, by cost class.",
+}, []string{"class"})
+
+// Collectors returns the metrics defined by this package for registration.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{HTTPRequestDuration, DBQueryDuration, RateLimitedRequests}
+}
+
+// RequestInfo describes a request being served. The request ID is set when
+// the request enters the API; Route, RouterID and TraceID are filled in
+// once the request has been routed, and Subject once the caller has been
+// authenticated, so that outer middleware can log them.
+type RequestInfo struct {
+	ID       string
+	Route    string
+	RouterID string
+	TraceID  string
+	Subject  string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a context carrying info.
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// Info returns the RequestInfo carried by ctx, or nil.
+func Info(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info
+}
+
+// WithRequestID returns a context carrying a RequestInfo with the given ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return WithRequestInfo(ctx, &RequestInfo{ID: id})
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	if info := Info(ctx); info != nil {
+		return info.ID
+	}
+	return ""
+}
+
+// NewRequestID returns a random 128-bit request ID in hex.
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// SetupTracing installs the global tracer provider for the given exporter:
+// "none" (or empty) disables tracing, "stdout" writes spans as JSON to
+// stdout and "otlp" sends them over OTLP/HTTP to the endpoint configured by
+// the standard OTEL_EXPORTER_OTLP_* environment variables. The returned
+// function flushes and stops the exporter.
+func SetupTracing(ctx context.Context, exporter string) (func(context.Context) error, error) {
+	var (
+		exp sdktrace.SpanExporter
+		err error
+	)
+	switch exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		exp, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s exporter: %w", exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("create resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,34 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequestIDContext(t *testing.T) {
+	ctx := context.Background()
+	if id := RequestID(ctx); id != "" {
+		t.Fatalf("expected empty ID, got %q", id)
+	}
+	if id := RequestID(WithRequestID(ctx, "abc")); id != "abc" {
+		t.Fatalf("expected 'abc', got %q", id)
+	}
+	if a, b := NewRequestID(), NewRequestID(); len(a) != 32 || a == b {
+		t.Fatalf("expected distinct 32-char IDs, got %q and %q", a, b)
+	}
+}
+
+func TestSetupTracing(t *testing.T) {
+	for _, exporter := range []string{"", "none", "stdout"} {
+		shutdown, err := SetupTracing(context.Background(), exporter)
+		if err != nil {
+			t.Fatalf("SetupTracing(%q): %v", exporter, err)
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Fatalf("shutdown(%q): %v", exporter, err)
+		}
+	}
+	if _, err := SetupTracing(context.Background(), "jaeger"); err == nil {
+		t.Fatal("expected error for unknown exporter")
+	}
+}
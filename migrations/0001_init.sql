@@ -1,11 +1,13 @@
 -- 0001_init.sql
--- Schema from route-beacon-ri for local development.
--- This file is mounted as a PostgreSQL init script by docker-compose.
+-- Schema from route-beacon-ri. Every object is created only if missing so
+-- that a database already populated by route-beacon-ri can be adopted.
+-- There is no down section: the tables hold data ingested by
+-- route-beacon-ri, which this API does not own, so 0001 cannot be reverted.
 
 CREATE EXTENSION IF NOT EXISTS btree_gist;
 
 -- Table: routers
-CREATE TABLE routers (
+CREATE TABLE IF NOT EXISTS routers (
     router_id   TEXT PRIMARY KEY,
     router_ip   INET,
     hostname    TEXT,
@@ -16,7 +18,7 @@ CREATE TABLE routers (
 );
 
 -- Table: current_routes
-CREATE TABLE current_routes (
+CREATE TABLE IF NOT EXISTS current_routes (
     router_id        TEXT      NOT NULL,
     table_name       TEXT      NOT NULL,
     afi              SMALLINT  NOT NULL CHECK (afi IN (4, 6)),
@@ -37,38 +39,38 @@ CREATE TABLE current_routes (
     PRIMARY KEY (router_id, table_name, afi, prefix, path_id)
 );
 
-CREATE INDEX idx_current_routes_prefix_gist
+CREATE INDEX IF NOT EXISTS idx_current_routes_prefix_gist
     ON current_routes USING GIST (prefix inet_ops);
 
-CREATE INDEX idx_current_routes_prefix_btree
+CREATE INDEX IF NOT EXISTS idx_current_routes_prefix_btree
     ON current_routes (prefix);
 
-CREATE INDEX idx_current_routes_router_table_afi
+CREATE INDEX IF NOT EXISTS idx_current_routes_router_table_afi
     ON current_routes (router_id, table_name, afi);
 
-CREATE INDEX idx_current_routes_origin_asn
+CREATE INDEX IF NOT EXISTS idx_current_routes_origin_asn
     ON current_routes (origin_asn);
 
-CREATE INDEX idx_current_routes_nexthop
+CREATE INDEX IF NOT EXISTS idx_current_routes_nexthop
     ON current_routes (nexthop);
 
-CREATE INDEX idx_current_routes_updated_at
+CREATE INDEX IF NOT EXISTS idx_current_routes_updated_at
     ON current_routes (updated_at DESC);
 
-CREATE INDEX idx_current_routes_comparison
+CREATE INDEX IF NOT EXISTS idx_current_routes_comparison
     ON current_routes (table_name, afi, prefix, router_id);
 
-CREATE INDEX idx_current_routes_comm_std_gin
+CREATE INDEX IF NOT EXISTS idx_current_routes_comm_std_gin
     ON current_routes USING GIN (communities_std);
 
-CREATE INDEX idx_current_routes_comm_ext_gin
+CREATE INDEX IF NOT EXISTS idx_current_routes_comm_ext_gin
     ON current_routes USING GIN (communities_ext);
 
-CREATE INDEX idx_current_routes_comm_large_gin
+CREATE INDEX IF NOT EXISTS idx_current_routes_comm_large_gin
     ON current_routes USING GIN (communities_large);
 
 -- Table: route_events (partitioned by day)
-CREATE TABLE route_events (
+CREATE TABLE IF NOT EXISTS route_events (
     event_id    BYTEA        NOT NULL,
     ingest_time TIMESTAMPTZ  NOT NULL,
     router_id   TEXT         NOT NULL,
@@ -91,11 +93,17 @@ CREATE TABLE route_events (
     PRIMARY KEY (event_id, ingest_time)
 ) PARTITION BY RANGE (ingest_time);
 
--- Create a default partition for local development
-CREATE TABLE route_events_default PARTITION OF route_events DEFAULT;
+-- Create a default partition for local development, unless partitions are
+-- already managed by route-beacon-ri
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_inherits WHERE inhparent = 'route_events'::regclass) THEN
+        CREATE TABLE route_events_default PARTITION OF route_events DEFAULT;
+    END IF;
+END $$;
 
 -- Table: rib_sync_status
-CREATE TABLE rib_sync_status (
+CREATE TABLE IF NOT EXISTS rib_sync_status (
     router_id           TEXT        NOT NULL,
     table_name          TEXT        NOT NULL,
     afi                 SMALLINT    NOT NULL,
@@ -109,7 +117,7 @@ CREATE TABLE rib_sync_status (
 );
 
 -- Materialized View: route_summary
-CREATE MATERIALIZED VIEW route_summary AS
+CREATE MATERIALIZED VIEW IF NOT EXISTS route_summary AS
 SELECT
     router_id,
     table_name,
@@ -121,5 +129,5 @@ SELECT
 FROM current_routes
 GROUP BY router_id, table_name, afi;
 
-CREATE UNIQUE INDEX idx_route_summary_key
+CREATE UNIQUE INDEX IF NOT EXISTS idx_route_summary_key
     ON route_summary (router_id, table_name, afi);
@@ -0,0 +1,17 @@
+-- 0002_api_keys.sql
+-- API keys managed by the API itself (auth.api_key_table). Only the hex
+-- SHA-256 digest of each key is stored.
+
+-- Table: api_keys
+CREATE TABLE IF NOT EXISTS api_keys (
+    name        TEXT PRIMARY KEY,
+    key_hash    TEXT        NOT NULL UNIQUE CHECK (key_hash ~ '^[0-9a-f]{64}$'),
+    scopes      TEXT[]      NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at  TIMESTAMPTZ,
+    revoked_at  TIMESTAMPTZ
+);
+
+-- migrate:down
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,47 @@
+-- 0003_routers_overview.sql
+-- Operator-maintained router metadata and the per-router overview the API
+-- reads router lists, details and health from.
+
+ALTER TABLE routers
+    ADD COLUMN IF NOT EXISTS display_name TEXT,
+    ADD COLUMN IF NOT EXISTS location     TEXT;
+
+-- View: routers_overview
+-- A router is online while it has routes or an open BMP session.
+-- all_afis_synced is NULL when no RIB sync status is known.
+CREATE OR REPLACE VIEW routers_overview AS
+SELECT
+    r.router_id,
+    r.router_ip,
+    r.hostname,
+    r.as_number,
+    r.description,
+    r.display_name,
+    r.location,
+    r.first_seen,
+    r.last_seen,
+    COALESCE(c.route_count, 0) AS route_count,
+    s.session_start_time,
+    s.sync_updated_at,
+    s.all_afis_synced
+FROM routers r
+LEFT JOIN (
+    SELECT router_id, COUNT(*) AS route_count
+    FROM current_routes
+    GROUP BY router_id
+) c ON c.router_id = r.router_id
+LEFT JOIN (
+    SELECT router_id,
+           MAX(session_start_time) AS session_start_time,
+           MAX(updated_at)         AS sync_updated_at,
+           bool_and(eor_seen)      AS all_afis_synced
+    FROM rib_sync_status
+    GROUP BY router_id
+) s ON s.router_id = r.router_id;
+
+-- migrate:down
+
+DROP VIEW IF EXISTS routers_overview;
+ALTER TABLE routers
+    DROP COLUMN IF EXISTS location,
+    DROP COLUMN IF EXISTS display_name;
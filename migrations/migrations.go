@@ -0,0 +1,13 @@
+// Package migrations embeds the versioned SQL schema migrations. Each file
+// is named NNNN_description.sql and holds the up statements, followed by
+// the down statements after a "-- migrate:down" line. Migrations without
+// down statements cannot be reverted; 0001 is one, since it adopts tables
+// owned by the ingester.
+package migrations
+
+import "embed"
+
+// FS holds the migration files.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -200,13 +200,31 @@ export const api = {
     return mapRouteLookupResponse(data);
   },
 
+  // Ping and traceroute answer with a server-sent event stream, read
+  // with useSSE.
   ping: (
-    _targetId: string,
-    _body: { destination: string; count?: number; timeout_ms?: number },
-  ) => Promise.reject(new Error("Diagnostics unavailable")),
+    targetId: string,
+    body: { destination: string; count?: number },
+    signal: AbortSignal,
+  ): Promise<Response> => {
+    const params = new URLSearchParams({ target: body.destination });
+    if (body.count) params.set("count", String(body.count));
+    return fetch(
+      `${BASE}/routers/${encodeURIComponent(targetId)}/ping?${params}`,
+      { signal, headers: { Accept: "text/event-stream" } },
+    );
+  },
 
   traceroute: (
-    _targetId: string,
-    _body: { destination: string; max_hops?: number; timeout_ms?: number },
-  ) => Promise.reject(new Error("Diagnostics unavailable")),
+    targetId: string,
+    body: { destination: string; max_hops?: number },
+    signal: AbortSignal,
+  ): Promise<Response> => {
+    const params = new URLSearchParams({ target: body.destination });
+    if (body.max_hops) params.set("max_hops", String(body.max_hops));
+    return fetch(
+      `${BASE}/routers/${encodeURIComponent(targetId)}/traceroute?${params}`,
+      { signal, headers: { Accept: "text/event-stream" } },
+    );
+  },
 };
@@ -135,6 +135,22 @@ export interface TracerouteComplete {
   reached_destination: boolean;
 }
 
+export interface ProbeLine {
+  line: string;
+}
+
+// Data of the server-sent events of the ping and traceroute streams.
+export type PingEvents = {
+  line: ProbeLine;
+  reply: PingReply;
+  summary: PingSummary;
+};
+
+export type TracerouteEvents = {
+  line: ProbeLine;
+  hop: TracerouteHop;
+};
+
 export interface SSEError {
   code: string;
   message: string;
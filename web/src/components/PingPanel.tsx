@@ -1,11 +1,23 @@
+import { useEffect, useState } from "react";
 import {
   Stack,
+  Group,
+  TextInput,
+  Button,
   Text,
+  Title,
   Card,
+  Alert,
+  Table,
 } from "@mantine/core";
 import {
   IconAlertTriangle,
+  IconPlayerPlay,
+  IconPlayerStop,
 } from "@tabler/icons-react";
+import { api } from "../api/client";
+import { useSSE } from "../hooks/useSSE";
+import type { PingEvents, PingReply, PingSummary } from "../api/types";
 
 interface PingPanelProps {
   targetId: string | null;
@@ -17,27 +29,133 @@ const cardStyle = {
   background: "var(--rb-surface)",
 };
 
-export function PingPanel(_props: PingPanelProps) {
+export function PingPanel({ targetId }: PingPanelProps) {
+  const [destination, setDestination] = useState("");
+  const [running, setRunning] = useState(false);
+  const [replies, setReplies] = useState<PingReply[]>([]);
+  const [summary, setSummary] = useState<PingSummary | null>(null);
+  const [error, setError] = useState<string | null>(null);
+  const { start, abort } = useSSE<PingEvents>();
+
+  // Stop a running ping when the router changes or the panel unmounts.
+  useEffect(() => abort, [abort, targetId]);
+
+  const handleStart = async () => {
+    if (!targetId || !destination.trim()) return;
+    setRunning(true);
+    setReplies([]);
+    setSummary(null);
+    setError(null);
+    await start(
+      (signal) => api.ping(targetId, { destination: destination.trim() }, signal),
+      {
+        onEvent: (type, data) => {
+          if (type === "reply") {
+            setReplies((prev) => [...prev, data as PingReply]);
+          } else if (type === "summary") {
+            setSummary(data as PingSummary);
+          }
+        },
+        onError: setError,
+        onComplete: () => {},
+      },
+    );
+    setRunning(false);
+  };
+
+  const handleStop = () => {
+    abort();
+    setRunning(false);
+  };
+
   return (
     <Stack gap="lg">
-      <Card padding="xl" style={cardStyle}>
-        <Stack gap="md" align="center" py="xl">
-          <IconAlertTriangle size={32} style={{ color: "var(--rb-muted)" }} />
-          <Text size="sm" fw={600} style={{ color: "var(--rb-text-secondary)" }}>
-            Unavailable
-          </Text>
-          <Text
-            size="xs"
-            fw={400}
-            ta="center"
-            maw={360}
-            style={{ color: "var(--rb-muted)", lineHeight: 1.6 }}
-          >
-            Diagnostics require a future backend component.
-            Ping functionality will be restored when a diagnostics service is available.
+      <Text size="xs" fw={400} style={{ color: "var(--rb-muted)" }}>
+        Ping an IPv4 or IPv6 address from the location of the selected router.
+      </Text>
+      <Stack gap={12}>
+        <Title order={4}>Destination</Title>
+        <Group gap="sm" align="flex-end">
+          <TextInput
+            placeholder="192.0.2.1 or 2001:db8::1"
+            value={destination}
+            onChange={(e) => setDestination(e.currentTarget.value)}
+            onKeyDown={(e) => e.key === "Enter" && !running && handleStart()}
+            disabled={!targetId || running}
+            style={{ flex: 1 }}
+            styles={{
+              input: { fontFamily: "var(--mantine-font-family-monospace)" },
+            }}
+          />
+          {running ? (
+            <Button
+              onClick={handleStop}
+              color="gray"
+              leftSection={<IconPlayerStop size={16} />}
+              w={120}
+            >
+              Stop
+            </Button>
+          ) : (
+            <Button
+              onClick={handleStart}
+              disabled={!targetId || !destination.trim()}
+              leftSection={<IconPlayerPlay size={16} />}
+              w={120}
+            >
+              Ping
+            </Button>
+          )}
+        </Group>
+      </Stack>
+
+      {error && (
+        <Alert
+          color="red"
+          variant="light"
+          icon={<IconAlertTriangle size={16} />}
+          radius="lg"
+        >
+          <Text size="sm" fw={500} ff="monospace">
+            {error}
           </Text>
-        </Stack>
-      </Card>
+        </Alert>
+      )}
+
+      {(replies.length > 0 || summary) && (
+        <Card padding="md" style={cardStyle}>
+          <Stack gap="md">
+            <Table horizontalSpacing="sm" verticalSpacing={6}>
+              <Table.Thead>
+                <Table.Tr>
+                  <Table.Th>Seq</Table.Th>
+                  <Table.Th>RTT</Table.Th>
+                  <Table.Th>TTL</Table.Th>
+                </Table.Tr>
+              </Table.Thead>
+              <Table.Tbody>
+                {replies.map((r) => (
+                  <Table.Tr key={r.seq}>
+                    <Table.Td ff="monospace">{r.seq}</Table.Td>
+                    <Table.Td ff="monospace">
+                      {r.success ? `${r.rtt_ms.toFixed(1)} ms` : "timeout"}
+                    </Table.Td>
+                    <Table.Td ff="monospace">{r.success ? r.ttl : "—"}</Table.Td>
+                  </Table.Tr>
+                ))}
+              </Table.Tbody>
+            </Table>
+            {summary && (
+              <Text size="xs" fw={500} ff="monospace" style={{ color: "var(--rb-text-secondary)" }}>
+                {summary.packets_sent} sent, {summary.packets_received} received,{" "}
+                {summary.loss_pct}% loss
+                {summary.packets_received > 0 &&
+                  ` · min/avg/max ${summary.rtt_min_ms}/${summary.rtt_avg_ms}/${summary.rtt_max_ms} ms`}
+              </Text>
+            )}
+          </Stack>
+        </Card>
+      )}
     </Stack>
   );
 }
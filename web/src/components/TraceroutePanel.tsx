@@ -1,11 +1,24 @@
+import { useEffect, useState } from "react";
 import {
   Stack,
+  Group,
+  TextInput,
+  Button,
   Text,
+  Title,
   Card,
+  Alert,
+  Table,
+  Loader,
 } from "@mantine/core";
 import {
   IconAlertTriangle,
+  IconPlayerPlay,
+  IconPlayerStop,
 } from "@tabler/icons-react";
+import { api } from "../api/client";
+import { useSSE } from "../hooks/useSSE";
+import type { TracerouteEvents, TracerouteHop } from "../api/types";
 
 interface TraceroutePanelProps {
   targetId: string | null;
@@ -17,27 +30,130 @@ const cardStyle = {
   background: "var(--rb-surface)",
 };
 
-export function TraceroutePanel(_props: TraceroutePanelProps) {
+export function TraceroutePanel({ targetId }: TraceroutePanelProps) {
+  const [destination, setDestination] = useState("");
+  const [running, setRunning] = useState(false);
+  const [hops, setHops] = useState<TracerouteHop[]>([]);
+  const [error, setError] = useState<string | null>(null);
+  const { start, abort } = useSSE<TracerouteEvents>();
+
+  // Stop a running traceroute when the router changes or the panel
+  // unmounts.
+  useEffect(() => abort, [abort, targetId]);
+
+  const handleStart = async () => {
+    if (!targetId || !destination.trim()) return;
+    setRunning(true);
+    setHops([]);
+    setError(null);
+    await start(
+      (signal) =>
+        api.traceroute(targetId, { destination: destination.trim() }, signal),
+      {
+        onEvent: (type, data) => {
+          if (type === "hop") {
+            setHops((prev) => [...prev, data as TracerouteHop]);
+          }
+        },
+        onError: setError,
+        onComplete: () => {},
+      },
+    );
+    setRunning(false);
+  };
+
+  const handleStop = () => {
+    abort();
+    setRunning(false);
+  };
+
   return (
     <Stack gap="lg">
-      <Card padding="xl" style={cardStyle}>
-        <Stack gap="md" align="center" py="xl">
-          <IconAlertTriangle size={32} style={{ color: "var(--rb-muted)" }} />
-          <Text size="sm" fw={600} style={{ color: "var(--rb-text-secondary)" }}>
-            Unavailable
-          </Text>
-          <Text
-            size="xs"
-            fw={400}
-            ta="center"
-            maw={360}
-            style={{ color: "var(--rb-muted)", lineHeight: 1.6 }}
-          >
-            Diagnostics require a future backend component.
-            Traceroute functionality will be restored when a diagnostics service is available.
+      <Text size="xs" fw={400} style={{ color: "var(--rb-muted)" }}>
+        Trace the path to an IPv4 or IPv6 address from the location of the selected router.
+      </Text>
+      <Stack gap={12}>
+        <Title order={4}>Destination</Title>
+        <Group gap="sm" align="flex-end">
+          <TextInput
+            placeholder="192.0.2.1 or 2001:db8::1"
+            value={destination}
+            onChange={(e) => setDestination(e.currentTarget.value)}
+            onKeyDown={(e) => e.key === "Enter" && !running && handleStart()}
+            disabled={!targetId || running}
+            style={{ flex: 1 }}
+            styles={{
+              input: { fontFamily: "var(--mantine-font-family-monospace)" },
+            }}
+          />
+          {running ? (
+            <Button
+              onClick={handleStop}
+              color="gray"
+              leftSection={<IconPlayerStop size={16} />}
+              w={120}
+            >
+              Stop
+            </Button>
+          ) : (
+            <Button
+              onClick={handleStart}
+              disabled={!targetId || !destination.trim()}
+              leftSection={<IconPlayerPlay size={16} />}
+              w={120}
+            >
+              Trace
+            </Button>
+          )}
+        </Group>
+      </Stack>
+
+      {error && (
+        <Alert
+          color="red"
+          variant="light"
+          icon={<IconAlertTriangle size={16} />}
+          radius="lg"
+        >
+          <Text size="sm" fw={500} ff="monospace">
+            {error}
           </Text>
-        </Stack>
-      </Card>
+        </Alert>
+      )}
+
+      {(hops.length > 0 || running) && (
+        <Card padding="md" style={cardStyle}>
+          <Stack gap="md">
+            <Table horizontalSpacing="sm" verticalSpacing={6}>
+              <Table.Thead>
+                <Table.Tr>
+                  <Table.Th>Hop</Table.Th>
+                  <Table.Th>Address</Table.Th>
+                  <Table.Th>RTT</Table.Th>
+                </Table.Tr>
+              </Table.Thead>
+              <Table.Tbody>
+                {hops.map((h) => (
+                  <Table.Tr key={h.hop_number}>
+                    <Table.Td ff="monospace">{h.hop_number}</Table.Td>
+                    <Table.Td ff="monospace">{h.address || "*"}</Table.Td>
+                    <Table.Td ff="monospace">
+                      {h.rtt_ms.length > 0
+                        ? h.rtt_ms.map((rtt) => `${rtt.toFixed(1)} ms`).join("  ")
+                        : "*"}
+                    </Table.Td>
+                  </Table.Tr>
+                ))}
+              </Table.Tbody>
+            </Table>
+            {running && (
+              <Group justify="center">
+                <Loader size="sm" color="blue" />
+              </Group>
+            )}
+          </Stack>
+        </Card>
+      )}
     </Stack>
   );
 }